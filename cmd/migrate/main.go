@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
@@ -64,5 +66,57 @@ func main() {
 		log.Fatalf("Error inserting initial data: %v\n", err)
 	}
 	log.Println("Initial data inserted successfully")
+
+	if err := applyMigrations(ctx, conn); err != nil {
+		log.Fatalf("Error applying migrations: %v\n", err)
+	}
+}
+
+func applyMigrations(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS "schema_migrations"(
+		"version" VARCHAR(255) NOT NULL PRIMARY KEY,
+		"applied_at" TIMESTAMP(0) WITH TIME zone NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join("migrations", "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := filepath.Base(file)
+		var applied bool
+		if err := conn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		sqlFile, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, string(sqlFile)); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("%s: %w", version, err)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, now())`, version); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		log.Printf("Migration applied: %s\n", version)
+	}
+	return nil
 }
 
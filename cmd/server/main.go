@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"skycontainers/internal/auth"
 	"skycontainers/internal/http/router"
 	"skycontainers/internal/repo"
+	"skycontainers/internal/unipass"
 	"skycontainers/internal/view"
 
 	"github.com/joho/godotenv"
@@ -31,8 +36,23 @@ func main() {
 		port = "8080"
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background UNIPASS lookups
+	workersDone := unipass.StartWorkers(ctx)
+
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
 	log.Printf("Server starting on :%s\n", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
+	workersDone()
 }
@@ -2,17 +2,20 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"skycontainers/internal/pagination"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
+	"skycontainers/internal/unipass"
 	"skycontainers/internal/view"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -25,17 +28,15 @@ func ListBLMarkings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	containerNo := strings.TrimSpace(r.URL.Query().Get("container_no"))
-	hblNo := strings.TrimSpace(r.URL.Query().Get("hbl_no"))
-	unassignedOnly := strings.EqualFold(r.URL.Query().Get("unassigned_only"), "1") ||
-		strings.EqualFold(r.URL.Query().Get("unassigned_only"), "true") ||
-		strings.EqualFold(r.URL.Query().Get("unassigned_only"), "on")
-	unipassStatus := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("unipass_status")))
-	data, err := blMarkingPageData(r.Context(), page, containerNo, hblNo, unassignedOnly, unipassStatus)
+	filter := parseBLMarkingFilter(r)
+	data, err := blMarkingPageData(r.Context(), page, filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if batchID, _ := strconv.ParseInt(r.URL.Query().Get("unipass_batch"), 10, 64); batchID > 0 {
+		data["UnipassBatchID"] = batchID
+	}
 
 	view.Render(w, r, "bl_markings_list.html", view.PageData{
 		Title: "BL 마킹 관리",
@@ -48,64 +49,210 @@ func PostDeleteBLMarkingsFiltered(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	containerNo := strings.TrimSpace(r.FormValue("container_no"))
-	hblNo := strings.TrimSpace(r.FormValue("hbl_no"))
-	unassignedOnly := strings.EqualFold(r.FormValue("unassigned_only"), "1") ||
-		strings.EqualFold(r.FormValue("unassigned_only"), "true") ||
-		strings.EqualFold(r.FormValue("unassigned_only"), "on")
-	unipassStatus := strings.ToLower(strings.TrimSpace(r.FormValue("unipass_status")))
+	filter := parseBLMarkingFilter(r)
 
 	repoItem := repo.BLMarking{}
-	deleted, err := repoItem.DeleteByFilters(r.Context(), containerNo, hblNo, unassignedOnly)
+	deleted, err := repoItem.DeleteByFilters(r.Context(), filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	message := "삭제 완료: " + strconv.FormatInt(deleted, 10) + "건"
-	redirectWithSuccess(w, r, buildBLMarkingListURL(containerNo, hblNo, unassignedOnly, unipassStatus), message)
+	redirectWithSuccess(w, r, buildBLMarkingListURL(filter), message)
 }
 
 func PostApplyUnipassFiltered(w http.ResponseWriter, r *http.Request) {
-	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceBLMarkings, 0, "BL 마킹 관리")
+	if !ok {
 		return
 	}
 
-	containerNo := strings.TrimSpace(r.FormValue("container_no"))
-	hblNo := strings.TrimSpace(r.FormValue("hbl_no"))
-	unassignedOnly := strings.EqualFold(r.FormValue("unassigned_only"), "1") ||
-		strings.EqualFold(r.FormValue("unassigned_only"), "true") ||
-		strings.EqualFold(r.FormValue("unassigned_only"), "on")
-	unipassStatus := strings.ToLower(strings.TrimSpace(r.FormValue("unipass_status")))
+	filter := parseBLMarkingFilter(r)
 
 	repoItem := repo.BLMarking{}
-	targets, err := repoItem.ListForUnipassApply(r.Context(), containerNo, hblNo, unassignedOnly, unipassStatus)
+	targets, err := repoItem.ListForUnipassApply(r.Context(), filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	if len(targets) == 0 {
-		redirectWithSuccess(w, r, buildBLMarkingListURL(containerNo, hblNo, unassignedOnly, unipassStatus), "적용할 항목이 없습니다.")
+		redirectWithSuccess(w, r, buildBLMarkingListURL(filter), "적용할 항목이 없습니다.")
 		return
 	}
 
-	var updated int
-	var failed int
+	batch := repo.UnipassJobBatch{UserID: user.ID, Source: repo.UnipassBatchApply}
+	if err := batch.Create(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jobRepo := repo.UnipassJob{}
 	for _, target := range targets {
-		xmlBody, ok := fetchUnipassXML(r.Context(), target.HBLNo)
-		if !ok {
-			failed++
-			continue
+		if err := jobRepo.Enqueue(r.Context(), &batch.ID, target.ID, target.HBLNo); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		if err := repoItem.UpdateUnipassXML(r.Context(), target.ID, &xmlBody); err != nil {
-			failed++
-			continue
+	}
+
+	listURL := buildBLMarkingListURL(filter)
+	if strings.Contains(listURL, "?") {
+		listURL += "&"
+	} else {
+		listURL += "?"
+	}
+	listURL += "unipass_batch=" + strconv.FormatInt(batch.ID, 10)
+	message := "유니패스 조회 요청: " + strconv.Itoa(len(targets)) + "건 (백그라운드에서 진행됩니다)"
+	redirectWithSuccess(w, r, listURL, message)
+}
+
+func ShowUnipassBatchProgress(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	batchRepo := repo.UnipassJobBatch{}
+	batch, err := batchRepo.GetProgress(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+
+	var failedJobs []repo.UnipassJob
+	if batch.Failed > 0 {
+		jobRepo := repo.UnipassJob{}
+		failedJobs, err = jobRepo.ListFailedByBatch(r.Context(), batch.ID, 20)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		updated++
 	}
 
-	message := "유니패스 적용 완료: 성공 " + strconv.Itoa(updated) + "건, 실패 " + strconv.Itoa(failed) + "건"
-	redirectWithSuccess(w, r, buildBLMarkingListURL(containerNo, hblNo, unassignedOnly, unipassStatus), message)
+	// htmx stops polling when it receives 286.
+	if batch.IsComplete() && r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(286)
+	}
+	view.Render(w, r, "bl_markings_unipass_progress.html", view.PageData{
+		Title: "유니패스 조회 진행상황",
+		Data: map[string]interface{}{
+			"Batch":      batch,
+			"FailedJobs": failedJobs,
+		},
+	})
+}
+
+func ShowBLMarkingUnipassEvents(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.BLMarking{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, item.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	eventRepo := repo.BLUnipassEvent{}
+	events, err := eventRepo.ListByBLMarkingID(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cargoRepo := repo.BLUnipassCargo{}
+	cargo, err := cargoRepo.GetByBLMarkingID(r.Context(), id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	candidateRepo := repo.BLUnipassCandidate{}
+	candidates, err := candidateRepo.ListByBLMarkingID(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "bl_markings_unipass_events.html", view.PageData{
+		Title: "통관 진행 이력",
+		Data: map[string]interface{}{
+			"ID":         id,
+			"Cargo":      cargo,
+			"Events":     events,
+			"Candidates": candidates,
+			"OwnerID":    item.UserID,
+		},
+	})
+}
+
+func ShowBLMarkingPositionMoves(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.BLMarking{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, item.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	moveRepo := repo.BLPositionMove{}
+	moves, err := moveRepo.ListByBLMarkingID(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "bl_markings_position_moves.html", view.PageData{
+		Title: "위치 이동 이력",
+		Data: map[string]interface{}{
+			"ID":    id,
+			"Moves": moves,
+		},
+	})
+}
+
+// PostSelectUnipassCandidate resolves an HBL that UNIPASS returned several
+// cargo records for, using the record the user picked.
+func PostSelectUnipassCandidate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.BLMarking{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceBLMarkings, item.UserID, "BL 마킹 수정"); !ok {
+		return
+	}
+	editPath := "/admin/bl_markings/" + strconv.FormatInt(id, 10) + "/edit"
+	if err := r.ParseForm(); err != nil {
+		redirectWithError(w, r, editPath, "요청을 처리할 수 없습니다.")
+		return
+	}
+	cargMtNo := strings.TrimSpace(r.FormValue("carg_mt_no"))
+
+	xmlData, err := repoItem.GetUnipassXML(r.Context(), id)
+	if err != nil || xmlData == nil {
+		redirectWithError(w, r, editPath, "유니패스 조회 결과가 없습니다.")
+		return
+	}
+	resp, err := unipass.ParseCargoProgress(*xmlData)
+	if err != nil {
+		redirectWithError(w, r, editPath, "유니패스 조회 결과를 읽을 수 없습니다.")
+		return
+	}
+	cargo, ok := resp.Pick(cargMtNo, "", "")
+	if !ok || cargo == nil || strings.TrimSpace(cargo.CargMtNo) != cargMtNo {
+		redirectWithError(w, r, editPath, "선택한 화물관리번호를 찾을 수 없습니다.")
+		return
+	}
+	if err := repoItem.UpdateUnipassResult(r.Context(), id, *xmlData, unipass.CargoRow(cargo)); err != nil {
+		redirectWithError(w, r, editPath, "저장 중 오류가 발생했습니다.")
+		return
+	}
+	redirectWithSuccess(w, r, editPath, "화물관리번호 "+cargMtNo+"(으)로 지정했습니다.")
 }
 
 func ExportBLMarkings(w http.ResponseWriter, r *http.Request) {
@@ -113,15 +260,10 @@ func ExportBLMarkings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	containerNo := strings.TrimSpace(r.URL.Query().Get("container_no"))
-	hblNo := strings.TrimSpace(r.URL.Query().Get("hbl_no"))
-	unassignedOnly := strings.EqualFold(r.URL.Query().Get("unassigned_only"), "1") ||
-		strings.EqualFold(r.URL.Query().Get("unassigned_only"), "true") ||
-		strings.EqualFold(r.URL.Query().Get("unassigned_only"), "on")
-	unipassStatus := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("unipass_status")))
+	filter := parseBLMarkingFilter(r)
 
 	repoItem := repo.BLMarking{}
-	list, err := repoItem.ListForExport(r.Context(), containerNo, hblNo, unassignedOnly, unipassStatus)
+	list, err := repoItem.ListForExport(r.Context(), filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -129,7 +271,7 @@ func ExportBLMarkings(w http.ResponseWriter, r *http.Request) {
 
 	file := excelize.NewFile()
 	sheet := file.GetSheetName(0)
-	headers := []string{"컨테이너 번호", "업체", "BL 포지션", "HBL 번호", "Marks", "사용자", "생성일"}
+	headers := []string{"컨테이너 번호", "업체", "BL 포지션", "HBL 번호", "Marks", "사용자", "생성일", "통관상태", "진행상태", "입항일"}
 	for i, header := range headers {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		_ = file.SetCellValue(sheet, cell, header)
@@ -164,6 +306,11 @@ func ExportBLMarkings(w http.ResponseWriter, r *http.Request) {
 		_ = file.SetCellValue(sheet, "E"+strconv.Itoa(row), item.Marks)
 		_ = file.SetCellValue(sheet, "F"+strconv.Itoa(row), userValue)
 		_ = file.SetCellValue(sheet, "G"+strconv.Itoa(row), createdValue)
+		_ = file.SetCellValue(sheet, "H"+strconv.Itoa(row), item.CustomsStatus)
+		_ = file.SetCellValue(sheet, "I"+strconv.Itoa(row), item.ProgressStatus)
+		if item.ArrivalDate != nil {
+			_ = file.SetCellValue(sheet, "J"+strconv.Itoa(row), item.ArrivalDate.Format("2006-01-02"))
+		}
 	}
 
 	filename := "bl_markings_" + time.Now().Format("20060102_150405") + ".xlsx"
@@ -223,7 +370,7 @@ func PostCreateBLMarking(w http.ResponseWriter, r *http.Request) {
 		IsActive:     isActive,
 	}
 
-	if err := item.Create(r.Context()); err != nil {
+	if err := item.Create(r.Context(), blPositionMover(r, repo.BLMoveAdmin)); err != nil {
 		view.Render(w, r, "bl_markings_form.html", view.PageData{
 			Title: "BL 마킹 등록",
 			Error: "등록 중 오류가 발생했습니다: " + err.Error(),
@@ -319,7 +466,7 @@ func PostUpdateBLMarking(w http.ResponseWriter, r *http.Request) {
 		IsActive:     isActive,
 	}
 
-	if err := item.Update(r.Context()); err != nil {
+	if err := item.Update(r.Context(), blPositionMover(r, repo.BLMoveAdmin)); err != nil {
 		view.Render(w, r, "bl_markings_form.html", view.PageData{
 			Title: "BL 마킹 수정",
 			Error: "수정 중 오류가 발생했습니다: " + err.Error(),
@@ -331,6 +478,17 @@ func PostUpdateBLMarking(w http.ResponseWriter, r *http.Request) {
 	redirectWithSuccess(w, r, "/admin/bl_markings", "수정이 완료되었습니다.")
 }
 
+// blPositionMover identifies the logged-in user and their device for the
+// position move log.
+func blPositionMover(r *http.Request, source repo.BLPositionMoveSource) repo.BLPositionMover {
+	userID, _ := currentUserID(r.Context())
+	return repo.BLPositionMover{
+		UserID: userID,
+		Device: r.UserAgent(),
+		Source: source,
+	}
+}
+
 func PostUpdateBLMarkingStatus(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	repoItem := repo.BLMarking{}
@@ -348,7 +506,7 @@ func PostUpdateBLMarkingStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	existing.IsActive = r.FormValue("is_active") == "true"
-	if err := existing.Update(r.Context()); err != nil {
+	if err := existing.Update(r.Context(), blPositionMover(r, repo.BLMoveAdmin)); err != nil {
 		http.Error(w, "상태 변경 중 오류가 발생했습니다: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -374,83 +532,97 @@ func DeleteBLMarking(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func blMarkingPageData(ctx context.Context, page int, containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) (map[string]interface{}, error) {
+func parseBLMarkingFilter(r *http.Request) repo.BLMarkingFilter {
+	unassignedOnly := strings.EqualFold(r.FormValue("unassigned_only"), "1") ||
+		strings.EqualFold(r.FormValue("unassigned_only"), "true") ||
+		strings.EqualFold(r.FormValue("unassigned_only"), "on")
+	return repo.BLMarkingFilter{
+		ContainerNo:    strings.TrimSpace(r.FormValue("container_no")),
+		HBLNo:          strings.TrimSpace(r.FormValue("hbl_no")),
+		UnassignedOnly: unassignedOnly,
+		UnipassStatus:  strings.ToLower(strings.TrimSpace(r.FormValue("unipass_status"))),
+		CustomsStatus:  strings.TrimSpace(r.FormValue("customs_status")),
+		Sort:           strings.ToLower(strings.TrimSpace(r.FormValue("sort"))),
+	}
+}
+
+func blMarkingPageData(ctx context.Context, page int, filter repo.BLMarkingFilter) (map[string]interface{}, error) {
 	pager := pagination.NewPager(0, page, 10)
 	repoItem := repo.BLMarking{}
-	list, total, err := repoItem.List(ctx, pager, containerNo, hblNo, unassignedOnly, unipassStatus)
+	list, total, err := repoItem.List(ctx, pager, filter)
 	if err != nil {
 		return nil, err
 	}
 	pager = pagination.NewPager(total, page, 10)
 
+	cargoRepo := repo.BLUnipassCargo{}
+	customsStatuses, err := cargoRepo.ListCustomsStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profileRepo := repo.BLImportProfile{}
+	importProfiles, err := profileRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"Items":          list,
-		"Pager":          pager,
-		"ContainerNo":    containerNo,
-		"HBLNo":          hblNo,
-		"UnassignedOnly": unassignedOnly,
-		"UnipassStatus":  unipassStatus,
-		"ExportURL":      buildBLMarkingExportURL(containerNo, hblNo, unassignedOnly, unipassStatus),
-		"CargoCardURL":   buildBLMarkingCargoCardURL(containerNo, hblNo, unassignedOnly, unipassStatus),
+		"Items":           list,
+		"Pager":           pager,
+		"ContainerNo":     filter.ContainerNo,
+		"HBLNo":           filter.HBLNo,
+		"UnassignedOnly":  filter.UnassignedOnly,
+		"UnipassStatus":   filter.UnipassStatus,
+		"CustomsStatus":   filter.CustomsStatus,
+		"CustomsStatuses": customsStatuses,
+		"ImportProfiles":  importProfiles,
+		"Sort":            filter.Sort,
+		"ExportURL":       buildBLMarkingExportURL(filter),
+		"CargoCardURL":    buildBLMarkingCargoCardURL(filter),
+		"LabelURL":        buildBLMarkingURL("/admin/bl_markings/labels", filter),
 	}, nil
 }
 
-func buildBLMarkingExportURL(containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) string {
+func blMarkingFilterValues(filter repo.BLMarkingFilter) url.Values {
 	values := url.Values{}
-	if strings.TrimSpace(containerNo) != "" {
-		values.Set("container_no", strings.TrimSpace(containerNo))
+	if strings.TrimSpace(filter.ContainerNo) != "" {
+		values.Set("container_no", strings.TrimSpace(filter.ContainerNo))
 	}
-	if strings.TrimSpace(hblNo) != "" {
-		values.Set("hbl_no", strings.TrimSpace(hblNo))
+	if strings.TrimSpace(filter.HBLNo) != "" {
+		values.Set("hbl_no", strings.TrimSpace(filter.HBLNo))
 	}
-	if unassignedOnly {
+	if filter.UnassignedOnly {
 		values.Set("unassigned_only", "1")
 	}
-	if strings.EqualFold(unipassStatus, "y") || strings.EqualFold(unipassStatus, "n") {
-		values.Set("unipass_status", strings.ToLower(unipassStatus))
+	if strings.EqualFold(filter.UnipassStatus, "y") || strings.EqualFold(filter.UnipassStatus, "n") {
+		values.Set("unipass_status", strings.ToLower(filter.UnipassStatus))
 	}
-	if len(values) == 0 {
-		return "/admin/bl_markings/export"
+	if strings.TrimSpace(filter.CustomsStatus) != "" {
+		values.Set("customs_status", strings.TrimSpace(filter.CustomsStatus))
+	}
+	if filter.Sort == "customs_status" || filter.Sort == "arrival_date" {
+		values.Set("sort", filter.Sort)
 	}
-	return "/admin/bl_markings/export?" + values.Encode()
+	return values
 }
 
-func buildBLMarkingCargoCardURL(containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) string {
-	values := url.Values{}
-	if strings.TrimSpace(containerNo) != "" {
-		values.Set("container_no", strings.TrimSpace(containerNo))
-	}
-	if strings.TrimSpace(hblNo) != "" {
-		values.Set("hbl_no", strings.TrimSpace(hblNo))
-	}
-	if unassignedOnly {
-		values.Set("unassigned_only", "1")
-	}
-	if strings.EqualFold(unipassStatus, "y") || strings.EqualFold(unipassStatus, "n") {
-		values.Set("unipass_status", strings.ToLower(unipassStatus))
-	}
-	if len(values) == 0 {
-		return "/admin/bl_markings/cargo_card"
-	}
-	return "/admin/bl_markings/cargo_card?" + values.Encode()
+func buildBLMarkingExportURL(filter repo.BLMarkingFilter) string {
+	return buildBLMarkingURL("/admin/bl_markings/export", filter)
 }
 
-func buildBLMarkingListURL(containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) string {
-	values := url.Values{}
-	if strings.TrimSpace(containerNo) != "" {
-		values.Set("container_no", strings.TrimSpace(containerNo))
-	}
-	if strings.TrimSpace(hblNo) != "" {
-		values.Set("hbl_no", strings.TrimSpace(hblNo))
-	}
-	if unassignedOnly {
-		values.Set("unassigned_only", "1")
-	}
-	if strings.EqualFold(unipassStatus, "y") || strings.EqualFold(unipassStatus, "n") {
-		values.Set("unipass_status", strings.ToLower(unipassStatus))
-	}
+func buildBLMarkingCargoCardURL(filter repo.BLMarkingFilter) string {
+	return buildBLMarkingURL("/admin/bl_markings/cargo_card", filter)
+}
+
+func buildBLMarkingListURL(filter repo.BLMarkingFilter) string {
+	return buildBLMarkingURL("/admin/bl_markings", filter)
+}
+
+func buildBLMarkingURL(path string, filter repo.BLMarkingFilter) string {
+	values := blMarkingFilterValues(filter)
 	if len(values) == 0 {
-		return "/admin/bl_markings"
+		return path
 	}
-	return "/admin/bl_markings?" + values.Encode()
+	return path + "?" + values.Encode()
 }
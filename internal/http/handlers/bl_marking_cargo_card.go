@@ -1,10 +1,12 @@
 package handlers
 
 import (
-	"encoding/xml"
+	"html/template"
 	"net/http"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
+	"skycontainers/internal/scancode"
+	"skycontainers/internal/unipass"
 	"skycontainers/internal/view"
 	"strings"
 	"time"
@@ -26,6 +28,19 @@ type cargoCardItem struct {
 	MarksColor    string
 	HasUnipass    bool
 	UnipassStatus string
+	Barcode       scancode.Matrix
+	QRCode        scancode.Matrix
+	Layout        *cargoCardLayout
+}
+
+// BarcodeSVG is the Code128 symbol of the HBL number for the templates.
+func (c cargoCardItem) BarcodeSVG() template.HTML {
+	return template.HTML(c.Barcode.SVG(10))
+}
+
+// QRSVG is the QR symbol of the HBL number for the templates.
+func (c cargoCardItem) QRSVG() template.HTML {
+	return template.HTML(c.QRCode.SVG(2))
 }
 
 func ShowBLCargoCards(w http.ResponseWriter, r *http.Request) {
@@ -33,55 +48,46 @@ func ShowBLCargoCards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	containerNo := strings.TrimSpace(r.URL.Query().Get("container_no"))
-	hblNo := strings.TrimSpace(r.URL.Query().Get("hbl_no"))
-	unassignedOnly := strings.EqualFold(r.URL.Query().Get("unassigned_only"), "1") ||
-		strings.EqualFold(r.URL.Query().Get("unassigned_only"), "true") ||
-		strings.EqualFold(r.URL.Query().Get("unassigned_only"), "on")
-	unipassStatus := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("unipass_status")))
+	filter := parseBLMarkingFilter(r)
 
-	repoItem := repo.BLMarking{}
-	list, err := repoItem.ListForCargoCard(r.Context(), containerNo, hblNo, unassignedOnly, unipassStatus)
+	items, err := listCargoCardItems(r, filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	items := make([]cargoCardItem, 0, len(list))
-	for _, item := range list {
-		card := buildCargoCard(item)
-		items = append(items, card)
-	}
-
 	view.Render(w, r, "bl_markings_cargo_card.html", view.PageData{
 		Title: "화물카드",
 		Data: map[string]interface{}{
-			"Items":     items,
-			"PrintedAt": time.Now().Format("2006-01-02"),
+			"Items":        items,
+			"PrintedAt":    time.Now().Format("2006-01-02"),
+			"FilterParams": blMarkingFilterValues(filter),
+			"LabelURL":     buildBLMarkingURL("/admin/bl_markings/labels", filter),
 		},
 	})
 }
 
 func buildCargoCard(item repo.BLMarking) cargoCardItem {
-	values := map[string]string{}
+	var cargo *unipass.CargoProgress
 	if item.FrmUnipass != nil {
-		values = parseUnipassValues(*item.FrmUnipass)
+		// While the HBL still waits for a user to choose among several
+		// records, the UNIPASS fields stay blank rather than show another
+		// cargo's arrival and weights.
+		if resp, err := unipass.ParseCargoProgress(*item.FrmUnipass); err == nil {
+			if picked, ok := resp.Pick(item.CargMtNo, item.ContainerNo, ""); ok {
+				cargo = picked
+			}
+		}
+	}
+	if cargo == nil {
+		cargo = &unipass.CargoProgress{}
 	}
 
-	arrival := formatUnipassDate(firstValue(values, "etprdt", "etprdt", "prcsdttm", "arrvdt", "inptdt", "etprde"))
-	vessel := firstValue(values, "shipnm", "vslname", "vslname", "shipname")
-	cargoNo := firstValue(values, "cargmtno", "cargmtno", "cargono")
-	volume := combineValueUnit(
-		firstValue(values, "msrm", "m3", "vol", "volume"),
-		firstValue(values, "msrmut", "m3ut", "volut", "volumeut"),
-	)
-	weight := strings.TrimSpace(firstValue(values, "ttwg", "wght", "weight", "totwt", "wgt"))
-	quantity := strings.TrimSpace(firstValue(values, "pckgcnt"))
-	product := firstValue(values, "prnm", "prdtnm", "goodsnm", "gdsnm", "goodsname")
-	product = truncateRunes(product, 32)
-	containerNo := firstValue(values, "cntrno", "cntrno", "cntrno1", "container")
+	arrival := ""
+	if date := cargo.ArrivalDate(); date != nil {
+		arrival = date.Format("2006-01-02")
+	}
 	consignee := chooseValue(item.Cnee, item.SupplierName)
-	forwarder := firstValue(values, "frwrentsconm", "shcoflco", "frwrnm", "forwarder", "frwrdnm")
 
 	hasUnipass := item.FrmUnipass != nil && strings.TrimSpace(*item.FrmUnipass) != ""
 	status := "N"
@@ -89,22 +95,30 @@ func buildCargoCard(item repo.BLMarking) cargoCardItem {
 		status = "Y"
 	}
 
+	// Codes that cannot be encoded (e.g. non-ASCII characters for Code128)
+	// are left empty and the card prints the HBL number as text only.
+	barcode, _ := scancode.Code128(item.HBLNo)
+	qrCode, _ := scancode.QR(item.HBLNo)
+
 	return cargoCardItem{
 		BLNo:          item.HBLNo,
 		ArrivalDate:   arrival,
-		VesselName:    vessel,
-		CargoNo:       cargoNo,
-		Volume:        volume,
-		Weight:        weight,
-		Quantity:      quantity,
-		ProductName:   product,
-		ContainerNo:   chooseValue(containerNo, item.ContainerNo),
+		VesselName:    strings.TrimSpace(cargo.ShipNm),
+		CargoNo:       strings.TrimSpace(cargo.CargMtNo),
+		Volume:        strings.TrimSpace(cargo.Msrm),
+		Weight:        strings.TrimSpace(cargo.Ttwg),
+		Quantity:      strings.TrimSpace(cargo.PckGcnt),
+		ProductName:   truncateRunes(cargo.Prnm, 32),
+		ContainerNo:   chooseValue(cargo.CntrNo, item.ContainerNo),
 		Consignee:     consignee,
-		Forwarder:     forwarder,
+		Forwarder:     cargo.Forwarder(),
 		Marks:         item.Marks,
 		MarksColor:    normalizeColor(item.SupplierColor),
 		HasUnipass:    hasUnipass,
 		UnipassStatus: status,
+		Barcode:       barcode,
+		QRCode:        qrCode,
+		Layout:        defaultCargoCardLayout(),
 	}
 }
 
@@ -120,48 +134,6 @@ func truncateRunes(value string, limit int) string {
 	return strings.TrimSpace(string(runes[:limit]))
 }
 
-func parseUnipassValues(xmlBody string) map[string]string {
-	values := make(map[string]string)
-	decoder := xml.NewDecoder(strings.NewReader(xmlBody))
-	var stack []string
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			break
-		}
-		switch t := token.(type) {
-		case xml.StartElement:
-			stack = append(stack, strings.ToLower(t.Name.Local))
-		case xml.EndElement:
-			if len(stack) > 0 {
-				stack = stack[:len(stack)-1]
-			}
-		case xml.CharData:
-			if len(stack) == 0 {
-				continue
-			}
-			value := strings.TrimSpace(string(t))
-			if value == "" {
-				continue
-			}
-			key := stack[len(stack)-1]
-			if _, ok := values[key]; !ok {
-				values[key] = value
-			}
-		}
-	}
-	return values
-}
-
-func firstValue(values map[string]string, keys ...string) string {
-	for _, key := range keys {
-		if value := strings.TrimSpace(values[strings.ToLower(key)]); value != "" {
-			return value
-		}
-	}
-	return ""
-}
-
 func chooseValue(primary string, fallback string) string {
 	if strings.TrimSpace(primary) != "" {
 		return primary
@@ -169,38 +141,6 @@ func chooseValue(primary string, fallback string) string {
 	return strings.TrimSpace(fallback)
 }
 
-func formatUnipassDate(value string) string {
-	value = strings.TrimSpace(value)
-	if len(value) >= 8 && isAllDigits(value[:8]) {
-		return value[:4] + "-" + value[4:6] + "-" + value[6:8]
-	}
-	if len(value) >= 10 && value[4] == '-' && value[7] == '-' {
-		return value[:10]
-	}
-	return value
-}
-
-func isAllDigits(value string) bool {
-	for _, r := range value {
-		if r < '0' || r > '9' {
-			return false
-		}
-	}
-	return true
-}
-
-func combineValueUnit(value string, unit string) string {
-	value = strings.TrimSpace(value)
-	unit = strings.TrimSpace(unit)
-	if value == "" {
-		return ""
-	}
-	if unit == "" {
-		return value
-	}
-	return value + " " + unit
-}
-
 func normalizeColor(value string) string {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"skycontainers/internal/repo"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultCargoCardTitle = "수입화물품목카드"
+
+// cargoCardField is one row block of the card. Both the HTML template and the
+// PDF renderer switch on Key.
+type cargoCardField struct {
+	Key   string
+	Label string
+}
+
+var cargoCardFields = []cargoCardField{
+	{Key: "arrival", Label: "입항일 / 선(기)명"},
+	{Key: "bl_no", Label: "B/L 번호"},
+	{Key: "barcode", Label: "바코드 / QR"},
+	{Key: "metrics", Label: "수량 / 용적 / 중량"},
+	{Key: "cargo_no", Label: "화물관리번호"},
+	{Key: "product", Label: "품명"},
+	{Key: "container", Label: "컨테이너"},
+	{Key: "consignee", Label: "수화인"},
+	{Key: "forwarder", Label: "포워더"},
+	{Key: "extra", Label: "추가 항목"},
+	{Key: "marks", Label: "Marks"},
+}
+
+type cargoCardExtra struct {
+	Label string
+	Value string
+}
+
+// cargoCardLayout is the resolved layout one card is printed with.
+type cargoCardLayout struct {
+	SupplierID      int64
+	Custom          bool
+	Title           string
+	Fields          []string
+	Extras          []cargoCardExtra
+	ShowOverlay     bool
+	HasLogo         bool
+	LogoURL         string
+	Logo            []byte
+	LogoContentType string
+}
+
+func defaultCargoCardLayout() *cargoCardLayout {
+	fields := make([]string, 0, len(cargoCardFields))
+	for _, field := range cargoCardFields {
+		if field.Key != "extra" {
+			fields = append(fields, field.Key)
+		}
+	}
+	return &cargoCardLayout{Title: defaultCargoCardTitle, Fields: fields, ShowOverlay: true}
+}
+
+func cargoCardLayoutFromTemplate(tmpl *repo.CargoCardTemplate) *cargoCardLayout {
+	if tmpl == nil {
+		return defaultCargoCardLayout()
+	}
+	layout := &cargoCardLayout{
+		SupplierID:      tmpl.SupplierID,
+		Custom:          true,
+		Title:           strings.TrimSpace(tmpl.Title),
+		Fields:          parseCargoCardFields(tmpl.Fields),
+		Extras:          parseCargoCardExtras(tmpl.ExtraFields),
+		ShowOverlay:     tmpl.ShowOverlay,
+		HasLogo:         tmpl.HasLogo,
+		Logo:            tmpl.Logo,
+		LogoContentType: tmpl.LogoContentType,
+	}
+	if layout.Title == "" {
+		layout.Title = defaultCargoCardTitle
+	}
+	if len(layout.Fields) == 0 {
+		layout.Fields = defaultCargoCardLayout().Fields
+	}
+	if layout.HasLogo {
+		layout.LogoURL = supplierCardTemplatePath(tmpl.SupplierID) + "/logo?v=" + strconv.FormatInt(tmpl.UpdatedAt.Unix(), 10)
+	}
+	return layout
+}
+
+// Has reports whether the layout prints the field.
+func (l *cargoCardLayout) Has(key string) bool {
+	for _, field := range l.Fields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCargoCardFields keeps known keys in the stored order and drops
+// duplicates.
+func parseCargoCardFields(value string) []string {
+	seen := map[string]bool{}
+	var fields []string
+	for _, part := range strings.Split(value, ",") {
+		key := strings.TrimSpace(part)
+		if key == "" || seen[key] || !isCargoCardField(key) {
+			continue
+		}
+		seen[key] = true
+		fields = append(fields, key)
+	}
+	return fields
+}
+
+func isCargoCardField(key string) bool {
+	for _, field := range cargoCardFields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCargoCardExtras reads "항목: 값" lines. A line without a colon is a
+// value with an empty label.
+func parseCargoCardExtras(value string) []cargoCardExtra {
+	var extras []cargoCardExtra
+	for _, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		extra := cargoCardExtra{Value: line}
+		if idx := strings.Index(line, ":"); idx >= 0 {
+			extra.Label = strings.TrimSpace(line[:idx])
+			extra.Value = strings.TrimSpace(line[idx+1:])
+		}
+		extras = append(extras, extra)
+	}
+	return extras
+}
+
+// orderCargoCardFields builds the stored field list from the checked keys and
+// their order inputs; fields with the same order keep the registry order.
+func orderCargoCardFields(checked []string, order func(key string) string) string {
+	selected := map[string]bool{}
+	for _, key := range checked {
+		selected[strings.TrimSpace(key)] = true
+	}
+	type entry struct {
+		key   string
+		order int
+		index int
+	}
+	var entries []entry
+	for idx, field := range cargoCardFields {
+		if !selected[field.Key] {
+			continue
+		}
+		position, err := strconv.Atoi(strings.TrimSpace(order(field.Key)))
+		if err != nil {
+			position = idx + 1
+		}
+		entries = append(entries, entry{key: field.Key, order: position, index: idx})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].order != entries[j].order {
+			return entries[i].order < entries[j].order
+		}
+		return entries[i].index < entries[j].index
+	})
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.key)
+	}
+	return strings.Join(keys, ",")
+}
+
+// loadCargoCardLayouts resolves the layout of every supplier in the list,
+// using the default layout for suppliers without a template.
+func loadCargoCardLayouts(ctx context.Context, supplierIDs []int64) (map[int64]*cargoCardLayout, error) {
+	templateRepo := repo.CargoCardTemplate{}
+	list, err := templateRepo.ListBySupplierIDs(ctx, supplierIDs)
+	if err != nil {
+		return nil, err
+	}
+	layouts := make(map[int64]*cargoCardLayout, len(list))
+	for i := range list {
+		layouts[list[i].SupplierID] = cargoCardLayoutFromTemplate(&list[i])
+	}
+	return layouts, nil
+}
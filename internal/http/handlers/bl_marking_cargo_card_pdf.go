@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"skycontainers/internal/pdfdoc"
+	"skycontainers/internal/policy"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Card geometry in millimetres for a full A4 card. Smaller cells (A5 paper or
+// N-up) scale every length and font size by cell width / cargoCardBaseWidth.
+const (
+	cargoCardBaseWidth  = 210.0
+	cargoCardBaseHeight = 297.0
+	cargoCardPadding    = 5.0
+	cargoCardTitleH     = 22.0
+)
+
+// cargoCardColumns mirrors the colgroup of bl_markings_cargo_card.html.
+var cargoCardColumns = []float64{0.19, 0.20, 0.15, 0.20, 0.12, 0.19}
+
+func ExportBLCargoCardsPDF(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	filter := parseBLMarkingFilter(r)
+	backURL := buildBLMarkingCargoCardURL(filter)
+
+	items, err := listCargoCardItems(r, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(items) == 0 {
+		redirectWithError(w, r, backURL, "출력할 데이터가 없습니다.")
+		return
+	}
+
+	size := "A4"
+	if strings.EqualFold(r.URL.Query().Get("size"), "a5") {
+		size = "A5"
+	}
+	perPage := 1
+	switch r.URL.Query().Get("per_page") {
+	case "2":
+		perPage = 2
+	case "4":
+		perPage = 4
+	}
+
+	pdf, err := renderCargoCardsPDF(items, size, perPage)
+	if err != nil {
+		redirectWithError(w, r, backURL, "PDF 생성 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+
+	filename := "cargo_cards_" + time.Now().Format("20060102_150405") + ".pdf"
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+filename+"\"")
+	_, _ = w.Write(pdf)
+}
+
+// renderCargoCardsPDF lays the cards out perPage at a time. Two cards go side
+// by side on a landscape page and four in a 2x2 grid, so every card keeps the
+// A-series aspect ratio of the single-card layout.
+func renderCargoCardsPDF(items []cargoCardItem, size string, perPage int) ([]byte, error) {
+	orientation := "P"
+	if perPage == 2 {
+		orientation = "L"
+	}
+	pdf, err := pdfdoc.New(orientation, size)
+	if err != nil {
+		return nil, err
+	}
+	pdf.SetTitle("수입화물품목카드", true)
+
+	pageW, pageH := pdf.GetPageSize()
+	cols, rows := 1, 1
+	switch perPage {
+	case 2:
+		cols = 2
+	case 4:
+		cols, rows = 2, 2
+	}
+	cellW := pageW / float64(cols)
+	cellH := pageH / float64(rows)
+
+	for idx, item := range items {
+		slot := idx % perPage
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		x := float64(slot%cols) * cellW
+		y := float64(slot/cols) * cellH
+		drawCargoCard(pdf, item, x, y, cellW, cellH)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cargoCardRowHeights are the A4 heights of the fixed rows; Marks takes
+// whatever height is left.
+var cargoCardRowHeights = map[string]float64{
+	"arrival":   16,
+	"bl_no":     24,
+	"barcode":   28,
+	"metrics":   20,
+	"cargo_no":  14,
+	"product":   14,
+	"container": 14,
+	"consignee": 14,
+	"forwarder": 14,
+	"extra":     14,
+}
+
+func drawCargoCard(pdf *gofpdf.Fpdf, item cargoCardItem, x, y, w, h float64) {
+	layout := item.Layout
+	if layout == nil {
+		layout = defaultCargoCardLayout()
+	}
+	scale := w / cargoCardBaseWidth
+	if hs := h / cargoCardBaseHeight; hs < scale {
+		scale = hs
+	}
+	pad := cargoCardPadding * scale
+
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetLineWidth(0.2)
+	pdf.Rect(x, y, w, h, "D")
+
+	left := x + pad
+	width := w - pad*2
+	top := y + pad
+
+	titleH := cargoCardTitleH * scale
+	drawCardText(pdf, layout.Title, left, top, width, titleH, 28*scale, true, "C")
+	titleW := pdf.GetStringWidth(layout.Title)
+	pdf.SetLineWidth(0.5 * scale)
+	pdf.Line(left+(width-titleW)/2, top+titleH*0.8, left+(width+titleW)/2, top+titleH*0.8)
+	if name := registerCardLogo(pdf, layout); name != "" {
+		logoH := titleH * 0.8
+		pdf.ImageOptions(name, left, top+(titleH-logoH)/2, 0, logoH, false, gofpdf.ImageOptions{}, 0, "")
+	}
+	top += titleH + 3*scale
+
+	colX := make([]float64, len(cargoCardColumns)+1)
+	colX[0] = left
+	for i, ratio := range cargoCardColumns {
+		colX[i+1] = colX[i] + width*ratio
+	}
+	span := func(from, to int) (float64, float64) {
+		return colX[from], colX[to] - colX[from]
+	}
+
+	tableBottom := y + h - pad
+	used := 0.0
+	for _, field := range layout.Fields {
+		if field == "extra" {
+			used += cargoCardRowHeights[field] * scale * float64(len(layout.Extras))
+		} else {
+			used += cargoCardRowHeights[field] * scale
+		}
+	}
+	marksH := tableBottom - top - used
+	if minH := 20 * scale; marksH < minH {
+		marksH = minH
+	}
+	if !layout.Has("marks") {
+		tableBottom = top + used
+	}
+
+	pdf.SetLineWidth(0.2)
+	header := func(label string, col int, rowY, rowHeight float64) {
+		cx, cw := span(col, col+1)
+		pdf.SetFillColor(242, 242, 242)
+		pdf.Rect(cx, rowY, cw, rowHeight, "FD")
+		drawCardText(pdf, label, cx, rowY, cw, rowHeight, 20*scale, true, "C")
+	}
+	value := func(text string, from, to int, rowY, rowHeight, fontSize float64, bold bool, align string, color string) {
+		cx, cw := span(from, to)
+		pdf.Rect(cx, rowY, cw, rowHeight, "D")
+		if color != "" {
+			pdfdoc.SetHexColor(pdf, color)
+		}
+		drawCardText(pdf, text, cx, rowY, cw, rowHeight, fontSize, bold, align)
+		pdf.SetTextColor(0, 0, 0)
+	}
+	long := func(label, text string, rowY, rowHeight float64) {
+		header(label, 0, rowY, rowHeight)
+		cx, cw := span(1, 6)
+		pdf.Rect(cx, rowY, cw, rowHeight, "D")
+		drawCardWrapped(pdf, text, cx, rowY, cw, rowHeight, 18*scale, true, false)
+	}
+
+	rowY := top
+	for _, field := range layout.Fields {
+		rowH := cargoCardRowHeights[field] * scale
+		switch field {
+		case "arrival":
+			header("입항일", 0, rowY, rowH)
+			value(item.ArrivalDate, 1, 2, rowY, rowH, 17*scale, false, "C", "")
+			header("선(기)명", 2, rowY, rowH)
+			value(item.VesselName, 3, 6, rowY, rowH, 17*scale, false, "C", "")
+		case "bl_no":
+			header("B/L 번호", 0, rowY, rowH)
+			value(item.BLNo, 1, 6, rowY, rowH, 54*scale, false, "C", "")
+		case "barcode":
+			header("바코드", 0, rowY, rowH)
+			drawCardCodes(pdf, item, colX[1], rowY, colX[6]-colX[1], rowH, scale)
+		case "metrics":
+			header("수량", 0, rowY, rowH)
+			value(item.Quantity, 1, 2, rowY, rowH, 32*scale, true, "C", item.MarksColor)
+			header("용적", 2, rowY, rowH)
+			value(item.Volume, 3, 4, rowY, rowH, 32*scale, true, "C", "")
+			header("중량", 4, rowY, rowH)
+			value(item.Weight, 5, 6, rowY, rowH, 32*scale, true, "C", "")
+		case "cargo_no":
+			long("화물관리", item.CargoNo, rowY, rowH)
+		case "product":
+			long("품명", item.ProductName, rowY, rowH)
+		case "container":
+			long("컨테이너", item.ContainerNo, rowY, rowH)
+		case "consignee":
+			long("수화인", item.Consignee, rowY, rowH)
+		case "forwarder":
+			long("포워더", item.Forwarder, rowY, rowH)
+		case "extra":
+			for _, extra := range layout.Extras {
+				long(extra.Label, extra.Value, rowY, rowH)
+				rowY += rowH
+			}
+			continue
+		case "marks":
+			rowH = marksH
+			header("Marks", 0, rowY, rowH)
+			cx, cw := span(1, 6)
+			pdf.Rect(cx, rowY, cw, rowH, "D")
+			if item.MarksColor != "" {
+				pdfdoc.SetHexColor(pdf, item.MarksColor)
+			}
+			drawCardWrapped(pdf, item.Marks, cx+3*scale, rowY+3*scale, cw-6*scale, rowH-6*scale, 66*scale, true, true)
+			pdf.SetTextColor(0, 0, 0)
+		}
+		rowY += rowH
+	}
+
+	pdf.SetLineWidth(0.6 * scale)
+	pdf.Rect(left, top, width, tableBottom-top, "D")
+
+	if item.Quantity != "" && layout.ShowOverlay {
+		pdf.SetAlpha(0.35, "Normal")
+		if item.MarksColor != "" {
+			pdfdoc.SetHexColor(pdf, item.MarksColor)
+		}
+		overlayH := 80 * scale
+		drawCardText(pdf, item.Quantity, left, y+h/2, width-12*scale, overlayH, 240*scale, true, "R")
+		pdf.SetAlpha(1, "Normal")
+		pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+// registerCardLogo adds the supplier logo to the document once and returns
+// its image name, or "" when the layout has no usable logo.
+func registerCardLogo(pdf *gofpdf.Fpdf, layout *cargoCardLayout) string {
+	if !layout.HasLogo || len(layout.Logo) == 0 {
+		return ""
+	}
+	imageType := "PNG"
+	if layout.LogoContentType == "image/jpeg" {
+		imageType = "JPG"
+	}
+	name := "supplier-logo-" + strconv.FormatInt(layout.SupplierID, 10)
+	if pdf.GetImageInfo(name) == nil {
+		pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(layout.Logo))
+		if pdf.Err() {
+			// A broken logo must not fail the whole print job.
+			pdf.ClearError()
+			return ""
+		}
+	}
+	return name
+}
+
+// drawCardCodes fills the barcode row: Code128 with the HBL number printed
+// under it, and the QR code at the right end.
+func drawCardCodes(pdf *gofpdf.Fpdf, item cargoCardItem, x, y, w, h, scale float64) {
+	pdf.Rect(x, y, w, h, "D")
+	pad := 1.5 * scale
+	qrSide := h - pad*2
+	textH := 5 * scale
+	barW := w - qrSide - pad*4
+	if item.Barcode.Width == 0 {
+		drawCardText(pdf, item.BLNo, x, y, barW, h, 14*scale, false, "C")
+	} else {
+		pdfdoc.DrawCode(pdf, item.Barcode, x+pad, y+pad, barW, h-pad*2-textH, 10)
+		drawCardText(pdf, item.BLNo, x+pad, y+h-pad-textH, barW, textH, 11*scale, false, "C")
+	}
+	pdfdoc.DrawCode(pdf, item.QRCode, x+w-pad-qrSide, y+pad, qrSide, qrSide, 2)
+}
+
+// drawCardText writes one line inside the box, shrinking the font until the
+// text fits the width.
+func drawCardText(pdf *gofpdf.Fpdf, text string, x, y, w, h, fontSize float64, bold bool, align string) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\n", " "))
+	maxW := w - 2*pdf.GetCellMargin()
+	for {
+		pdf.SetFont(pdfdoc.FontFamily, style, fontSize)
+		if fontSize <= 4 || pdf.GetStringWidth(text) <= maxW {
+			break
+		}
+		fontSize *= 0.92
+	}
+	if lineH := fontSize * 25.4 / 72; lineH > h {
+		pdf.SetFont(pdfdoc.FontFamily, style, h*72/25.4)
+	}
+	pdf.SetXY(x, y)
+	pdf.CellFormat(w, h, text, "", 0, align+"M", false, 0, "")
+}
+
+// drawCardWrapped wraps text to the box width, shrinking the font until all
+// lines fit the height. Lines start at the top when top is set and are
+// centred vertically otherwise.
+func drawCardWrapped(pdf *gofpdf.Fpdf, text string, x, y, w, h, fontSize float64, bold bool, top bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	var lines []string
+	lineH := 0.0
+	for {
+		pdf.SetFont(pdfdoc.FontFamily, style, fontSize)
+		lineH = fontSize * 25.4 / 72 * 1.1
+		lines = lines[:0]
+		for _, paragraph := range strings.Split(text, "\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if paragraph == "" {
+				lines = append(lines, "")
+				continue
+			}
+			lines = append(lines, pdf.SplitText(paragraph, w)...)
+		}
+		if fontSize <= 4 || float64(len(lines))*lineH <= h {
+			break
+		}
+		fontSize *= 0.92
+	}
+
+	startY := y
+	if !top {
+		startY = y + (h-float64(len(lines))*lineH)/2
+	}
+	for i, line := range lines {
+		pdf.SetXY(x, startY+float64(i)*lineH)
+		pdf.CellFormat(w, lineH, line, "", 0, "LM", false, 0, "")
+	}
+}
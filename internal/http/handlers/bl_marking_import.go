@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"skycontainers/internal/pagination"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/xuri/excelize/v2"
+)
+
+// ListBLMarkingImports shows who uploaded which file and what became of it.
+func ListBLMarkingImports(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch status {
+	case repo.ImportPreview, repo.ImportCommitted, repo.ImportCancelled, repo.ImportRolledBack:
+	default:
+		status = ""
+	}
+
+	pager := pagination.NewPager(0, page, 20)
+	batchRepo := repo.ImportBatch{}
+	list, total, err := batchRepo.List(r.Context(), pager, status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pager = pagination.NewPager(total, page, 20)
+
+	view.Render(w, r, "bl_markings_imports.html", view.PageData{
+		Title: "BL 마킹 업로드 이력",
+		Data: map[string]interface{}{
+			"Items":  list,
+			"Pager":  pager,
+			"Status": status,
+		},
+	})
+}
+
+func ShowBLMarkingImport(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	batchRepo := repo.ImportBatch{}
+	batch, err := batchRepo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, batch.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+	rows, err := batchRepo.ListRows(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "bl_markings_import_preview.html", view.PageData{
+		Title: "BL 마킹 업로드 확인",
+		Data: map[string]interface{}{
+			"Batch": batch,
+			"Rows":  rows,
+		},
+	})
+}
+
+// PostCommitBLMarkingImport applies the new and update rows of a previewed
+// upload and queues their UNIPASS lookups. Everything runs in one
+// transaction, so a failing row leaves bl_markings untouched.
+func PostCommitBLMarkingImport(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	batchRepo := repo.ImportBatch{}
+	batch, err := batchRepo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceBLMarkings, batch.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+	userID, ok := currentUserID(r.Context())
+	if !ok {
+		http.Error(w, "로그인 사용자 정보를 찾을 수 없습니다.", http.StatusUnauthorized)
+		return
+	}
+	previewPath := "/admin/bl_markings/imports/" + strconv.FormatInt(id, 10)
+
+	result, err := batchRepo.Commit(r.Context(), id, userID, r.UserAgent())
+	if err != nil {
+		var rowErr *repo.ImportCommitError
+		switch {
+		case errors.Is(err, repo.ErrImportNotPreview):
+			redirectWithError(w, r, previewPath, "이미 확정되었거나 취소된 업로드입니다.")
+		case errors.Is(err, pgx.ErrNoRows):
+			redirectWithError(w, r, "/admin/bl_markings", "찾을 수 없는 업로드입니다.")
+		case errors.As(err, &rowErr):
+			redirectWithError(w, r, previewPath,
+				strconv.Itoa(rowErr.RowNo)+"행 처리 중 오류가 발생해 전체 업로드를 취소했습니다. 변경된 내용은 없습니다: "+rowErr.Err.Error())
+		default:
+			redirectWithError(w, r, previewPath, "확정 중 오류가 발생했습니다. 변경된 내용은 없습니다: "+err.Error())
+		}
+		return
+	}
+
+	message := "업로드 확정: " + strconv.Itoa(result.Inserted) + "건 등록"
+	if result.Updated > 0 {
+		message += ", " + strconv.Itoa(result.Updated) + "건 갱신"
+	}
+	message += " (유니패스 조회는 백그라운드에서 진행됩니다)"
+	redirectWithSuccess(w, r, "/admin/bl_markings?unipass_batch="+strconv.FormatInt(result.UnipassBatchID, 10), message)
+}
+
+// ExportBLMarkingImportRejected downloads the rows of an upload that could not
+// be applied. The first four columns match the built-in upload layout so the
+// file can be fixed and uploaded again; the row number and reason follow.
+func ExportBLMarkingImportRejected(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	batchRepo := repo.ImportBatch{}
+	batch, err := batchRepo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceBLMarkings, batch.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+	rows, err := batchRepo.ListRows(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	headers := []string{"HBL 번호", "수하인", "Marks", "컨테이너 번호", "원본 행", "오류 사유"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		_ = file.SetCellValue(sheet, cell, header)
+	}
+	errorStyle, _ := file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "9C0006"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	})
+
+	row := 2
+	for _, item := range rows {
+		if item.Action != repo.ImportRowError {
+			continue
+		}
+		_ = file.SetCellValue(sheet, "A"+strconv.Itoa(row), item.HBLNo)
+		_ = file.SetCellValue(sheet, "B"+strconv.Itoa(row), item.Cnee)
+		_ = file.SetCellValue(sheet, "C"+strconv.Itoa(row), item.Marks)
+		containerNo := item.SourceContainerNo
+		if containerNo == "" {
+			containerNo = item.ContainerNo
+		}
+		_ = file.SetCellValue(sheet, "D"+strconv.Itoa(row), containerNo)
+		_ = file.SetCellValue(sheet, "E"+strconv.Itoa(row), item.RowNo)
+		_ = file.SetCellValue(sheet, "F"+strconv.Itoa(row), item.Message)
+		_ = file.SetCellStyle(sheet, "F"+strconv.Itoa(row), "F"+strconv.Itoa(row), errorStyle)
+		row++
+	}
+	_ = file.SetColWidth(sheet, "A", "D", 20)
+	_ = file.SetColWidth(sheet, "F", "F", 40)
+
+	filename := "bl_markings_rejected_" + strconv.FormatInt(id, 10) + "_" + time.Now().Format("20060102_150405") + ".xlsx"
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	if err := file.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func PostCancelBLMarkingImport(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	batchRepo := repo.ImportBatch{}
+	batch, err := batchRepo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceBLMarkings, batch.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+	if err := batchRepo.Cancel(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrImportNotPreview) {
+			redirectWithError(w, r, "/admin/bl_markings/imports/"+strconv.FormatInt(id, 10), "이미 확정되었거나 취소된 업로드입니다.")
+			return
+		}
+		redirectWithError(w, r, "/admin/bl_markings", "취소 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, "/admin/bl_markings", "업로드를 취소했습니다. 변경된 내용은 없습니다.")
+}
+
+// PostRollbackBLMarkingImport undoes a committed upload. It refuses when any
+// of its BLs changed afterwards, so later edits are never silently lost.
+func PostRollbackBLMarkingImport(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	batchRepo := repo.ImportBatch{}
+	batch, err := batchRepo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionDelete, policy.ResourceBLMarkings, batch.UserID, "BL 마킹 관리"); !ok {
+		return
+	}
+	userID, ok := currentUserID(r.Context())
+	if !ok {
+		http.Error(w, "로그인 사용자 정보를 찾을 수 없습니다.", http.StatusUnauthorized)
+		return
+	}
+	detailPath := "/admin/bl_markings/imports/" + strconv.FormatInt(id, 10)
+
+	if err := batchRepo.Rollback(r.Context(), id, userID, r.UserAgent()); err != nil {
+		var conflict *repo.ImportRollbackConflictError
+		switch {
+		case errors.Is(err, repo.ErrImportNotCommitted):
+			redirectWithError(w, r, detailPath, "확정된 업로드만 되돌릴 수 있습니다.")
+		case errors.Is(err, pgx.ErrNoRows):
+			redirectWithError(w, r, "/admin/bl_markings/imports", "찾을 수 없는 업로드입니다.")
+		case errors.As(err, &conflict):
+			hblNos := conflict.HBLNos
+			message := "업로드 이후 변경된 BL이 있어 되돌릴 수 없습니다: " + strings.Join(hblNos[:min(len(hblNos), 5)], ", ")
+			if len(hblNos) > 5 {
+				message += " 외 " + strconv.Itoa(len(hblNos)-5) + "건"
+			}
+			redirectWithError(w, r, detailPath, message)
+		default:
+			redirectWithError(w, r, detailPath, "되돌리기 중 오류가 발생했습니다. 변경된 내용은 없습니다: "+err.Error())
+		}
+		return
+	}
+	redirectWithSuccess(w, r, detailPath, "업로드를 되돌렸습니다. 신규 등록된 BL은 삭제하고 갱신된 BL은 이전 값으로 복원했습니다.")
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"skycontainers/internal/repo"
+	"strings"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// blMarkingSheet is one sheet of an uploaded file. A CSV file is a single
+// sheet without a name.
+type blMarkingSheet struct {
+	Name string
+	Rows [][]string
+}
+
+func readBLMarkingSheets(file io.Reader, filename string) ([]blMarkingSheet, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("엑셀 파일을 읽을 수 없습니다.")
+		}
+		defer func() { _ = workbook.Close() }()
+
+		names := workbook.GetSheetList()
+		if len(names) == 0 {
+			return nil, fmt.Errorf("엑셀 시트를 찾을 수 없습니다.")
+		}
+		sheets := make([]blMarkingSheet, 0, len(names))
+		for _, name := range names {
+			rows, err := workbook.GetRows(name)
+			if err != nil {
+				return nil, fmt.Errorf("엑셀 데이터를 읽을 수 없습니다.")
+			}
+			sheets = append(sheets, blMarkingSheet{Name: name, Rows: rows})
+		}
+		return sheets, nil
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("CSV 데이터를 읽을 수 없습니다.")
+		}
+		return []blMarkingSheet{{Rows: rows}}, nil
+	default:
+		return nil, fmt.Errorf("xlsx 또는 csv 파일만 업로드할 수 있습니다.")
+	}
+}
+
+const (
+	blFieldHBL = iota
+	blFieldCnee
+	blFieldMarks
+	blFieldContainer
+	blFieldCount
+)
+
+// blMarkingLayout says where hblno, cnee, marks and the container number sit
+// in a sheet. Profile is nil for the built-in layout (columns A/B/C, first row
+// is a header, container number only where that header names one).
+type blMarkingLayout struct {
+	Profile    *repo.BLImportProfile
+	Sheet      blMarkingSheet
+	HeaderRow  int
+	Columns    [blFieldCount]int
+	Transforms [blFieldCount][]string
+}
+
+// HasContainerColumn reports whether rows carry their own container number.
+func (l blMarkingLayout) HasContainerColumn() bool {
+	return l.Columns[blFieldContainer] >= 0
+}
+
+func defaultBLMarkingLayout(sheets []blMarkingSheet) blMarkingLayout {
+	layout := blMarkingLayout{
+		Sheet:     sheets[0],
+		HeaderRow: 1,
+		Columns:   [blFieldCount]int{0, 1, 2, -1},
+	}
+	if len(layout.Sheet.Rows) > 0 {
+		layout.Columns[blFieldContainer] = containerHeaderColumn(layout.Sheet.Rows[0])
+	}
+	return layout
+}
+
+// containerHeaderColumn finds the container number column of a built-in
+// layout header, or -1 when the file has none.
+func containerHeaderColumn(header []string) int {
+	for idx, cell := range header {
+		// A-C are hblno, cnee and marks.
+		if idx < 3 {
+			continue
+		}
+		token := normalizeHeaderToken(cleanCell(cell))
+		if containsAny(token, "컨테이너", "container", "cntr") {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Rows returns the data rows as hblno, cnee, marks, container number values
+// with the profile transforms applied, and the spreadsheet row number of the
+// first one.
+func (l blMarkingLayout) Rows() ([][]string, int) {
+	start := l.HeaderRow
+	if start > len(l.Sheet.Rows) {
+		start = len(l.Sheet.Rows)
+	}
+	result := make([][]string, 0, len(l.Sheet.Rows)-start)
+	for _, row := range l.Sheet.Rows[start:] {
+		values := make([]string, blFieldCount)
+		for field, column := range l.Columns {
+			if column < 0 {
+				continue
+			}
+			values[field] = applyBLTransforms(cleanCell(rowValue(row, column)), l.Transforms[field])
+		}
+		result = append(result, values)
+	}
+	return result, start + 1
+}
+
+// resolveBLImportProfile maps a profile onto the uploaded sheets. With
+// headerOnly set, column letters are not accepted; auto-detection uses this so
+// a profile only matches when the file really has its headers.
+func resolveBLImportProfile(profile repo.BLImportProfile, sheets []blMarkingSheet, headerOnly bool) (blMarkingLayout, error) {
+	layout := blMarkingLayout{Profile: &profile, HeaderRow: profile.HeaderRow}
+	if profile.SheetName == "" {
+		layout.Sheet = sheets[0]
+	} else {
+		found := false
+		for _, sheet := range sheets {
+			if strings.EqualFold(strings.TrimSpace(sheet.Name), strings.TrimSpace(profile.SheetName)) {
+				layout.Sheet = sheet
+				found = true
+				break
+			}
+		}
+		if !found {
+			return layout, fmt.Errorf("'%s' 시트를 찾을 수 없습니다.", profile.SheetName)
+		}
+	}
+	if profile.HeaderRow < 0 || profile.HeaderRow > len(layout.Sheet.Rows) {
+		return layout, fmt.Errorf("헤더 행(%d행)을 찾을 수 없습니다.", profile.HeaderRow)
+	}
+	if headerOnly && profile.HeaderRow == 0 {
+		return layout, fmt.Errorf("헤더 행이 없는 양식입니다.")
+	}
+
+	var header []string
+	if profile.HeaderRow > 0 {
+		header = layout.Sheet.Rows[profile.HeaderRow-1]
+	}
+	specs := [blFieldCount]string{profile.HBLColumn, profile.CneeColumn, profile.MarksColumn, profile.ContainerColumn}
+	labels := [blFieldCount]string{"HBL", "수하인", "Marks", "컨테이너"}
+	transforms := [blFieldCount]string{profile.HBLTransforms, profile.CneeTransforms, profile.MarksTransforms, ""}
+	for field, spec := range specs {
+		layout.Columns[field] = -1
+		layout.Transforms[field] = splitBLTransforms(transforms[field])
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			if field == blFieldCnee || field == blFieldContainer {
+				continue
+			}
+			return layout, fmt.Errorf("%s 열이 지정되지 않았습니다.", labels[field])
+		}
+		column := headerColumnIndex(header, spec)
+		if column < 0 && !headerOnly {
+			column = letterColumnIndex(spec)
+		}
+		if column < 0 {
+			return layout, fmt.Errorf("%s 열(%s)을 찾을 수 없습니다.", labels[field], spec)
+		}
+		layout.Columns[field] = column
+	}
+	return layout, nil
+}
+
+// detectBLImportProfile picks the profile whose header names all appear in
+// the file, preferring the one that maps the most fields.
+func detectBLImportProfile(profiles []repo.BLImportProfile, sheets []blMarkingSheet) (blMarkingLayout, bool) {
+	var best blMarkingLayout
+	bestScore := 0
+	for _, profile := range profiles {
+		layout, err := resolveBLImportProfile(profile, sheets, true)
+		if err != nil {
+			continue
+		}
+		score := 0
+		for _, column := range layout.Columns {
+			if column >= 0 {
+				score++
+			}
+		}
+		if score > bestScore {
+			best = layout
+			bestScore = score
+		}
+	}
+	return best, bestScore > 0
+}
+
+func headerColumnIndex(header []string, spec string) int {
+	target := normalizeHeaderToken(spec)
+	if target == "" {
+		return -1
+	}
+	for idx, cell := range header {
+		if normalizeHeaderToken(cleanCell(cell)) == target {
+			return idx
+		}
+	}
+	return -1
+}
+
+func letterColumnIndex(spec string) int {
+	spec = strings.ToUpper(strings.TrimSpace(spec))
+	if len(spec) == 0 || len(spec) > 2 {
+		return -1
+	}
+	number, err := excelize.ColumnNameToNumber(spec)
+	if err != nil {
+		return -1
+	}
+	return number - 1
+}
+
+// blTransforms lists the value transforms a profile may apply, in the order
+// they are offered on the profile form.
+var blTransforms = []struct {
+	Name  string
+	Label string
+}{
+	{"upper", "대문자로 변환"},
+	{"lower", "소문자로 변환"},
+	{"remove_spaces", "공백 제거"},
+	{"remove_hyphens", "하이픈(-) 제거"},
+	{"alnum", "영문/숫자만 남김"},
+	{"first_line", "첫 줄만 사용"},
+}
+
+func splitBLTransforms(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func validateBLTransforms(value string) error {
+	for _, name := range splitBLTransforms(value) {
+		known := false
+		for _, transform := range blTransforms {
+			if transform.Name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("알 수 없는 변환입니다: %s", name)
+		}
+	}
+	return nil
+}
+
+func applyBLTransforms(value string, names []string) string {
+	for _, name := range names {
+		switch name {
+		case "upper":
+			value = strings.ToUpper(value)
+		case "lower":
+			value = strings.ToLower(value)
+		case "remove_spaces":
+			value = strings.Join(strings.Fields(value), "")
+		case "remove_hyphens":
+			value = strings.ReplaceAll(value, "-", "")
+		case "alnum":
+			value = strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, value)
+		case "first_line":
+			if idx := strings.IndexAny(value, "\r\n"); idx >= 0 {
+				value = value[:idx]
+			}
+		}
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"skycontainers/internal/pdfdoc"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Label stock in millimetres. The HTML template uses the same size in its
+// @page rule.
+const (
+	cargoLabelWidth  = 100.0
+	cargoLabelHeight = 50.0
+)
+
+func ShowBLLabels(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	filter := parseBLMarkingFilter(r)
+	items, err := listCargoCardItems(r, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	settingRepo := repo.AppSetting{}
+	printerAddr, err := settingRepo.Get(r.Context(), repo.SettingLabelPrinterAddr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "bl_markings_labels.html", view.PageData{
+		Title: "화물 라벨",
+		Data: map[string]interface{}{
+			"Items":        items,
+			"PrintedAt":    time.Now().Format("2006-01-02"),
+			"PDFURL":       buildBLMarkingURL("/admin/bl_markings/labels/pdf", filter),
+			"ZPLURL":       buildBLMarkingURL("/admin/bl_markings/labels/zpl", filter),
+			"FilterParams": blMarkingFilterValues(filter),
+			"PrinterAddr":  printerAddr,
+		},
+	})
+}
+
+func ExportBLLabelsPDF(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	filter := parseBLMarkingFilter(r)
+	backURL := buildBLMarkingURL("/admin/bl_markings/labels", filter)
+	items, err := listCargoCardItems(r, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(items) == 0 {
+		redirectWithError(w, r, backURL, "출력할 데이터가 없습니다.")
+		return
+	}
+
+	pdf, err := renderCargoLabelsPDF(items)
+	if err != nil {
+		redirectWithError(w, r, backURL, "PDF 생성 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+
+	filename := "cargo_labels_" + time.Now().Format("20060102_150405") + ".pdf"
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+filename+"\"")
+	_, _ = w.Write(pdf)
+}
+
+func listCargoCardItems(r *http.Request, filter repo.BLMarkingFilter) ([]cargoCardItem, error) {
+	repoItem := repo.BLMarking{}
+	list, err := repoItem.ListForCargoCard(r.Context(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var supplierIDs []int64
+	seen := map[int64]bool{}
+	for _, item := range list {
+		if item.SupplierID > 0 && !seen[item.SupplierID] {
+			seen[item.SupplierID] = true
+			supplierIDs = append(supplierIDs, item.SupplierID)
+		}
+	}
+	layouts, err := loadCargoCardLayouts(r.Context(), supplierIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]cargoCardItem, 0, len(list))
+	for _, item := range list {
+		card := buildCargoCard(item)
+		if layout, ok := layouts[item.SupplierID]; ok {
+			card.Layout = layout
+		}
+		items = append(items, card)
+	}
+	return items, nil
+}
+
+// renderCargoLabelsPDF prints one label per page: QR code on the left, HBL,
+// container, quantity and consignee on the right, Code128 along the bottom.
+func renderCargoLabelsPDF(items []cargoCardItem) ([]byte, error) {
+	pdf, err := pdfdoc.NewCustom(cargoLabelWidth, cargoLabelHeight)
+	if err != nil {
+		return nil, err
+	}
+	pdf.SetTitle("화물 라벨", true)
+	for _, item := range items {
+		pdf.AddPage()
+		drawCargoLabel(pdf, item)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawCargoLabel(pdf *gofpdf.Fpdf, item cargoCardItem) {
+	const pad = 3.0
+	const qrSide = 30.0
+	pdf.SetTextColor(0, 0, 0)
+
+	pdfdoc.DrawCode(pdf, item.QRCode, pad, pad, qrSide, qrSide, 2)
+
+	textX := pad + qrSide + 2
+	textW := cargoLabelWidth - textX - pad
+	drawCardText(pdf, item.BLNo, textX, pad, textW, 9, 18, true, "L")
+	drawCardText(pdf, item.ContainerNo, textX, pad+10, textW, 6, 11, false, "L")
+	quantity := item.Quantity
+	if quantity != "" {
+		quantity = "수량 " + quantity
+	}
+	if item.MarksColor != "" {
+		pdfdoc.SetHexColor(pdf, item.MarksColor)
+	}
+	drawCardText(pdf, quantity, textX, pad+17, textW, 6, 12, true, "L")
+	pdf.SetTextColor(0, 0, 0)
+	drawCardText(pdf, item.Consignee, textX, pad+24, textW, 6, 10, false, "L")
+
+	barY := pad + qrSide + 2
+	barH := cargoLabelHeight - barY - pad
+	if item.Barcode.Width == 0 {
+		drawCardText(pdf, item.BLNo, pad, barY, cargoLabelWidth-pad*2, barH, 12, false, "C")
+		return
+	}
+	pdfdoc.DrawCode(pdf, item.Barcode, pad, barY, cargoLabelWidth-pad*2, barH, 10)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/unipass"
+	"skycontainers/internal/view"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const unipassLookupTimeout = 30 * time.Second
+
+type unipassLookupRow struct {
+	HBLNo               string
+	MBLNo               string
+	CargMtNo            string
+	ItemName            string
+	Packages            string
+	Weight              string
+	ContainerNo         string
+	CustomsStatus       string
+	ProgressStatus      string
+	ArrivalDate         *time.Time
+	ExistingContainerNo string
+	Registered          bool
+}
+
+// ShowUnipassLookup queries UNIPASS by MBL, cargo management number or HBL and
+// lists every HBL in the answer so the consolidated ones can be registered.
+func ShowUnipassLookup(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	mode := normalizeUnipassLookupMode(query.Get("mode"))
+	number := strings.TrimSpace(query.Get("no"))
+	yearValue := strings.TrimSpace(query.Get("year"))
+	data := map[string]interface{}{
+		"Mode": mode,
+		"No":   number,
+		"Year": yearValue,
+	}
+
+	var lookupError string
+	if number != "" {
+		q, err := buildUnipassLookupQuery(mode, number, yearValue)
+		if err != nil {
+			lookupError = err.Error()
+		} else {
+			rows, err := runUnipassLookup(r.Context(), q)
+			if err != nil {
+				lookupError = unipassLookupErrorMessage(err)
+			} else {
+				data["Rows"] = rows
+			}
+		}
+	}
+
+	view.Render(w, r, "bl_markings_unipass_lookup.html", view.PageData{
+		Title: "유니패스 MBL 조회",
+		Error: lookupError,
+		Data:  data,
+	})
+}
+
+// PostUnipassLookupCreate registers the selected HBLs on one container and
+// queues their UNIPASS lookups.
+func PostUnipassLookupCreate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+	userID, ok := currentUserID(r.Context())
+	if !ok {
+		http.Error(w, "로그인 사용자 정보를 찾을 수 없습니다.", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		redirectWithError(w, r, "/admin/bl_markings/unipass_lookup", "요청을 처리할 수 없습니다.")
+		return
+	}
+
+	backValues := url.Values{}
+	backValues.Set("mode", normalizeUnipassLookupMode(r.FormValue("mode")))
+	backValues.Set("no", strings.TrimSpace(r.FormValue("no")))
+	if year := strings.TrimSpace(r.FormValue("year")); year != "" {
+		backValues.Set("year", year)
+	}
+	backPath := "/admin/bl_markings/unipass_lookup?" + backValues.Encode()
+
+	var hblNos []string
+	seen := make(map[string]bool)
+	for _, value := range r.Form["hbl_no"] {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		hblNos = append(hblNos, value)
+	}
+	if len(hblNos) == 0 {
+		redirectWithError(w, r, backPath, "등록할 HBL을 선택해 주세요.")
+		return
+	}
+
+	repoContainer := repo.Container{}
+	container, err := repoContainer.FindAvailableByNo(r.Context(), r.FormValue("container_no"))
+	if err != nil {
+		if errors.Is(err, repo.ErrContainerUnavailable) {
+			redirectWithError(w, r, backPath, "등록되지 않았거나 이미 출고된 컨테이너입니다.")
+			return
+		}
+		redirectWithError(w, r, backPath, err.Error())
+		return
+	}
+	marks := strings.TrimSpace(r.FormValue("marks"))
+
+	repoItem := repo.BLMarking{}
+	result, err := repoItem.RegisterHBLs(r.Context(), container.ID, userID, hblNos, marks)
+	if err != nil {
+		redirectWithError(w, r, backPath, "등록 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	if result.Inserted == 0 && result.Reactivated == 0 {
+		redirectWithError(w, r, backPath, "선택한 HBL은 모두 이미 등록되어 있습니다.")
+		return
+	}
+	message := container.ContainerNo + "에 " + strconv.Itoa(result.Inserted) + "건 등록"
+	if result.Reactivated > 0 {
+		message += ", " + strconv.Itoa(result.Reactivated) + "건 재등록(삭제된 BL 복원)"
+	}
+	if result.Skipped > 0 {
+		message += ", " + strconv.Itoa(result.Skipped) + "건 제외(이미 등록됨)"
+	}
+	message += " (유니패스 조회는 백그라운드에서 진행됩니다)"
+	listValues := url.Values{}
+	listValues.Set("container_no", container.ContainerNo)
+	listValues.Set("unipass_batch", strconv.FormatInt(result.UnipassBatchID, 10))
+	redirectWithSuccess(w, r, "/admin/bl_markings?"+listValues.Encode(), message)
+}
+
+func normalizeUnipassLookupMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "hbl":
+		return "hbl"
+	case "carg_mt_no":
+		return "carg_mt_no"
+	default:
+		return "mbl"
+	}
+}
+
+func buildUnipassLookupQuery(mode string, number string, yearValue string) (unipass.CargoQuery, error) {
+	q := unipass.CargoQuery{}
+	switch mode {
+	case "hbl":
+		q.HBLNo = number
+	case "carg_mt_no":
+		q.CargMtNo = number
+	default:
+		q.MBLNo = number
+	}
+	if yearValue != "" {
+		year, err := strconv.Atoi(yearValue)
+		if err != nil || year < 2000 || year > time.Now().Year()+1 {
+			return q, errors.New("BL 연도를 확인해 주세요.")
+		}
+		q.BLYear = year
+	}
+	return q, nil
+}
+
+func runUnipassLookup(ctx context.Context, q unipass.CargoQuery) ([]unipassLookupRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, unipassLookupTimeout)
+	defer cancel()
+
+	body, err := unipass.Lookup(ctx, unipass.DefaultClient(), q)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := unipass.ParseCargoProgress(body)
+	if err != nil {
+		return nil, err
+	}
+
+	hblNos := make([]string, 0, len(resp.Cargos))
+	for _, cargo := range resp.Cargos {
+		if hblNo := strings.TrimSpace(cargo.HblNo); hblNo != "" {
+			hblNos = append(hblNos, hblNo)
+		}
+	}
+	repoItem := repo.BLMarking{}
+	existing, err := repoItem.ContainerNosByHBLNo(ctx, hblNos)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]unipassLookupRow, 0, len(resp.Cargos))
+	for i := range resp.Cargos {
+		cargo := &resp.Cargos[i]
+		row := unipassLookupRow{
+			HBLNo:          strings.TrimSpace(cargo.HblNo),
+			MBLNo:          strings.TrimSpace(cargo.MblNo),
+			CargMtNo:       strings.TrimSpace(cargo.CargMtNo),
+			ItemName:       strings.TrimSpace(cargo.Prnm),
+			Packages:       strings.TrimSpace(cargo.PckGcnt + " " + cargo.PckUt),
+			Weight:         strings.TrimSpace(cargo.Ttwg + " " + cargo.WghtUt),
+			ContainerNo:    strings.TrimSpace(cargo.CntrNo),
+			CustomsStatus:  strings.TrimSpace(cargo.CsclPrgsStts),
+			ProgressStatus: strings.TrimSpace(cargo.PrgsStts),
+			ArrivalDate:    cargo.ArrivalDate(),
+		}
+		if containerNo, ok := existing[row.HBLNo]; ok {
+			row.Registered = true
+			row.ExistingContainerNo = containerNo
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func unipassLookupErrorMessage(err error) string {
+	var resultErr *unipass.ResultError
+	switch {
+	case errors.Is(err, unipass.ErrNoAPIKey):
+		return "유니패스 API 키가 설정되지 않았습니다."
+	case errors.Is(err, unipass.ErrCircuitOpen):
+		return "유니패스 연결이 일시적으로 차단되었습니다. 잠시 후 다시 시도해 주세요."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "유니패스 응답이 지연되고 있습니다. 잠시 후 다시 시도해 주세요."
+	case errors.As(err, &resultErr):
+		if resultErr.Notice != "" {
+			return "유니패스 조회 실패: " + resultErr.Notice
+		}
+		return "유니패스 조회 결과가 없습니다."
+	default:
+		log.Printf("unipass lookup failed err=%v", err)
+		return "유니패스 조회 중 오류가 발생했습니다."
+	}
+}
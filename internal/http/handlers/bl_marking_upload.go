@@ -2,24 +2,18 @@ package handlers
 
 import (
 	"context"
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"net/url"
-	"os"
 	"path/filepath"
+	"skycontainers/internal/iso6346"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
 	"skycontainers/internal/view"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/xuri/excelize/v2"
 )
 
 const maxUploadRows = 2000
@@ -49,22 +43,24 @@ func PostUploadBLMarkings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The container chosen in the form is optional once the file has a
+	// container column; it then only fills rows that leave theirs empty.
 	repoContainer := repo.Container{}
-	var containerID int64
+	var defaultContainerID *int64
 	if containerIDValue != nil && *containerIDValue > 0 {
 		container, err := repoContainer.FindAvailableByID(r.Context(), *containerIDValue)
 		if err != nil {
 			renderBLMarkingsListError(w, r, err.Error())
 			return
 		}
-		containerID = container.ID
-	} else {
+		defaultContainerID = &container.ID
+	} else if containerNo != "" {
 		container, err := repoContainer.FindAvailableByNo(r.Context(), containerNo)
 		if err != nil {
 			renderBLMarkingsListError(w, r, err.Error())
 			return
 		}
-		containerID = container.ID
+		defaultContainerID = &container.ID
 	}
 
 	file, header, err := r.FormFile("file")
@@ -79,120 +75,222 @@ func PostUploadBLMarkings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := readBLMarkingRows(file, header.Filename)
+	sheets, err := readBLMarkingSheets(file, header.Filename)
 	if err != nil {
 		renderBLMarkingsListError(w, r, err.Error())
 		return
 	}
+	layout, detected, err := chooseBLMarkingLayout(r.Context(), r.FormValue("import_profile_id"), sheets)
+	if err != nil {
+		renderBLMarkingsListError(w, r, err.Error())
+		return
+	}
+	if defaultContainerID == nil && !layout.HasContainerColumn() {
+		renderBLMarkingsListError(w, r, "컨테이너 번호를 입력하거나 컨테이너 열이 있는 양식을 선택해 주세요.")
+		return
+	}
+	rows, firstRowNo := layout.Rows()
 	if len(rows) == 0 {
 		renderBLMarkingsListError(w, r, "업로드할 데이터가 없습니다.")
 		return
 	}
 
-	startIdx := 0
-	if len(rows) > 0 {
-		startIdx = 1
+	importRows, err := classifyBLMarkingRows(r.Context(), rows, firstRowNo, defaultContainerID, blPositionID)
+	if err != nil {
+		renderBLMarkingsListError(w, r, "엑셀 업로드 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	if len(importRows) == 0 {
+		renderBLMarkingsListError(w, r, "업로드할 데이터가 없습니다.")
+		return
 	}
 
-	inserted := 0
-	updated := 0
-	skipped := 0
-	repoItem := repo.BLMarking{}
-	for rowIdx := startIdx; rowIdx < len(rows); rowIdx++ {
-		if inserted+updated >= maxUploadRows {
-			break
+	batch := repo.ImportBatch{
+		UserID:       userID,
+		ContainerID:  defaultContainerID,
+		BLPositionID: blPositionID,
+		FileName:     filepath.Base(header.Filename),
+	}
+	if layout.Profile != nil {
+		batch.ProfileID = &layout.Profile.ID
+	}
+	if err := batch.Create(r.Context(), importRows); err != nil {
+		renderBLMarkingsListError(w, r, "엑셀 업로드 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	message := "업로드 파일을 확인한 뒤 확정해 주세요."
+	if detected {
+		message = "'" + layout.Profile.Label() + "' 양식으로 읽었습니다. " + message
+	}
+	redirectWithSuccess(w, r, "/admin/bl_markings/imports/"+strconv.FormatInt(batch.ID, 10), message)
+}
+
+// chooseBLMarkingLayout resolves the upload form's profile choice: a profile
+// ID, "default" for the built-in layout, or empty to detect the profile from
+// the header row. detected reports whether a profile was picked automatically.
+func chooseBLMarkingLayout(ctx context.Context, value string, sheets []blMarkingSheet) (blMarkingLayout, bool, error) {
+	value = strings.TrimSpace(value)
+	profileRepo := repo.BLImportProfile{}
+	switch value {
+	case "default":
+		return defaultBLMarkingLayout(sheets), false, nil
+	case "":
+		profiles, err := profileRepo.ListActive(ctx)
+		if err != nil {
+			return blMarkingLayout{}, false, err
+		}
+		if layout, ok := detectBLImportProfile(profiles, sheets); ok {
+			return layout, true, nil
 		}
-		row := rows[rowIdx]
+		return defaultBLMarkingLayout(sheets), false, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return blMarkingLayout{}, false, fmt.Errorf("업로드 양식을 확인해 주세요.")
+	}
+	profile, err := profileRepo.GetByID(ctx, id)
+	if err != nil || !profile.IsActive {
+		return blMarkingLayout{}, false, fmt.Errorf("업로드 양식을 찾을 수 없습니다.")
+	}
+	layout, err := resolveBLImportProfile(*profile, sheets, false)
+	if err != nil {
+		return blMarkingLayout{}, false, fmt.Errorf("'%s' 양식과 파일이 맞지 않습니다: %w", profile.Label(), err)
+	}
+	return layout, false, nil
+}
+
+// classifyBLMarkingRows decides what committing each row would do without
+// writing anything. firstRowNo is the spreadsheet row number of rows[0]. Rows
+// without a container number of their own use defaultContainerID.
+func classifyBLMarkingRows(ctx context.Context, rows [][]string, firstRowNo int, defaultContainerID *int64, blPositionID *int64) ([]repo.ImportBatchRow, error) {
+	repoItem := repo.BLMarking{}
+	containers := uploadContainerResolver{cache: make(map[string]uploadContainer)}
+	seen := make(map[string]int)
+	accepted := 0
+	var result []repo.ImportBatchRow
+	for idx, row := range rows {
 		if len(row) == 0 {
 			continue
 		}
-		hblNo := strings.TrimSpace(cleanCell(rowValue(row, 0)))
-		cnee := strings.TrimSpace(cleanCell(rowValue(row, 1)))
-		marks := strings.TrimSpace(cleanCell(rowValue(row, 2)))
+		hblNo := strings.TrimSpace(cleanCell(rowValue(row, blFieldHBL)))
+		cnee := strings.TrimSpace(cleanCell(rowValue(row, blFieldCnee)))
+		marks := strings.TrimSpace(cleanCell(rowValue(row, blFieldMarks)))
+		containerNo := strings.TrimSpace(cleanCell(rowValue(row, blFieldContainer)))
 		if isBLMarkingHeaderRow(hblNo, cnee, marks) {
 			continue
 		}
 		if hblNo == "" && cnee == "" && marks == "" {
 			continue
 		}
-		if hblNo == "" || marks == "" {
-			skipped++
-			continue
+
+		item := repo.ImportBatchRow{
+			RowNo:             firstRowNo + idx,
+			HBLNo:             hblNo,
+			Cnee:              cnee,
+			Marks:             marks,
+			SourceContainerNo: containerNo,
+			ContainerID:       defaultContainerID,
+			BLPositionID:      blPositionID,
+		}
+		containerMessage := ""
+		if containerNo != "" {
+			container, err := containers.resolve(ctx, containerNo)
+			if err != nil {
+				return nil, err
+			}
+			item.ContainerID = container.ID
+			containerMessage = container.Message
+		} else if defaultContainerID == nil {
+			containerMessage = "컨테이너 번호가 없습니다."
 		}
 
-		item := repo.BLMarking{
-			ContainerID:  containerID,
-			UserID:       userID,
-			BLPositionID: blPositionID,
-			HBLNo:        hblNo,
-			Marks:        marks,
-			Cnee:         cnee,
-			IsActive:     true,
+		switch {
+		case hblNo == "":
+			item.Action = repo.ImportRowError
+			item.Message = "HBL 번호가 없습니다."
+		case marks == "":
+			item.Action = repo.ImportRowError
+			item.Message = "마킹 정보가 없습니다."
+		case containerMessage != "":
+			item.Action = repo.ImportRowError
+			item.Message = containerMessage
+		case seen[hblNo] > 0:
+			item.Action = repo.ImportRowError
+			item.Message = fmt.Sprintf("파일 내 중복 HBL (%d행)", seen[hblNo])
+		case accepted >= maxUploadRows:
+			item.Action = repo.ImportRowError
+			item.Message = fmt.Sprintf("최대 %d건까지 처리합니다.", maxUploadRows)
 		}
-		var unipassXML *string
-		if xmlBody, ok := fetchUnipassXML(r.Context(), hblNo); ok {
-			unipassXML = &xmlBody
-			item.FrmUnipass = unipassXML
+		if item.Action != "" {
+			result = append(result, item)
+			continue
 		}
+		seen[hblNo] = item.RowNo
 
-		existing, err := repoItem.GetByHBLNo(r.Context(), hblNo)
+		existing, err := repoItem.GetByHBLNoForImport(ctx, hblNo)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
 		if err == nil {
-			existing.ContainerID = containerID
-			existing.UserID = userID
-			if blPositionID != nil {
-				existing.BLPositionID = blPositionID
-			}
-			existing.HBLNo = hblNo
-			existing.Marks = marks
-			existing.Cnee = cnee
-			existing.IsActive = true
-			if err := existing.Update(r.Context()); err != nil {
-				renderBLMarkingsListError(w, r, "엑셀 업로드 중 오류가 발생했습니다: "+err.Error())
-				return
+			existingID := existing.ID
+			existingContainerID := existing.ContainerID
+			item.BLMarkingID = &existingID
+			item.OldContainerID = &existingContainerID
+			item.OldBLPositionID = existing.BLPositionID
+			item.OldMarks = existing.Marks
+			item.OldCnee = existing.Cnee
+			item.OldIsActive = existing.IsActive
+			item.Action = repo.ImportRowUpdate
+			switch {
+			case item.Reactivates():
+				item.Message = "비활성 BL을 다시 활성화합니다."
+			case !item.ContainerChanged() && !item.PositionChanged() && !item.MarksChanged() && !item.CneeChanged():
+				item.Action = repo.ImportRowSkip
+				item.Message = "변경 사항이 없습니다."
 			}
-			if unipassXML != nil {
-				if err := repoItem.UpdateUnipassXML(r.Context(), existing.ID, unipassXML); err != nil {
-					renderBLMarkingsListError(w, r, "엑셀 업로드 중 오류가 발생했습니다: "+err.Error())
-					return
-				}
-			}
-			updated++
-			continue
+		} else {
+			item.Action = repo.ImportRowNew
 		}
-		if !errors.Is(err, pgx.ErrNoRows) {
-			renderBLMarkingsListError(w, r, "엑셀 업로드 중 오류가 발생했습니다: "+err.Error())
-			return
+		if item.Action != repo.ImportRowSkip {
+			accepted++
 		}
-		if err := item.Create(r.Context()); err != nil {
-			renderBLMarkingsListError(w, r, "엑셀 업로드 중 오류가 발생했습니다: "+err.Error())
-			return
-		}
-		inserted++
+		result = append(result, item)
 	}
+	return result, nil
+}
 
-	if inserted == 0 && updated == 0 {
-		renderBLMarkingsListError(w, r, "등록할 데이터가 없습니다.")
-		return
-	}
+type uploadContainer struct {
+	ID      *int64
+	Message string
+}
 
-	message := "업로드 완료: " + strconv.Itoa(inserted) + "건 등록"
-	if updated > 0 {
-		message += ", " + strconv.Itoa(updated) + "건 갱신"
-	}
-	if skipped > 0 {
-		message += ", " + strconv.Itoa(skipped) + "건 제외"
-	}
-	if inserted+updated >= maxUploadRows {
-		message += " (최대 " + strconv.Itoa(maxUploadRows) + "건까지 처리)"
-	}
-	redirectWithSuccess(w, r, "/admin/bl_markings", message)
+// uploadContainerResolver looks each container number up once per file, since
+// a manifest repeats the same few containers on every row.
+type uploadContainerResolver struct {
+	cache map[string]uploadContainer
 }
 
-func looksLikeBLMarkingHeader(row []string) bool {
-	if len(row) < 3 {
-		return false
+func (c uploadContainerResolver) resolve(ctx context.Context, containerNo string) (uploadContainer, error) {
+	key := iso6346.Normalize(containerNo)
+	if cached, ok := c.cache[key]; ok {
+		return cached, nil
+	}
+	repoContainer := repo.Container{}
+	var result uploadContainer
+	container, err := repoContainer.FindAvailableByNo(ctx, containerNo)
+	switch {
+	case err == nil:
+		result.ID = &container.ID
+	case errors.Is(err, repo.ErrContainerUnavailable):
+		result.Message = containerNo + ": " + err.Error()
+	case iso6346.IsInvalid(err):
+		result.Message = err.Error()
+	default:
+		return result, err
 	}
-	return isBLMarkingHeaderRow(row[0], row[1], row[2])
+	c.cache[key] = result
+	return result, nil
 }
 
 func rowValue(row []string, idx int) string {
@@ -247,132 +345,8 @@ func isDigit(r rune) bool {
 	return r >= '0' && r <= '9'
 }
 
-func readBLMarkingRows(file io.Reader, filename string) ([][]string, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
-	switch ext {
-	case ".xlsx":
-		workbook, err := excelize.OpenReader(file)
-		if err != nil {
-			return nil, fmt.Errorf("엑셀 파일을 읽을 수 없습니다.")
-		}
-		defer func() { _ = workbook.Close() }()
-
-		sheets := workbook.GetSheetList()
-		if len(sheets) == 0 {
-			return nil, fmt.Errorf("엑셀 시트를 찾을 수 없습니다.")
-		}
-
-		rows, err := workbook.GetRows(sheets[0])
-		if err != nil {
-			return nil, fmt.Errorf("엑셀 데이터를 읽을 수 없습니다.")
-		}
-		return rows, nil
-	case ".csv":
-		reader := csv.NewReader(file)
-		reader.TrimLeadingSpace = true
-		rows, err := reader.ReadAll()
-		if err != nil {
-			return nil, fmt.Errorf("CSV 데이터를 읽을 수 없습니다.")
-		}
-		return rows, nil
-	default:
-		return nil, fmt.Errorf("xlsx 또는 csv 파일만 업로드할 수 있습니다.")
-	}
-}
-
-func fetchUnipassXML(ctx context.Context, hblNo string) (string, bool) {
-	hblNo = strings.TrimSpace(hblNo)
-	if hblNo == "" {
-		return "", false
-	}
-	apiKey := strings.TrimSpace(os.Getenv("crkyCn"))
-	if apiKey == "" {
-		return "", false
-	}
-	baseURL := "https://unipass.customs.go.kr:38010/ext/rest/cargCsclPrgsInfoQry/retrieveCargCsclPrgsInfo"
-	query := url.Values{}
-	query.Set("crkyCn", apiKey)
-	query.Set("hblNo", hblNo)
-	query.Set("blYy", time.Now().Format("2006"))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"?"+query.Encode(), nil)
-	if err != nil {
-		return "", false
-	}
-	client := &http.Client{Timeout: 20 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("unipass request failed hbl=%s err=%v", hblNo, err)
-		return "", false
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("unipass non-200 hbl=%s status=%d", hblNo, resp.StatusCode)
-		return "", false
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("unipass read failed hbl=%s err=%v", hblNo, err)
-		return "", false
-	}
-	xmlBody := strings.TrimSpace(string(body))
-	if xmlBody == "" {
-		log.Printf("unipass empty body hbl=%s", hblNo)
-		return "", false
-	}
-	if !unipassResultOK(xmlBody) {
-		log.Printf("unipass error body hbl=%s resultCode=%s tCnt=%s ntceInfo=%s",
-			hblNo,
-			strings.TrimSpace(extractXMLTagValue(xmlBody, "resultCode")),
-			strings.TrimSpace(extractXMLTagValue(xmlBody, "tCnt")),
-			strings.TrimSpace(extractXMLTagValue(xmlBody, "ntceInfo")),
-		)
-		return "", false
-	}
-	return xmlBody, true
-}
-
-func unipassResultOK(xmlBody string) bool {
-	code := extractXMLTagValue(xmlBody, "resultCode")
-	if code == "" {
-		return !isUnipassErrorBody(xmlBody)
-	}
-	code = strings.TrimSpace(code)
-	if code != "00" {
-		return false
-	}
-	return !isUnipassErrorBody(xmlBody)
-}
-
-func extractXMLTagValue(xmlBody string, tag string) string {
-	startTag := "<" + tag + ">"
-	endTag := "</" + tag + ">"
-	start := strings.Index(xmlBody, startTag)
-	if start == -1 {
-		return ""
-	}
-	start += len(startTag)
-	end := strings.Index(xmlBody[start:], endTag)
-	if end == -1 {
-		return ""
-	}
-	return xmlBody[start : start+end]
-}
-
-func isUnipassErrorBody(xmlBody string) bool {
-	notice := strings.TrimSpace(extractXMLTagValue(xmlBody, "ntceInfo"))
-	if notice != "" {
-		return true
-	}
-	tcnt := strings.TrimSpace(extractXMLTagValue(xmlBody, "tCnt"))
-	if tcnt == "-1" || tcnt == "0" {
-		return true
-	}
-	return false
-}
-
 func renderBLMarkingsListError(w http.ResponseWriter, r *http.Request, message string) {
-	data, err := blMarkingPageData(r.Context(), 1, "", "", false, "")
+	data, err := blMarkingPageData(r.Context(), 1, repo.BLMarkingFilter{})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
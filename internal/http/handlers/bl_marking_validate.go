@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"skycontainers/internal/iso6346"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
 )
@@ -33,6 +34,10 @@ func ValidateBLMarkingContainer(w http.ResponseWriter, r *http.Request) {
 			writeContainerValidation(w, false, "등록되지 않았거나 이미 출고된 컨테이너입니다.")
 			return
 		}
+		if iso6346.IsInvalid(err) {
+			writeContainerValidation(w, false, err.Error())
+			return
+		}
 		writeContainerValidation(w, false, "컨테이너 확인 중 오류가 발생했습니다.")
 		return
 	}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/zpl"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+func ExportBLLabelsZPL(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	filter := parseBLMarkingFilter(r)
+	backURL := buildBLMarkingURL("/admin/bl_markings/labels", filter)
+	job, count, err := buildBLLabelsZPL(r.Context(), filter)
+	if err != nil {
+		redirectWithError(w, r, backURL, err.Error())
+		return
+	}
+	if count == 0 {
+		redirectWithError(w, r, backURL, "출력할 데이터가 없습니다.")
+		return
+	}
+
+	filename := "bl_labels_" + time.Now().Format("20060102_150405") + ".zpl"
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	_, _ = w.Write(job)
+}
+
+func PostPrintBLLabels(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+
+	filter := parseBLMarkingFilter(r)
+	backURL := buildBLMarkingURL("/admin/bl_markings/labels", filter)
+
+	settingRepo := repo.AppSetting{}
+	configured, err := settingRepo.Get(r.Context(), repo.SettingLabelPrinterAddr)
+	if err != nil {
+		redirectWithError(w, r, backURL, err.Error())
+		return
+	}
+	address, err := zpl.PrinterAddress(configured)
+	if err != nil {
+		redirectWithError(w, r, backURL, err.Error())
+		return
+	}
+	if address == "" {
+		redirectWithError(w, r, backURL, "설정에서 라벨 프린터 주소를 먼저 등록해 주세요.")
+		return
+	}
+
+	job, count, err := buildBLLabelsZPL(r.Context(), filter)
+	if err != nil {
+		redirectWithError(w, r, backURL, err.Error())
+		return
+	}
+	if count == 0 {
+		redirectWithError(w, r, backURL, "출력할 데이터가 없습니다.")
+		return
+	}
+	if err := zpl.Send(r.Context(), address, job); err != nil {
+		redirectWithError(w, r, backURL, err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, backURL, strconv.Itoa(count)+"장을 프린터("+address+")로 보냈습니다.")
+}
+
+// buildBLLabelsZPL renders one label per BL with its supplier's template,
+// falling back to the default template for suppliers without one.
+func buildBLLabelsZPL(ctx context.Context, filter repo.BLMarkingFilter) ([]byte, int, error) {
+	repoItem := repo.BLMarking{}
+	list, err := repoItem.ListForLabels(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	templates := map[int64]*template.Template{}
+	var job strings.Builder
+	for _, item := range list {
+		tmpl, ok := templates[item.SupplierID]
+		if !ok {
+			tmpl, err = zpl.Parse(item.ZPLTemplate)
+			if err != nil {
+				return nil, 0, err
+			}
+			templates[item.SupplierID] = tmpl
+		}
+		label := zpl.NewLabel(item.HBLNo, item.Marks, chooseValue(item.Cnee, item.SupplierName),
+			item.ContainerNo, item.PositionName, item.SupplierName, item.SupplierShort)
+		out, err := zpl.Render(tmpl, label)
+		if err != nil {
+			return nil, 0, err
+		}
+		job.WriteString(out)
+	}
+	return []byte(job.String()), len(list), nil
+}
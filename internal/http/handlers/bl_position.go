@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"skycontainers/internal/pagination"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
 	"skycontainers/internal/view"
@@ -13,28 +13,21 @@ import (
 )
 
 func ListBLPositions(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
-	}
 	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLPositions, 0, "BL 포지션 관리"); !ok {
 		return
 	}
 
-	pager := pagination.NewPager(0, page, 10)
 	repoItem := repo.BLPosition{}
-	list, total, err := repoItem.List(r.Context(), pager)
+	list, err := repoItem.ListTree(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	pager = pagination.NewPager(total, page, 10)
 	view.Render(w, r, "bl_positions_list.html", view.PageData{
 		Title: "BL 포지션 관리",
 		Data: map[string]interface{}{
 			"Items": list,
-			"Pager": pager,
 		},
 	})
 }
@@ -43,9 +36,15 @@ func ShowCreateBLPosition(w http.ResponseWriter, r *http.Request) {
 	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceBLPositions, 0, "BL 포지션 등록"); !ok {
 		return
 	}
-	view.Render(w, r, "bl_positions_form.html", view.PageData{
-		Title: "BL 포지션 등록",
-	})
+	item := repo.BLPosition{LocationType: repo.BLLocationZone}
+	if parentID, _ := strconv.ParseInt(r.URL.Query().Get("parent_id"), 10, 64); parentID > 0 {
+		repoItem := repo.BLPosition{}
+		if parent, err := repoItem.GetByID(r.Context(), parentID); err == nil {
+			item.ParentID = &parent.ID
+			item.LocationType = parent.LocationType.Child()
+		}
+	}
+	renderBLPositionForm(w, r, "BL 포지션 등록", "", item)
 }
 
 func PostCreateBLPosition(w http.ResponseWriter, r *http.Request) {
@@ -57,42 +56,25 @@ func PostCreateBLPosition(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "로그인 사용자 정보를 찾을 수 없습니다.", http.StatusUnauthorized)
 		return
 	}
-	name := strings.TrimSpace(r.FormValue("name"))
-	if name == "" {
-		view.Render(w, r, "bl_positions_form.html", view.PageData{
-			Title: "BL 포지션 등록",
-			Error: "이름을 입력해 주세요.",
-			Data:  repo.BLPosition{Name: name},
-		})
+	item, errMsg := blPositionFromForm(r)
+	item.UserID = userID
+	if errMsg != "" {
+		renderBLPositionForm(w, r, "BL 포지션 등록", errMsg, item)
 		return
 	}
 	repoItem := repo.BLPosition{}
-	exists, err := repoItem.ExistsByName(r.Context(), name, nil)
+	exists, err := repoItem.ExistsByName(r.Context(), item.Name, item.ParentID, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	if exists {
-		view.Render(w, r, "bl_positions_form.html", view.PageData{
-			Title: "BL 포지션 등록",
-			Error: "같은 이름의 BL 포지션이 이미 있습니다.",
-			Data:  repo.BLPosition{Name: name},
-		})
+		renderBLPositionForm(w, r, "BL 포지션 등록", "같은 위치 아래에 같은 이름의 BL 포지션이 이미 있습니다.", item)
 		return
 	}
 
-	item := repo.BLPosition{
-		Name:     name,
-		IsActive: true,
-		UserID:   userID,
-	}
-
 	if err := item.Create(r.Context()); err != nil {
-		view.Render(w, r, "bl_positions_form.html", view.PageData{
-			Title: "BL 포지션 등록",
-			Error: "등록 중 오류가 발생했습니다: " + err.Error(),
-			Data:  item,
-		})
+		renderBLPositionForm(w, r, "BL 포지션 등록", "등록 중 오류가 발생했습니다: "+err.Error(), item)
 		return
 	}
 
@@ -111,10 +93,7 @@ func ShowEditBLPosition(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	view.Render(w, r, "bl_positions_form.html", view.PageData{
-		Title: "BL 포지션 수정",
-		Data:  item,
-	})
+	renderBLPositionForm(w, r, "BL 포지션 수정", "", *item)
 }
 
 func PostUpdateBLPosition(w http.ResponseWriter, r *http.Request) {
@@ -133,46 +112,106 @@ func PostUpdateBLPosition(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "로그인 사용자 정보를 찾을 수 없습니다.", http.StatusUnauthorized)
 		return
 	}
-	name := strings.TrimSpace(r.FormValue("name"))
-	if name == "" {
-		view.Render(w, r, "bl_positions_form.html", view.PageData{
-			Title: "BL 포지션 수정",
-			Error: "이름을 입력해 주세요.",
-			Data:  repo.BLPosition{ID: id, Name: name, IsActive: existing.IsActive},
-		})
+	item, errMsg := blPositionFromForm(r)
+	item.ID = id
+	item.IsActive = existing.IsActive
+	item.UserID = userID
+	if errMsg != "" {
+		renderBLPositionForm(w, r, "BL 포지션 수정", errMsg, item)
 		return
 	}
-	exists, err := repoItem.ExistsByName(r.Context(), name, &id)
+	exists, err := repoItem.ExistsByName(r.Context(), item.Name, item.ParentID, &id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	if exists {
-		view.Render(w, r, "bl_positions_form.html", view.PageData{
-			Title: "BL 포지션 수정",
-			Error: "같은 이름의 BL 포지션이 이미 있습니다.",
-			Data:  repo.BLPosition{ID: id, Name: name, IsActive: existing.IsActive},
-		})
+		renderBLPositionForm(w, r, "BL 포지션 수정", "같은 위치 아래에 같은 이름의 BL 포지션이 이미 있습니다.", item)
 		return
 	}
 
+	if err := item.Update(r.Context()); err != nil {
+		errMsg := "수정 중 오류가 발생했습니다: " + err.Error()
+		if errors.Is(err, repo.ErrBLPositionParentCycle) {
+			errMsg = err.Error()
+		}
+		renderBLPositionForm(w, r, "BL 포지션 수정", errMsg, item)
+		return
+	}
+
+	redirectWithSuccess(w, r, "/admin/bl_positions", "수정이 완료되었습니다.")
+}
+
+// blPositionFromForm reads the location form. Capacities are optional; the
+// returned message is empty when the input is usable.
+func blPositionFromForm(r *http.Request) (repo.BLPosition, string) {
 	item := repo.BLPosition{
-		ID:       id,
-		Name:     name,
-		IsActive: existing.IsActive,
-		UserID:   userID,
+		Name:         strings.TrimSpace(r.FormValue("name")),
+		LocationType: repo.BLLocationType(r.FormValue("location_type")),
+	}
+	if parentID, _ := strconv.ParseInt(r.FormValue("parent_id"), 10, 64); parentID > 0 {
+		item.ParentID = &parentID
 	}
+	if item.Name == "" {
+		return item, "이름을 입력해 주세요."
+	}
+	if strings.Contains(item.Name, strings.TrimSpace(repo.BLPositionPathSeparator)) {
+		return item, "이름에는 '>' 문자를 쓸 수 없습니다."
+	}
+	if !item.LocationType.Valid() {
+		return item, "위치 유형을 선택해 주세요."
+	}
+	if value := strings.TrimSpace(r.FormValue("capacity_pallets")); value != "" {
+		pallets, err := strconv.Atoi(value)
+		if err != nil || pallets < 0 {
+			return item, "팔레트 수용량은 0 이상의 정수로 입력해 주세요."
+		}
+		item.CapacityPallets = &pallets
+	}
+	if value := strings.TrimSpace(r.FormValue("capacity_cbm")); value != "" {
+		cbm, err := strconv.ParseFloat(value, 64)
+		if err != nil || cbm < 0 {
+			return item, "CBM 수용량은 0 이상의 숫자로 입력해 주세요."
+		}
+		item.CapacityCBM = &cbm
+	}
+	return item, ""
+}
 
-	if err := item.Update(r.Context()); err != nil {
-		view.Render(w, r, "bl_positions_form.html", view.PageData{
-			Title: "BL 포지션 수정",
-			Error: "수정 중 오류가 발생했습니다: " + err.Error(),
-			Data:  item,
-		})
+// renderBLPositionForm offers every location outside the item's own subtree
+// as a parent.
+func renderBLPositionForm(w http.ResponseWriter, r *http.Request, title string, errMsg string, item repo.BLPosition) {
+	repoItem := repo.BLPosition{}
+	tree, err := repoItem.ListTree(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	parents := make([]repo.BLPosition, 0, len(tree))
+	skipDepth := -1
+	for _, node := range tree {
+		if skipDepth >= 0 {
+			if node.Depth > skipDepth {
+				continue
+			}
+			skipDepth = -1
+		}
+		if item.ID > 0 && node.ID == item.ID {
+			skipDepth = node.Depth
+			continue
+		}
+		parents = append(parents, node)
+	}
 
-	redirectWithSuccess(w, r, "/admin/bl_positions", "수정이 완료되었습니다.")
+	view.Render(w, r, "bl_positions_form.html", view.PageData{
+		Title: title,
+		Error: errMsg,
+		Data: map[string]interface{}{
+			"Item":    item,
+			"Parents": parents,
+			"Types":   repo.BLLocationTypes,
+		},
+	})
 }
 
 func PostUpdateBLPositionStatus(w http.ResponseWriter, r *http.Request) {
@@ -204,7 +243,11 @@ func DeleteBLPosition(w http.ResponseWriter, r *http.Request) {
 	}
 	repoItem := repo.BLPosition{}
 	if err := repoItem.Delete(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, repo.ErrBLPositionHasChildren) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
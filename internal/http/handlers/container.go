@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"skycontainers/internal/iso6346"
 	"skycontainers/internal/pagination"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
@@ -152,7 +153,7 @@ func PostCreateContainer(w http.ResponseWriter, r *http.Request) {
 
 	item := repo.Container{
 		ContainerTypeID:       containerTypeID,
-		ContainerNo:           r.FormValue("container_no"),
+		ContainerNo:           iso6346.Normalize(r.FormValue("container_no")),
 		ContainerStatus:       r.FormValue("container_status"),
 		SupplierID:            supplierID,
 		BookingNo:             r.FormValue("booking_no"),
@@ -232,7 +233,7 @@ func PostCreateContainer(w http.ResponseWriter, r *http.Request) {
 		}
 		view.Render(w, r, "containers_form.html", view.PageData{
 			Title: "입출고 등록",
-			Error: "등록 중 오류가 발생했습니다: " + err.Error(),
+			Error: containerSaveError("등록", err),
 			Data:  data,
 		})
 		return
@@ -281,7 +282,7 @@ func PostUpdateContainer(w http.ResponseWriter, r *http.Request) {
 	item := repo.Container{
 		ID:                    id,
 		ContainerTypeID:       containerTypeID,
-		ContainerNo:           r.FormValue("container_no"),
+		ContainerNo:           iso6346.Normalize(r.FormValue("container_no")),
 		ContainerStatus:       r.FormValue("container_status"),
 		SupplierID:            supplierID,
 		BookingNo:             r.FormValue("booking_no"),
@@ -361,7 +362,7 @@ func PostUpdateContainer(w http.ResponseWriter, r *http.Request) {
 		}
 		view.Render(w, r, "containers_form.html", view.PageData{
 			Title: "입출고 수정",
-			Error: "수정 중 오류가 발생했습니다: " + err.Error(),
+			Error: containerSaveError("수정", err),
 			Data:  data,
 		})
 		return
@@ -473,9 +474,19 @@ func containerFormData(ctx context.Context, item repo.Container) (map[string]int
 		return nil, err
 	}
 
+	var events []repo.ContainerEvent
+	if item.ID > 0 {
+		repoEvent := repo.ContainerEvent{}
+		events, err = repoEvent.ListByContainerID(ctx, item.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return map[string]interface{}{
 		"Item":           item,
 		"ContainerTypes": containerTypes,
 		"Suppliers":      suppliers,
+		"Events":         events,
 	}, nil
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"skycontainers/internal/iso6346"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+	"strconv"
+)
+
+const containerNumberIssuesPath = "/admin/containers/number_issues"
+
+// ShowContainerNumberIssues lists stored container numbers that fail ISO 6346
+// or are not in canonical form.
+func ShowContainerNumberIssues(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceContainers, 0, "컨테이너 번호 점검"); !ok {
+		return
+	}
+	repoItem := repo.Container{}
+	issues, err := repoItem.ListNumberIssues(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	invalid := 0
+	for _, issue := range issues {
+		if issue.Reason != "" {
+			invalid++
+		}
+	}
+	view.Render(w, r, "containers_number_issues.html", view.PageData{
+		Title: "컨테이너 번호 점검",
+		Data: map[string]interface{}{
+			"Items":        issues,
+			"InvalidCount": invalid,
+			"FormatCount":  len(issues) - invalid,
+		},
+	})
+}
+
+func PostNormalizeContainerNumbers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, 0, "컨테이너 번호 정리"); !ok {
+		return
+	}
+	repoItem := repo.Container{}
+	updated, err := repoItem.NormalizeNumbers(r.Context())
+	if err != nil {
+		redirectWithError(w, r, containerNumberIssuesPath, "정리 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, containerNumberIssuesPath, strconv.Itoa(updated)+"건의 컨테이너 번호를 정리했습니다.")
+}
+
+// containerSaveError shows validation errors as they are and prefixes the
+// rest with the failed action.
+func containerSaveError(action string, err error) string {
+	if iso6346.IsInvalid(err) || errors.Is(err, repo.ErrContainerStateEdit) || errors.Is(err, repo.ErrContainerDateOrder) {
+		return err.Error()
+	}
+	return action + " 중 오류가 발생했습니다: " + err.Error()
+}
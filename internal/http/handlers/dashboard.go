@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/unipass"
 	"skycontainers/internal/view"
 )
 
@@ -10,7 +13,19 @@ func ShowDashboard(w http.ResponseWriter, r *http.Request) {
 	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceDashboard, 0, "대시보드"); !ok {
 		return
 	}
+
+	jobRepo := repo.UnipassJob{}
+	queued, err := jobRepo.CountQueued(r.Context())
+	if err != nil {
+		log.Printf("dashboard unipass queue count failed err=%v", err)
+	}
+	breaker, _ := unipass.CurrentStatus()
+
 	view.Render(w, r, "dashboard.html", view.PageData{
 		Title: "대시보드",
+		Data: map[string]interface{}{
+			"UnipassBreaker": breaker,
+			"UnipassQueued":  queued,
+		},
 	})
 }
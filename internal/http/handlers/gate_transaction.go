@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xuri/excelize/v2"
+)
+
+const gateReportPath = "/admin/gate_transactions"
+
+// RedirectCarNumbers sends the old car number list to the gate report that
+// replaced it.
+func RedirectCarNumbers(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, gateReportPath, http.StatusMovedPermanently)
+}
+
+// gateReportDay reads ?date= and falls back to today.
+func gateReportDay(r *http.Request) (time.Time, string) {
+	value := strings.TrimSpace(r.URL.Query().Get("date"))
+	if day, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return day, ""
+	}
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if value != "" {
+		return day, "날짜 형식이 올바르지 않아 오늘 기준으로 표시합니다."
+	}
+	return day, ""
+}
+
+func ShowGateReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceCarNumbers, 0, "게이트 일보"); !ok {
+		return
+	}
+	day, errMsg := gateReportDay(r)
+
+	repoItem := repo.GateTransaction{}
+	list, err := repoItem.ListByDay(r.Context(), day)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	inTrucks, outTrucks, inContainers, outContainers, unknownTrucks := 0, 0, 0, 0, 0
+	for _, item := range list {
+		switch item.Direction {
+		case repo.GateIn:
+			inTrucks++
+			inContainers += len(item.Containers)
+		case repo.GateOut:
+			outTrucks++
+			outContainers += len(item.Containers)
+		default:
+			unknownTrucks++
+		}
+	}
+	legacyCount, err := repoItem.CountLegacyCarNumbers(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "gate_report.html", view.PageData{
+		Title: "게이트 일보",
+		Error: errMsg,
+		Data: map[string]interface{}{
+			"Date":          day.Format("2006-01-02"),
+			"PrevDate":      day.AddDate(0, 0, -1).Format("2006-01-02"),
+			"NextDate":      day.AddDate(0, 0, 1).Format("2006-01-02"),
+			"Items":         list,
+			"InTrucks":      inTrucks,
+			"OutTrucks":     outTrucks,
+			"InContainers":  inContainers,
+			"OutContainers": outContainers,
+			"UnknownTrucks": unknownTrucks,
+			"LegacyCount":   legacyCount,
+		},
+	})
+}
+
+func ExportGateReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceCarNumbers, 0, "게이트 일보"); !ok {
+		return
+	}
+	day, _ := gateReportDay(r)
+
+	repoItem := repo.GateTransaction{}
+	list, err := repoItem.ListByDay(r.Context(), day)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	headers := []string{"시각", "구분", "차량번호", "기사", "연락처", "컨테이너 번호", "사이즈", "업체", "씰 번호", "메모", "처리자"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		_ = file.SetCellValue(sheet, cell, header)
+	}
+
+	// One row per container, so a truck carrying two boxes takes two rows.
+	row := 2
+	for _, item := range list {
+		containers := item.Containers
+		if len(containers) == 0 {
+			containers = []repo.GateTransactionContainer{{}}
+		}
+		for _, container := range containers {
+			values := []interface{}{
+				item.GateAt.Format("15:04"),
+				item.Direction.Label(),
+				item.TruckPlate,
+				item.DriverName,
+				item.DriverPhone,
+				container.ContainerNo,
+				container.ContainerTypeCode,
+				container.SupplierName,
+				container.SealNo,
+				item.Memo,
+				item.UserName,
+			}
+			for i, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				_ = file.SetCellValue(sheet, cell, value)
+			}
+			row++
+		}
+	}
+
+	filename := "gate_report_" + day.Format("20060102") + ".xlsx"
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	if err := file.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// ExportLegacyCarNumbers downloads the old car number rows the gate
+// transaction migration left in carnumbers, so they can be checked and entered
+// by hand.
+func ExportLegacyCarNumbers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceCarNumbers, 0, "게이트 일보"); !ok {
+		return
+	}
+
+	repoItem := repo.GateTransaction{}
+	list, err := repoItem.ListLegacyCarNumbers(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	headers := []string{"ID", "기록일(원본)", "차량번호", "등록일시"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		_ = file.SetCellValue(sheet, cell, header)
+	}
+	for i, item := range list {
+		row := strconv.Itoa(i + 2)
+		_ = file.SetCellValue(sheet, "A"+row, item.ID)
+		_ = file.SetCellValue(sheet, "B"+row, item.LogDate)
+		_ = file.SetCellValue(sheet, "C"+row, item.CarNo)
+		_ = file.SetCellValue(sheet, "D"+row, item.CreatedAt.Format("2006-01-02 15:04"))
+	}
+
+	filename := "carnumbers_not_migrated_" + time.Now().Format("20060102_150405") + ".xlsx"
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	if err := file.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func ShowCreateGateTransaction(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceCarNumbers, 0, "차량 출입 등록"); !ok {
+		return
+	}
+	view.Render(w, r, "gate_transaction_form.html", view.PageData{
+		Title: "차량 출입 등록",
+		Data: repo.GateTransaction{
+			Direction: repo.GateIn,
+			GateAt:    time.Now(),
+		},
+	})
+}
+
+func PostCreateGateTransaction(w http.ResponseWriter, r *http.Request) {
+	user, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceCarNumbers, 0, "차량 출입 등록")
+	if !ok {
+		return
+	}
+	item := repo.GateTransaction{
+		Direction:   repo.GateDirection(r.FormValue("direction")),
+		TruckPlate:  r.FormValue("truck_plate"),
+		DriverName:  r.FormValue("driver_name"),
+		DriverPhone: r.FormValue("driver_phone"),
+		Memo:        r.FormValue("memo"),
+	}
+	gateAt, err := parseOptionalDateTime(r.FormValue("gate_at"))
+	if err != nil {
+		view.Render(w, r, "gate_transaction_form.html", view.PageData{
+			Title: "차량 출입 등록",
+			Error: "출입 일시 형식이 올바르지 않습니다.",
+			Data:  item,
+		})
+		return
+	}
+	if gateAt != nil {
+		item.GateAt = *gateAt
+	}
+
+	if err := item.Create(r.Context(), user.ID); err != nil {
+		msg := "등록 중 오류가 발생했습니다: " + err.Error()
+		if errors.Is(err, repo.ErrGateTruckPlateRequired) || errors.Is(err, repo.ErrGateTruckPlateTooLong) || errors.Is(err, repo.ErrGateDirectionInvalid) {
+			msg = err.Error()
+		}
+		view.Render(w, r, "gate_transaction_form.html", view.PageData{
+			Title: "차량 출입 등록",
+			Error: msg,
+			Data:  item,
+		})
+		return
+	}
+
+	redirectWithSuccess(w, r, gateReportPath+"?date="+item.GateAt.Format("2006-01-02"), "등록이 완료되었습니다.")
+}
+
+func DeleteGateTransaction(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, ok := requirePermission(w, r, policy.ActionDelete, policy.ResourceCarNumbers, 0, "차량 출입 삭제"); !ok {
+		return
+	}
+	repoItem := repo.GateTransaction{}
+	if err := repoItem.Delete(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/tariff"
+	"skycontainers/internal/view"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const invoiceRatesPath = "/admin/invoice_rates"
+
+func ListInvoiceRates(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceInvoices, 0, "청구 요율"); !ok {
+		return
+	}
+	repoItem := repo.InvoiceRate{}
+	list, err := repoItem.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	view.Render(w, r, "invoice_rates_list.html", view.PageData{
+		Title: "청구 요율",
+		Data: map[string]interface{}{
+			"Items": list,
+		},
+	})
+}
+
+func ShowCreateInvoiceRate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceInvoices, 0, "청구 요율 등록"); !ok {
+		return
+	}
+	renderInvoiceRateForm(w, r, "청구 요율 등록", "", repo.InvoiceRate{Currency: tariff.DefaultCurrency})
+}
+
+func PostCreateInvoiceRate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceInvoices, 0, "청구 요율 등록"); !ok {
+		return
+	}
+	item, err := invoiceRateFromForm(r)
+	if err == nil {
+		err = item.Create(r.Context())
+	}
+	if err != nil {
+		renderInvoiceRateForm(w, r, "청구 요율 등록", invoiceRateError("등록", err), item)
+		return
+	}
+	redirectWithSuccess(w, r, invoiceRatesPath, "등록이 완료되었습니다.")
+}
+
+func ShowEditInvoiceRate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.InvoiceRate{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceInvoices, 0, "청구 요율 수정"); !ok {
+		return
+	}
+	renderInvoiceRateForm(w, r, "청구 요율 수정", "", *item)
+}
+
+func PostUpdateInvoiceRate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceInvoices, 0, "청구 요율 수정"); !ok {
+		return
+	}
+	item, err := invoiceRateFromForm(r)
+	item.ID = id
+	if err == nil {
+		err = item.Update(r.Context())
+	}
+	if err != nil {
+		renderInvoiceRateForm(w, r, "청구 요율 수정", invoiceRateError("수정", err), item)
+		return
+	}
+	redirectWithSuccess(w, r, invoiceRatesPath, "수정이 완료되었습니다.")
+}
+
+func DeleteInvoiceRate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, ok := requirePermission(w, r, policy.ActionDelete, policy.ResourceInvoices, 0, "청구 요율 삭제"); !ok {
+		return
+	}
+	repoItem := repo.InvoiceRate{}
+	if err := repoItem.Delete(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// invoiceRateFromForm reads the rate card. Handling fees come as
+// handling_<length_ft> fields; blank ones are not saved.
+func invoiceRateFromForm(r *http.Request) (repo.InvoiceRate, error) {
+	item := repo.InvoiceRate{
+		Currency: tariff.NormalizeCurrency(r.FormValue("currency")),
+		Memo:     strings.TrimSpace(r.FormValue("memo")),
+	}
+	if id, _ := strconv.ParseInt(r.FormValue("supplier_id"), 10, 64); id > 0 {
+		item.SupplierID = &id
+	}
+	blFee, err := tariff.ParseAmount(r.FormValue("bl_fee"), item.Currency)
+	if err != nil {
+		return item, err
+	}
+	item.BLFee = blFee
+
+	for key, values := range r.Form {
+		lengthText, ok := strings.CutPrefix(key, "handling_")
+		if !ok || len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+			continue
+		}
+		lengthFT, err := strconv.ParseInt(lengthText, 10, 16)
+		if err != nil {
+			continue
+		}
+		amount, err := tariff.ParseAmount(values[0], item.Currency)
+		if err != nil {
+			return item, err
+		}
+		item.HandlingFees = append(item.HandlingFees, repo.InvoiceHandlingFee{LengthFT: int16(lengthFT), Amount: amount})
+	}
+	return item, nil
+}
+
+func invoiceRateError(action string, err error) string {
+	if errors.Is(err, repo.ErrInvoiceRateDuplicate) {
+		return err.Error()
+	}
+	return action + " 중 오류가 발생했습니다: " + err.Error()
+}
+
+// invoiceRateLengthRow is one handling fee input of the form.
+type invoiceRateLengthRow struct {
+	LengthFT int16
+	Codes    string
+	Amount   string
+}
+
+func renderInvoiceRateForm(w http.ResponseWriter, r *http.Request, title string, errMsg string, item repo.InvoiceRate) {
+	data, err := invoiceRateFormData(r.Context(), item)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	view.Render(w, r, "invoice_rates_form.html", view.PageData{
+		Title: title,
+		Error: errMsg,
+		Data:  data,
+	})
+}
+
+// invoiceRateFormData offers one handling fee input per container length in
+// use, plus any length the card already has.
+func invoiceRateFormData(ctx context.Context, item repo.InvoiceRate) (map[string]interface{}, error) {
+	supplierRepo := repo.Supplier{}
+	suppliers, err := supplierRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	typeRepo := repo.ContainerType{}
+	types, err := typeRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[int16][]string)
+	for _, t := range types {
+		codes[t.LengthFT] = append(codes[t.LengthFT], t.Code)
+	}
+	for _, fee := range item.HandlingFees {
+		if _, ok := codes[fee.LengthFT]; !ok {
+			codes[fee.LengthFT] = nil
+		}
+	}
+	rows := make([]invoiceRateLengthRow, 0, len(codes))
+	for lengthFT, list := range codes {
+		row := invoiceRateLengthRow{LengthFT: lengthFT, Codes: strings.Join(list, ", ")}
+		if amount, ok := item.HandlingFee(lengthFT); ok {
+			row.Amount = tariff.FormatAmount(amount, item.Currency)
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].LengthFT < rows[j].LengthFT })
+
+	return map[string]interface{}{
+		"Item":      item,
+		"Lengths":   rows,
+		"Suppliers": suppliers,
+	}, nil
+}
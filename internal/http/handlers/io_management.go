@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"skycontainers/internal/iso6346"
 	"skycontainers/internal/pagination"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
@@ -18,13 +20,7 @@ func ShowIOManagement(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tab := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tab")))
-	if tab == "" {
-		tab = "inbound"
-	}
-	if tab != "inbound" && tab != "work" && tab != "outbound" {
-		tab = "inbound"
-	}
+	tab := ioManagementTab(r.URL.Query().Get("tab"))
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
@@ -46,6 +42,7 @@ func ShowIOManagement(w http.ResponseWriter, r *http.Request) {
 			"Tab":   tab,
 			"Items": list,
 			"Pager": pager,
+			"Today": time.Now().Format("2006-01-02"),
 		},
 	})
 }
@@ -90,10 +87,15 @@ func PostIOInbound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "입고등록"); !ok {
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "입고등록")
+	if !ok {
 		return
 	}
 
+	// The truck is optional, as in PostIOBulk; without a plate no gate
+	// transaction is recorded.
+	gate := gateTransactionFromForm(r, id)
+
 	// Handle type update if provided
 	typeID, _ := strconv.ParseInt(r.FormValue("type_id"), 10, 64)
 	if typeID > 0 {
@@ -104,8 +106,12 @@ func PostIOInbound(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := repoItem.MarkInboundToday(r.Context(), id); err != nil {
-		redirectWithError(w, r, "/admin/io_management?tab=inbound", err.Error())
+	if err := repoItem.MarkInboundToday(r.Context(), id, user.ID, gate); err != nil {
+		message := err.Error()
+		if iso6346.IsInvalid(err) {
+			message += " 번호를 수정한 뒤 입고등록해 주세요."
+		}
+		redirectWithError(w, r, "/admin/io_management?tab=inbound", message)
 		return
 	}
 	redirectWithSuccess(w, r, "/admin/io_management?tab=inbound", "입고등록이 완료되었습니다.")
@@ -124,17 +130,63 @@ func PostIOProcessing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "작업확인"); !ok {
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "작업확인")
+	if !ok {
 		return
 	}
 
-	if err := repoItem.MarkProcessingToday(r.Context(), id); err != nil {
+	if err := repoItem.MarkProcessingToday(r.Context(), id, user.ID); err != nil {
 		redirectWithError(w, r, "/admin/io_management?tab=work", err.Error())
 		return
 	}
 	redirectWithSuccess(w, r, "/admin/io_management?tab=work", "작업확인이 완료되었습니다.")
 }
 
+func PostIOStartDevanning(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if id <= 0 {
+		redirectWithError(w, r, "/admin/io_management?tab=work", "잘못된 요청입니다.")
+		return
+	}
+	repoItem := repo.Container{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		redirectWithError(w, r, "/admin/io_management?tab=work", "대상 컨테이너를 찾을 수 없습니다.")
+		return
+	}
+
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "작업 시작")
+	if !ok {
+		return
+	}
+
+	if err := repoItem.Transition(r.Context(), id, repo.TransitionStartDevanning, time.Now(), user.ID, ""); err != nil {
+		redirectWithError(w, r, "/admin/io_management?tab=work", err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, "/admin/io_management?tab=work", "작업을 시작했습니다.")
+}
+
+func ShowOutboundModal(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.Container{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "대상 컨테이너를 찾을 수 없습니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "출고등록"); !ok {
+		return
+	}
+
+	view.Render(w, r, "io_outbound_modal.html", view.PageData{
+		Title: "출고 등록",
+		Data: map[string]interface{}{
+			"Item": item,
+		},
+	})
+}
+
 func PostIOOutbound(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if id <= 0 {
@@ -148,13 +200,205 @@ func PostIOOutbound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "출고등록"); !ok {
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "출고등록")
+	if !ok {
 		return
 	}
+	// The truck is optional, as in PostIOBulk; without a plate no gate
+	// transaction is recorded.
+	gate := gateTransactionFromForm(r, id)
 
-	if err := repoItem.MarkOutboundToday(r.Context(), id); err != nil {
+	if err := repoItem.MarkOutboundToday(r.Context(), id, user.ID, gate); err != nil {
 		redirectWithError(w, r, "/admin/io_management?tab=outbound", err.Error())
 		return
 	}
 	redirectWithSuccess(w, r, "/admin/io_management?tab=outbound", "출고등록이 완료되었습니다.")
 }
+
+// ShowIOUndoModal asks for the reason before a transition is reversed.
+func ShowIOUndoModal(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.Container{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "대상 컨테이너를 찾을 수 없습니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "입출고 취소"); !ok {
+		return
+	}
+	transition := repo.ContainerTransition(r.URL.Query().Get("transition"))
+	if !transition.IsUndo() {
+		http.Error(w, "잘못된 요청입니다.", http.StatusBadRequest)
+		return
+	}
+
+	view.Render(w, r, "io_undo_modal.html", view.PageData{
+		Title: transition.Label(),
+		Data: map[string]interface{}{
+			"Item":       item,
+			"Transition": transition,
+			"Tab":        ioManagementTab(r.URL.Query().Get("tab")),
+		},
+	})
+}
+
+func PostIOUndo(w http.ResponseWriter, r *http.Request) {
+	backPath := "/admin/io_management?tab=" + ioManagementTab(r.FormValue("tab"))
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if id <= 0 {
+		redirectWithError(w, r, backPath, "잘못된 요청입니다.")
+		return
+	}
+	repoItem := repo.Container{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		redirectWithError(w, r, backPath, "대상 컨테이너를 찾을 수 없습니다.")
+		return
+	}
+
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceContainers, item.UserID, "입출고 취소")
+	if !ok {
+		return
+	}
+
+	transition := repo.ContainerTransition(r.FormValue("transition"))
+	if !transition.IsUndo() {
+		redirectWithError(w, r, backPath, "잘못된 요청입니다.")
+		return
+	}
+	if err := repoItem.Transition(r.Context(), id, transition, time.Now(), user.ID, r.FormValue("reason")); err != nil {
+		redirectWithError(w, r, backPath, err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, backPath, item.ContainerNo+" "+transition.Label()+" 처리했습니다.")
+}
+
+func ioManagementTab(value string) string {
+	tab := strings.ToLower(strings.TrimSpace(value))
+	switch tab {
+	case "inbound", "work", "outbound", "done":
+		return tab
+	default:
+		return "inbound"
+	}
+}
+
+var ioBulkTransitions = map[string]repo.ContainerTransition{
+	"inbound":    repo.TransitionGateIn,
+	"processing": repo.TransitionDevan,
+	"outbound":   repo.TransitionGateOut,
+}
+
+// PostIOBulk applies one I/O step to the selected containers on a chosen
+// date and shows the per-container outcome in the global modal.
+func PostIOBulk(w http.ResponseWriter, r *http.Request) {
+	// Staff may only move their own containers, so the gate here checks the
+	// action and each container is checked again below.
+	user, ok := currentUser(r.Context())
+	if !ok {
+		http.Error(w, "로그인 사용자 정보를 찾을 수 없습니다.", http.StatusUnauthorized)
+		return
+	}
+	if !policy.Allow(user, policy.ActionUpdate, policy.ResourceContainers, user.ID) {
+		renderPermissionDenied(w, r, "일괄 처리")
+		return
+	}
+
+	transition, ok := ioBulkTransitions[r.FormValue("action")]
+	if !ok {
+		renderModalMessage(w, r, "일괄 처리", "잘못된 요청입니다.")
+		return
+	}
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, value := range r.Form["ids"] {
+		id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		renderModalMessage(w, r, "일괄 처리", "처리할 컨테이너를 선택해 주세요.")
+		return
+	}
+	date, err := parseOptionalDate(r.FormValue("date"))
+	if err != nil {
+		renderModalMessage(w, r, "일괄 처리", "날짜 형식이 올바르지 않습니다.")
+		return
+	}
+	if date == nil {
+		today := time.Now()
+		date = &today
+	}
+	if date.After(time.Now()) {
+		renderModalMessage(w, r, "일괄 처리", "오늘 이후 날짜로는 처리할 수 없습니다.")
+		return
+	}
+	// Inbound and outbound cross the gate; the truck is optional, as for a
+	// single container, so back-dated batches can be entered without one.
+	var gate *repo.GateTransaction
+	if transition == repo.TransitionGateIn || transition == repo.TransitionGateOut {
+		gate = gateTransactionFromForm(r, 0)
+		if gate != nil {
+			gate.GateAt = gateTimeOn(*date)
+		}
+	}
+
+	repoItem := repo.Container{}
+	results, err := repoItem.BulkTransition(r.Context(), ids, transition, *date, user.ID, func(ownerID int64) bool {
+		return policy.Allow(user, policy.ActionUpdate, policy.ResourceContainers, ownerID)
+	}, gate)
+	if err != nil {
+		renderModalMessage(w, r, "일괄 처리", "처리 중 오류가 발생해 모두 취소했습니다: "+err.Error())
+		return
+	}
+	succeeded := 0
+	for _, result := range results {
+		if result.OK {
+			succeeded++
+		}
+	}
+
+	view.Render(w, r, "io_bulk_result.html", view.PageData{
+		Title: "일괄 " + transition.Label() + " 결과",
+		Data: map[string]interface{}{
+			"Results":   results,
+			"Date":      date.Format("2006-01-02"),
+			"Succeeded": succeeded,
+			"Rejected":  len(results) - succeeded,
+		},
+	})
+}
+
+// gateTransactionFromForm reads the truck fields posted with an inbound or
+// outbound. It returns nil when no truck plate was entered. The seal number
+// belongs to containerID; bulk posts pass 0 and carry no seals.
+func gateTransactionFromForm(r *http.Request, containerID int64) *repo.GateTransaction {
+	plate := strings.TrimSpace(r.FormValue("truck_plate"))
+	if plate == "" {
+		return nil
+	}
+	gate := &repo.GateTransaction{
+		TruckPlate:  plate,
+		DriverName:  r.FormValue("driver_name"),
+		DriverPhone: r.FormValue("driver_phone"),
+		GateAt:      time.Now(),
+	}
+	if containerID > 0 {
+		gate.Containers = []repo.GateTransactionContainer{{ContainerID: containerID, SealNo: r.FormValue("seal_no")}}
+	}
+	return gate
+}
+
+// gateTimeOn keeps the current time for today and uses midnight for an
+// earlier day, so back-dated gate entries land on the right report date.
+func gateTimeOn(date time.Time) time.Time {
+	now := time.Now()
+	if date.Year() == now.Year() && date.YearDay() == now.YearDay() {
+		return now
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+}
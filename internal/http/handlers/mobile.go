@@ -100,7 +100,7 @@ func PostMobileScanSave(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update Position
-	if err := markingRepo.UpdatePosition(r.Context(), item.ID, positionID); err != nil {
+	if err := markingRepo.UpdatePosition(r.Context(), item.ID, positionID, blPositionMover(r, repo.BLMoveMobileScan)); err != nil {
 		w.Header().Set("HX-Reswap", "innerHTML")
 		fmt.Fprint(w, `<div class="toast toast-error">저장 실패: `+err.Error()+`</div>`)
 		return
@@ -121,6 +121,9 @@ func ShowMobileSearch(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// mobileSearchMoveLimit is how many recent moves the search result lists.
+const mobileSearchMoveLimit = 5
+
 func GetMobileSearchResult(w http.ResponseWriter, r *http.Request) {
 	hblNo := r.URL.Query().Get("hbl_no")
 
@@ -144,12 +147,44 @@ func GetMobileSearchResult(w http.ResponseWriter, r *http.Request) {
 		posName = "위치 미지정"
 	}
 
+	// Recent moves so the worker can tell where the BL was before. The
+	// history is a nice-to-have; a failed lookup only hides it.
+	var history strings.Builder
+	moveRepo := repo.BLPositionMove{}
+	if moves, err := moveRepo.ListByBLMarkingID(r.Context(), item.ID); err == nil && len(moves) > 0 {
+		if len(moves) > mobileSearchMoveLimit {
+			moves = moves[:mobileSearchMoveLimit]
+		}
+		history.WriteString(`<div class="move-history-title">위치 이동 이력</div><ol class="move-history">`)
+		for _, move := range moves {
+			from, to := move.FromPath, move.ToPath
+			if from == "" {
+				from = "-"
+			}
+			if to == "" {
+				to = "-"
+			}
+			by := move.Source.Label()
+			if move.UserName != "" {
+				by += " · " + move.UserName
+			}
+			if label := move.DeviceLabel(); label != "" {
+				by += " · " + label
+			}
+			fmt.Fprintf(&history, `<li><span class="move-time">%s</span> %s → <strong>%s</strong><span class="move-by">%s</span></li>`,
+				move.MovedAt.Format("01-02 15:04"), template.HTMLEscapeString(from),
+				template.HTMLEscapeString(to), template.HTMLEscapeString(by))
+		}
+		history.WriteString(`</ol>`)
+	}
+
 	fmt.Fprintf(w, `
 		<div class="result-card">
 			<div class="supplier-name">%s</div>
 			<div class="position-name">%s</div>
+			%s
 		</div>
-	`, supplierName, posName)
+	`, template.HTMLEscapeString(supplierName), template.HTMLEscapeString(posName), history.String())
 }
 
 // 3. Leave Routes
@@ -41,7 +41,7 @@ func policyRoles() []policyRole {
 		{
 			Key:       "admin",
 			Label:     "관리자",
-			Summary:   "설정(규격/업체/BL포지션/차량번호) CRUD + 사용자 조회",
+			Summary:   "설정(규격/업체/BL포지션), 게이트 일보, 보관료, 청구서 CRUD + 사용자 조회",
 			Detail:    "관리자는 설정 메뉴의 주요 마스터 데이터를 등록/수정/삭제할 수 있으며, 사용자 관리는 조회만 가능합니다. 기타 메뉴는 정책에서 개별적으로 허용해야 합니다.",
 			Exception: "기본 운영 규칙: 사용자관리는 조회만 허용됩니다.",
 		},
@@ -71,10 +71,13 @@ func policyResources() []policyResource {
 		{Key: string(policy.ResourceContainerTypes), Label: "규격관리"},
 		{Key: string(policy.ResourceSuppliers), Label: "업체관리"},
 		{Key: string(policy.ResourceBLPositions), Label: "BL 포지션"},
-		{Key: string(policy.ResourceCarNumbers), Label: "차량번호"},
+		{Key: string(policy.ResourceCarNumbers), Label: "게이트 일보"},
+		{Key: string(policy.ResourceStorageTariffs), Label: "보관료"},
+		{Key: string(policy.ResourceInvoices), Label: "청구서"},
 		{Key: string(policy.ResourceUsers), Label: "사용자관리"},
 		{Key: string(policy.ResourceSupplierPortal), Label: "업체 전용 조회"},
 		{Key: string(policy.ResourcePolicies), Label: "권한관리"},
+		{Key: string(policy.ResourceSettings), Label: "시스템 설정"},
 	}
 }
 
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+	"skycontainers/internal/zpl"
+	"strings"
+)
+
+func ShowSettings(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSettings, 0, "시스템 설정"); !ok {
+		return
+	}
+	settingRepo := repo.AppSetting{}
+	printerAddr, err := settingRepo.Get(r.Context(), repo.SettingLabelPrinterAddr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	renderSettings(w, r, printerAddr, "")
+}
+
+func PostUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSettings, 0, "시스템 설정")
+	if !ok {
+		return
+	}
+	printerAddr := strings.TrimSpace(r.FormValue("label_printer_addr"))
+	normalized, err := zpl.PrinterAddress(printerAddr)
+	if err != nil {
+		renderSettings(w, r, printerAddr, err.Error())
+		return
+	}
+	settingRepo := repo.AppSetting{}
+	if err := settingRepo.Set(r.Context(), repo.SettingLabelPrinterAddr, normalized, user.ID); err != nil {
+		renderSettings(w, r, printerAddr, "저장 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, "/admin/settings", "설정을 저장했습니다.")
+}
+
+func renderSettings(w http.ResponseWriter, r *http.Request, printerAddr string, message string) {
+	view.Render(w, r, "settings.html", view.PageData{
+		Title: "시스템 설정",
+		Error: message,
+		Data: map[string]interface{}{
+			"LabelPrinterAddr": printerAddr,
+			"DefaultPort":      zpl.DefaultPort,
+		},
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/tariff"
+	"skycontainers/internal/view"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// storageChargeRow is one container's storage within the billing period.
+// Tariff is nil when no tariff matches, and the row is then left at zero.
+type storageChargeRow struct {
+	Container repo.Container
+	Tariff    *repo.StorageTariff
+	Currency  string
+	Charge    tariff.Charge
+}
+
+// storageChargeTotal sums the rows of one supplier in one currency.
+type storageChargeTotal struct {
+	SupplierID     int64
+	SupplierName   string
+	Currency       string
+	Containers     int
+	BilledDays     int
+	ChargeableDays int
+	Amount         int64
+}
+
+type storageChargeFilters struct {
+	Start      time.Time
+	End        time.Time
+	SupplierID int64
+	ErrMsg     string
+}
+
+// parseStorageChargeFilters reads the billing period, defaulting to the
+// current month.
+func parseStorageChargeFilters(r *http.Request) storageChargeFilters {
+	now := time.Now()
+	filters := storageChargeFilters{
+		Start: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+	}
+	filters.End = filters.Start.AddDate(0, 1, -1)
+	filters.SupplierID, _ = strconv.ParseInt(r.URL.Query().Get("supplier_id"), 10, 64)
+
+	start, err := parseOptionalDate(r.URL.Query().Get("start"))
+	if err != nil {
+		filters.ErrMsg = "시작일 형식이 올바르지 않습니다."
+		return filters
+	}
+	end, err := parseOptionalDate(r.URL.Query().Get("end"))
+	if err != nil {
+		filters.ErrMsg = "종료일 형식이 올바르지 않습니다."
+		return filters
+	}
+	if start != nil {
+		filters.Start = *start
+	}
+	if end != nil {
+		filters.End = *end
+	}
+	if filters.End.Before(filters.Start) {
+		filters.ErrMsg = "종료일은 시작일 이후여야 합니다."
+	}
+	return filters
+}
+
+func calculateStorageCharges(ctx context.Context, filters storageChargeFilters) ([]storageChargeRow, []storageChargeTotal, error) {
+	tariffRepo := repo.StorageTariff{}
+	tariffs, err := tariffRepo.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	containerRepo := repo.Container{}
+	containers, err := containerRepo.ListForStorageCharges(ctx, filters.Start, filters.End, filters.SupplierID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]storageChargeRow, 0, len(containers))
+	var totals []storageChargeTotal
+	totalIndex := make(map[string]int)
+	for _, container := range containers {
+		row := storageChargeRow{Container: container, Currency: tariff.DefaultCurrency}
+		row.Tariff = repo.MatchStorageTariff(tariffs, container.SupplierID, container.ContainerTypeID)
+		calc := tariff.Tariff{}
+		if row.Tariff != nil {
+			calc = row.Tariff.Tariff()
+			row.Currency = row.Tariff.Currency
+		}
+		row.Charge = calc.Calculate(*container.InboundDate, container.OutboundDate, filters.Start, filters.End)
+		rows = append(rows, row)
+
+		key := strconv.FormatInt(container.SupplierID, 10) + "/" + row.Currency
+		i, ok := totalIndex[key]
+		if !ok {
+			i = len(totals)
+			totalIndex[key] = i
+			totals = append(totals, storageChargeTotal{
+				SupplierID:   container.SupplierID,
+				SupplierName: container.SupplierName,
+				Currency:     row.Currency,
+			})
+		}
+		totals[i].Containers++
+		totals[i].BilledDays += row.Charge.BilledDays
+		totals[i].ChargeableDays += row.Charge.ChargeableDays
+		totals[i].Amount += row.Charge.Amount
+	}
+	return rows, totals, nil
+}
+
+func ShowStorageCharges(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceStorageTariffs, 0, "보관료 계산"); !ok {
+		return
+	}
+	filters := parseStorageChargeFilters(r)
+
+	var rows []storageChargeRow
+	var totals []storageChargeTotal
+	if filters.ErrMsg == "" {
+		var err error
+		rows, totals, err = calculateStorageCharges(r.Context(), filters)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	supplierRepo := repo.Supplier{}
+	suppliers, err := supplierRepo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	unmatched := 0
+	for _, row := range rows {
+		if row.Tariff == nil {
+			unmatched++
+		}
+	}
+
+	view.Render(w, r, "storage_charges.html", view.PageData{
+		Title: "보관료 계산",
+		Error: filters.ErrMsg,
+		Data: map[string]interface{}{
+			"Start":      filters.Start.Format("2006-01-02"),
+			"End":        filters.End.Format("2006-01-02"),
+			"SupplierID": filters.SupplierID,
+			"Suppliers":  suppliers,
+			"Rows":       rows,
+			"Totals":     totals,
+			"Unmatched":  unmatched,
+		},
+	})
+}
+
+func ExportStorageCharges(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceStorageTariffs, 0, "보관료 계산"); !ok {
+		return
+	}
+	filters := parseStorageChargeFilters(r)
+	if filters.ErrMsg != "" {
+		http.Error(w, filters.ErrMsg, http.StatusBadRequest)
+		return
+	}
+	rows, totals, err := calculateStorageCharges(r.Context(), filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	_ = file.SetSheetName(sheet, "업체별")
+	sheet = "업체별"
+	headers := []string{"업체", "통화", "컨테이너 수", "청구 대상 일수", "유료 일수", "보관료"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		_ = file.SetCellValue(sheet, cell, header)
+	}
+	for i, total := range totals {
+		row := strconv.Itoa(i + 2)
+		_ = file.SetCellValue(sheet, "A"+row, total.SupplierName)
+		_ = file.SetCellValue(sheet, "B"+row, total.Currency)
+		_ = file.SetCellValue(sheet, "C"+row, total.Containers)
+		_ = file.SetCellValue(sheet, "D"+row, total.BilledDays)
+		_ = file.SetCellValue(sheet, "E"+row, total.ChargeableDays)
+		_ = file.SetCellValue(sheet, "F"+row, tariff.AmountValue(total.Amount, total.Currency))
+	}
+
+	detail := "컨테이너별"
+	_, _ = file.NewSheet(detail)
+	headers = []string{"업체", "컨테이너 번호", "컨테이너 타입", "입고일", "출고일", "보관 일차", "청구 대상 일수", "무료 일수", "유료 일수", "통화", "보관료", "비고"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		_ = file.SetCellValue(detail, cell, header)
+	}
+	for i, item := range rows {
+		row := strconv.Itoa(i + 2)
+		_ = file.SetCellValue(detail, "A"+row, item.Container.SupplierName)
+		_ = file.SetCellValue(detail, "B"+row, item.Container.ContainerNo)
+		_ = file.SetCellValue(detail, "C"+row, item.Container.ContainerTypeCode)
+		_ = file.SetCellValue(detail, "D"+row, formatDatePtr(item.Container.InboundDate))
+		_ = file.SetCellValue(detail, "E"+row, formatDatePtr(item.Container.OutboundDate))
+		_ = file.SetCellValue(detail, "F"+row, item.Charge.StorageDay)
+		_ = file.SetCellValue(detail, "G"+row, item.Charge.BilledDays)
+		_ = file.SetCellValue(detail, "H"+row, item.Charge.FreeDays)
+		_ = file.SetCellValue(detail, "I"+row, item.Charge.ChargeableDays)
+		_ = file.SetCellValue(detail, "J"+row, item.Currency)
+		_ = file.SetCellValue(detail, "K"+row, tariff.AmountValue(item.Charge.Amount, item.Currency))
+		if item.Tariff == nil {
+			_ = file.SetCellValue(detail, "L"+row, "요율 없음")
+		}
+	}
+
+	filename := "storage_charges_" + filters.Start.Format("20060102") + "_" + filters.End.Format("20060102") + ".xlsx"
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	if err := file.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
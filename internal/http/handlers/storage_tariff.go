@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/tariff"
+	"skycontainers/internal/view"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const storageTariffsPath = "/admin/storage_tariffs"
+
+// storageTariffFormRows is how many tier rows the form shows beyond the
+// ones already saved.
+const storageTariffFormRows = 3
+
+func ListStorageTariffs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceStorageTariffs, 0, "보관료 요율"); !ok {
+		return
+	}
+	repoItem := repo.StorageTariff{}
+	list, err := repoItem.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "storage_tariffs_list.html", view.PageData{
+		Title: "보관료 요율",
+		Data: map[string]interface{}{
+			"Items": list,
+		},
+	})
+}
+
+func ShowCreateStorageTariff(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceStorageTariffs, 0, "요율 등록"); !ok {
+		return
+	}
+	renderStorageTariffForm(w, r, "요율 등록", "", repo.StorageTariff{
+		Currency: tariff.DefaultCurrency,
+		Tiers:    []repo.StorageTariffTier{{FromDay: 1}},
+	})
+}
+
+func PostCreateStorageTariff(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceStorageTariffs, 0, "요율 등록"); !ok {
+		return
+	}
+	item, err := storageTariffFromForm(r)
+	if err == nil {
+		err = item.Create(r.Context())
+	}
+	if err != nil {
+		renderStorageTariffForm(w, r, "요율 등록", storageTariffError("등록", err), item)
+		return
+	}
+	redirectWithSuccess(w, r, storageTariffsPath, "등록이 완료되었습니다.")
+}
+
+func ShowEditStorageTariff(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.StorageTariff{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceStorageTariffs, 0, "요율 수정"); !ok {
+		return
+	}
+	renderStorageTariffForm(w, r, "요율 수정", "", *item)
+}
+
+func PostUpdateStorageTariff(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceStorageTariffs, 0, "요율 수정"); !ok {
+		return
+	}
+	item, err := storageTariffFromForm(r)
+	item.ID = id
+	if err == nil {
+		err = item.Update(r.Context())
+	}
+	if err != nil {
+		renderStorageTariffForm(w, r, "요율 수정", storageTariffError("수정", err), item)
+		return
+	}
+	redirectWithSuccess(w, r, storageTariffsPath, "수정이 완료되었습니다.")
+}
+
+func DeleteStorageTariff(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, ok := requirePermission(w, r, policy.ActionDelete, policy.ResourceStorageTariffs, 0, "요율 삭제"); !ok {
+		return
+	}
+	repoItem := repo.StorageTariff{}
+	if err := repoItem.Delete(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// storageTariffFromForm reads the tariff and its tier rows. Rows without a
+// rate are skipped, so the blank rows the form adds can stay empty.
+func storageTariffFromForm(r *http.Request) (repo.StorageTariff, error) {
+	item := repo.StorageTariff{
+		Currency: tariff.NormalizeCurrency(r.FormValue("currency")),
+		Memo:     strings.TrimSpace(r.FormValue("memo")),
+	}
+	if id, _ := strconv.ParseInt(r.FormValue("supplier_id"), 10, 64); id > 0 {
+		item.SupplierID = &id
+	}
+	if id, _ := strconv.ParseInt(r.FormValue("containers_type_id"), 10, 64); id > 0 {
+		item.ContainerTypeID = &id
+	}
+	freeDays, err := strconv.Atoi(strings.TrimSpace(r.FormValue("free_days")))
+	if err != nil && strings.TrimSpace(r.FormValue("free_days")) != "" {
+		return item, errors.New("무료 일수는 숫자로 입력해 주세요.")
+	}
+	item.FreeDays = freeDays
+
+	fromDays := r.Form["tier_from_day"]
+	rates := r.Form["tier_rate"]
+	for i := range rates {
+		if strings.TrimSpace(rates[i]) == "" {
+			continue
+		}
+		fromDay := 1
+		if i < len(fromDays) {
+			if value, err := strconv.Atoi(strings.TrimSpace(fromDays[i])); err == nil {
+				fromDay = value
+			}
+		}
+		rate, err := tariff.ParseAmount(rates[i], item.Currency)
+		if err != nil {
+			return item, err
+		}
+		item.Tiers = append(item.Tiers, repo.StorageTariffTier{FromDay: fromDay, Rate: rate})
+	}
+	return item, nil
+}
+
+func storageTariffError(action string, err error) string {
+	if errors.Is(err, repo.ErrStorageTariffDuplicate) || errors.Is(err, repo.ErrStorageTariffTiersRequired) {
+		return err.Error()
+	}
+	return action + " 중 오류가 발생했습니다: " + err.Error()
+}
+
+func renderStorageTariffForm(w http.ResponseWriter, r *http.Request, title string, errMsg string, item repo.StorageTariff) {
+	data, err := storageTariffFormData(r.Context(), item)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	view.Render(w, r, "storage_tariffs_form.html", view.PageData{
+		Title: title,
+		Error: errMsg,
+		Data:  data,
+	})
+}
+
+func storageTariffFormData(ctx context.Context, item repo.StorageTariff) (map[string]interface{}, error) {
+	supplierRepo := repo.Supplier{}
+	suppliers, err := supplierRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	typeRepo := repo.ContainerType{}
+	types, err := typeRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tiers := append([]repo.StorageTariffTier(nil), item.Tiers...)
+	for i := 0; i < storageTariffFormRows; i++ {
+		tiers = append(tiers, repo.StorageTariffTier{})
+	}
+	return map[string]interface{}{
+		"Item":           item,
+		"Tiers":          tiers,
+		"Suppliers":      suppliers,
+		"ContainerTypes": types,
+	}, nil
+}
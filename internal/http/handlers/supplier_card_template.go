@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/scancode"
+	"skycontainers/internal/view"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxCargoCardLogoBytes = 512 << 10
+
+type cargoCardFieldOption struct {
+	Key     string
+	Label   string
+	Checked bool
+	Order   int
+}
+
+func ShowSupplierCardTemplate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSuppliers, 0, "업체 관리"); !ok {
+		return
+	}
+	supplier, ok := supplierFromURL(w, r)
+	if !ok {
+		return
+	}
+	templateRepo := repo.CargoCardTemplate{}
+	current, err := templateRepo.GetBySupplier(r.Context(), supplier.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	item := current
+	if item == nil {
+		layout := defaultCargoCardLayout()
+		item = &repo.CargoCardTemplate{
+			SupplierID:  supplier.ID,
+			Title:       layout.Title,
+			Fields:      strings.Join(layout.Fields, ","),
+			ShowOverlay: true,
+		}
+	}
+	renderSupplierCardTemplate(w, r, supplier, item, current != nil, "")
+}
+
+func PostUpdateSupplierCardTemplate(w http.ResponseWriter, r *http.Request) {
+	user, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "화물카드 템플릿 수정")
+	if !ok {
+		return
+	}
+	supplier, ok := supplierFromURL(w, r)
+	if !ok {
+		return
+	}
+	templateRepo := repo.CargoCardTemplate{}
+	current, err := templateRepo.GetBySupplier(r.Context(), supplier.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	item := cargoCardTemplateFromForm(r, supplier.ID)
+	item.UpdatedBy = user.ID
+	if current != nil {
+		item.HasLogo = current.HasLogo
+	}
+	if item.Fields == "" {
+		renderSupplierCardTemplate(w, r, supplier, &item, current != nil, "출력할 항목을 하나 이상 선택해 주세요.")
+		return
+	}
+
+	replaceLogo := r.FormValue("remove_logo") == "1"
+	if replaceLogo {
+		item.HasLogo = false
+	}
+	if file, _, err := r.FormFile("logo"); err == nil {
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxCargoCardLogoBytes+1))
+		if err != nil {
+			renderSupplierCardTemplate(w, r, supplier, &item, current != nil, "로고 파일을 읽을 수 없습니다.")
+			return
+		}
+		if len(data) > maxCargoCardLogoBytes {
+			renderSupplierCardTemplate(w, r, supplier, &item, current != nil, "로고 파일은 512KB 이하로 올려 주세요.")
+			return
+		}
+		contentType := http.DetectContentType(data)
+		if contentType != "image/png" && contentType != "image/jpeg" {
+			renderSupplierCardTemplate(w, r, supplier, &item, current != nil, "로고는 PNG 또는 JPG 파일만 사용할 수 있습니다.")
+			return
+		}
+		item.Logo = data
+		item.LogoContentType = contentType
+		item.HasLogo = true
+		replaceLogo = true
+	}
+
+	if err := item.Save(r.Context(), replaceLogo); err != nil {
+		renderSupplierCardTemplate(w, r, supplier, &item, current != nil, "저장 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, supplierCardTemplatePath(supplier.ID), "화물카드 템플릿을 저장했습니다.")
+}
+
+func PostResetSupplierCardTemplate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "화물카드 템플릿 수정"); !ok {
+		return
+	}
+	supplier, ok := supplierFromURL(w, r)
+	if !ok {
+		return
+	}
+	templateRepo := repo.CargoCardTemplate{}
+	if err := templateRepo.DeleteBySupplier(r.Context(), supplier.ID); err != nil {
+		redirectWithError(w, r, supplierCardTemplatePath(supplier.ID), err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, supplierCardTemplatePath(supplier.ID), "기본 화물카드로 되돌렸습니다.")
+}
+
+// ShowSupplierCardTemplatePreview renders the cargo card page with sample
+// data and the unsaved form values, for the iframe on the template screen.
+func ShowSupplierCardTemplatePreview(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSuppliers, 0, "업체 관리"); !ok {
+		return
+	}
+	supplier, ok := supplierFromURL(w, r)
+	if !ok {
+		return
+	}
+	templateRepo := repo.CargoCardTemplate{}
+	current, err := templateRepo.GetBySupplier(r.Context(), supplier.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	item := cargoCardTemplateFromForm(r, supplier.ID)
+	if current != nil && r.FormValue("remove_logo") != "1" {
+		item.HasLogo = current.HasLogo
+		item.UpdatedAt = current.UpdatedAt
+	}
+	card := sampleCargoCard(supplier)
+	card.Layout = cargoCardLayoutFromTemplate(&item)
+
+	view.Render(w, r, "bl_markings_cargo_card.html", view.PageData{
+		Title: "화물카드 미리보기",
+		Data: map[string]interface{}{
+			"Items":     []cargoCardItem{card},
+			"PrintedAt": time.Now().Format("2006-01-02"),
+			"Preview":   true,
+		},
+	})
+}
+
+// GetSupplierCardTemplateLogo serves the logo printed on a supplier's cargo
+// cards, so it needs the same access as the cargo card page.
+func GetSupplierCardTemplateLogo(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceBLMarkings, 0, "BL 마킹 관리"); !ok {
+		return
+	}
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	templateRepo := repo.CargoCardTemplate{}
+	item, err := templateRepo.GetBySupplier(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if item == nil || !item.HasLogo {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", item.LogoContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	_, _ = w.Write(item.Logo)
+}
+
+func cargoCardTemplateFromForm(r *http.Request, supplierID int64) repo.CargoCardTemplate {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		_ = r.ParseMultipartForm(maxCargoCardLogoBytes * 2)
+	} else {
+		_ = r.ParseForm()
+	}
+	return repo.CargoCardTemplate{
+		SupplierID: supplierID,
+		Title:      strings.TrimSpace(r.FormValue("title")),
+		Fields: orderCargoCardFields(r.Form["fields"], func(key string) string {
+			return r.FormValue("order_" + key)
+		}),
+		ExtraFields: strings.TrimSpace(r.FormValue("extra_fields")),
+		ShowOverlay: r.FormValue("show_overlay") == "true",
+	}
+}
+
+func cargoCardFieldOptions(fields string) []cargoCardFieldOption {
+	selected := parseCargoCardFields(fields)
+	options := make([]cargoCardFieldOption, 0, len(cargoCardFields))
+	for idx, field := range cargoCardFields {
+		option := cargoCardFieldOption{Key: field.Key, Label: field.Label, Order: len(selected) + idx + 1}
+		for position, key := range selected {
+			if key == field.Key {
+				option.Checked = true
+				option.Order = position + 1
+				break
+			}
+		}
+		options = append(options, option)
+	}
+	return options
+}
+
+// sampleCargoCard fills every field so the preview shows the whole layout.
+func sampleCargoCard(supplier *repo.Supplier) cargoCardItem {
+	card := cargoCardItem{
+		BLNo:        "SAMPLE2401001",
+		ArrivalDate: time.Now().Format("2006-01-02"),
+		VesselName:  "SKY PIONEER 0012E",
+		CargoNo:     "24SKYC0012E-0001-001",
+		Volume:      "12.5",
+		Weight:      "3,240",
+		Quantity:    "48",
+		ProductName: "AUTO PARTS",
+		ContainerNo: "SKYU1234562",
+		Consignee:   supplier.Name,
+		Forwarder:   "SKY LOGISTICS",
+		Marks:       "SAMPLE MARKS\nC/NO. 1-48\nMADE IN KOREA",
+		MarksColor:  normalizeColor(supplier.Color),
+	}
+	card.Barcode, _ = scancode.Code128(card.BLNo)
+	card.QRCode, _ = scancode.QR(card.BLNo)
+	return card
+}
+
+func renderSupplierCardTemplate(w http.ResponseWriter, r *http.Request, supplier *repo.Supplier, item *repo.CargoCardTemplate, custom bool, message string) {
+	view.Render(w, r, "suppliers_card_template.html", view.PageData{
+		Title: supplier.Name + " 화물카드 템플릿",
+		Error: message,
+		Data: map[string]interface{}{
+			"Supplier":   supplier,
+			"Item":       item,
+			"Custom":     custom,
+			"Fields":     cargoCardFieldOptions(item.Fields),
+			"PreviewURL": cargoCardPreviewURL(item),
+		},
+	})
+}
+
+// cargoCardPreviewURL carries the form values to the preview the same way the
+// page script does after an edit.
+func cargoCardPreviewURL(item *repo.CargoCardTemplate) template.URL {
+	values := url.Values{}
+	values.Set("title", item.Title)
+	for idx, key := range parseCargoCardFields(item.Fields) {
+		values.Add("fields", key)
+		values.Set("order_"+key, strconv.Itoa(idx+1))
+	}
+	values.Set("extra_fields", item.ExtraFields)
+	if item.ShowOverlay {
+		values.Set("show_overlay", "true")
+	}
+	return template.URL(supplierCardTemplatePath(item.SupplierID) + "/preview?" + values.Encode())
+}
+
+func supplierFromURL(w http.ResponseWriter, r *http.Request) (*repo.Supplier, bool) {
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoSup := repo.Supplier{}
+	supplier, err := repoSup.GetByID(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return nil, false
+	}
+	return supplier, true
+}
+
+func supplierCardTemplatePath(supplierID int64) string {
+	return "/admin/suppliers/" + strconv.FormatInt(supplierID, 10) + "/card_template"
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net/http"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type blTransformOption struct {
+	Name    string
+	Label   string
+	Checked bool
+}
+
+func blTransformOptions(selected string) []blTransformOption {
+	names := splitBLTransforms(selected)
+	options := make([]blTransformOption, 0, len(blTransforms))
+	for _, transform := range blTransforms {
+		option := blTransformOption{Name: transform.Name, Label: transform.Label}
+		for _, name := range names {
+			if name == transform.Name {
+				option.Checked = true
+				break
+			}
+		}
+		options = append(options, option)
+	}
+	return options
+}
+
+func ListSupplierImportProfiles(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSuppliers, 0, "업체 관리"); !ok {
+		return
+	}
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoSup := repo.Supplier{}
+	supplier, err := repoSup.GetByID(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	profileRepo := repo.BLImportProfile{}
+	profiles, err := profileRepo.ListBySupplier(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "suppliers_import_profiles.html", view.PageData{
+		Title: supplier.Name + " 업로드 양식",
+		Data: map[string]interface{}{
+			"Supplier": supplier,
+			"Items":    profiles,
+		},
+	})
+}
+
+func ShowCreateSupplierImportProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "업로드 양식 등록"); !ok {
+		return
+	}
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	renderSupplierImportProfileForm(w, r, "업로드 양식 등록", "", repo.BLImportProfile{
+		SupplierID: supplierID,
+		HeaderRow:  1,
+		IsActive:   true,
+	})
+}
+
+func PostCreateSupplierImportProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "업로드 양식 등록"); !ok {
+		return
+	}
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoSup := repo.Supplier{}
+	if _, err := repoSup.GetByID(r.Context(), supplierID); err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+
+	item, message := supplierImportProfileFromForm(r)
+	item.SupplierID = supplierID
+	if message != "" {
+		renderSupplierImportProfileForm(w, r, "업로드 양식 등록", message, item)
+		return
+	}
+	if err := item.Create(r.Context()); err != nil {
+		renderSupplierImportProfileForm(w, r, "업로드 양식 등록", "등록 중 오류가 발생했습니다: "+err.Error(), item)
+		return
+	}
+	redirectWithSuccess(w, r, supplierImportProfilesPath(supplierID), "등록이 완료되었습니다.")
+}
+
+func ShowEditSupplierImportProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "업로드 양식 수정"); !ok {
+		return
+	}
+	item, ok := supplierImportProfileFromURL(w, r)
+	if !ok {
+		return
+	}
+	renderSupplierImportProfileForm(w, r, "업로드 양식 수정", "", *item)
+}
+
+func PostUpdateSupplierImportProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "업로드 양식 수정"); !ok {
+		return
+	}
+	existing, ok := supplierImportProfileFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	item, message := supplierImportProfileFromForm(r)
+	item.ID = existing.ID
+	item.SupplierID = existing.SupplierID
+	if message != "" {
+		renderSupplierImportProfileForm(w, r, "업로드 양식 수정", message, item)
+		return
+	}
+	if err := item.Update(r.Context()); err != nil {
+		renderSupplierImportProfileForm(w, r, "업로드 양식 수정", "수정 중 오류가 발생했습니다: "+err.Error(), item)
+		return
+	}
+	redirectWithSuccess(w, r, supplierImportProfilesPath(item.SupplierID), "수정이 완료되었습니다.")
+}
+
+func DeleteSupplierImportProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionDelete, policy.ResourceSuppliers, 0, "업로드 양식 삭제"); !ok {
+		return
+	}
+	item, ok := supplierImportProfileFromURL(w, r)
+	if !ok {
+		return
+	}
+	profileRepo := repo.BLImportProfile{}
+	if err := profileRepo.Delete(r.Context(), item.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// supplierImportProfileFromURL loads the profile named in the URL and checks
+// that it belongs to the supplier in the same URL.
+func supplierImportProfileFromURL(w http.ResponseWriter, r *http.Request) (*repo.BLImportProfile, bool) {
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	profileID, _ := strconv.ParseInt(chi.URLParam(r, "profileID"), 10, 64)
+	profileRepo := repo.BLImportProfile{}
+	item, err := profileRepo.GetByID(r.Context(), profileID)
+	if err != nil || item.SupplierID != supplierID {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return nil, false
+	}
+	return item, true
+}
+
+// supplierImportProfileFromForm reads the profile form. The returned message
+// is empty when the input is valid.
+func supplierImportProfileFromForm(r *http.Request) (repo.BLImportProfile, string) {
+	_ = r.ParseForm()
+	item := repo.BLImportProfile{
+		Name:            strings.TrimSpace(r.FormValue("name")),
+		SheetName:       strings.TrimSpace(r.FormValue("sheet_name")),
+		HBLColumn:       strings.TrimSpace(r.FormValue("hbl_column")),
+		CneeColumn:      strings.TrimSpace(r.FormValue("cnee_column")),
+		MarksColumn:     strings.TrimSpace(r.FormValue("marks_column")),
+		ContainerColumn: strings.TrimSpace(r.FormValue("container_column")),
+		HBLTransforms:   strings.Join(r.Form["hbl_transforms"], ","),
+		CneeTransforms:  strings.Join(r.Form["cnee_transforms"], ","),
+		MarksTransforms: strings.Join(r.Form["marks_transforms"], ","),
+		IsActive:        r.FormValue("is_active") == "true",
+	}
+	headerRow, err := strconv.Atoi(strings.TrimSpace(r.FormValue("header_row")))
+	if err != nil || headerRow < 0 {
+		return item, "헤더 행은 0 이상의 숫자로 입력해 주세요."
+	}
+	item.HeaderRow = headerRow
+
+	switch {
+	case item.Name == "":
+		return item, "양식 이름을 입력해 주세요."
+	case item.HBLColumn == "":
+		return item, "HBL 열을 입력해 주세요."
+	case item.MarksColumn == "":
+		return item, "Marks 열을 입력해 주세요."
+	}
+	if item.HeaderRow == 0 {
+		for _, spec := range []string{item.HBLColumn, item.CneeColumn, item.MarksColumn, item.ContainerColumn} {
+			if spec != "" && letterColumnIndex(spec) < 0 {
+				return item, "헤더 행이 없으면 열은 A, B, C 같은 열 문자로 입력해야 합니다."
+			}
+		}
+	}
+	for _, value := range []string{item.HBLTransforms, item.CneeTransforms, item.MarksTransforms} {
+		if err := validateBLTransforms(value); err != nil {
+			return item, err.Error()
+		}
+	}
+	return item, ""
+}
+
+func renderSupplierImportProfileForm(w http.ResponseWriter, r *http.Request, title string, message string, item repo.BLImportProfile) {
+	view.Render(w, r, "suppliers_import_profile_form.html", view.PageData{
+		Title: title,
+		Error: message,
+		Data: map[string]interface{}{
+			"Item":            item,
+			"HBLTransforms":   blTransformOptions(item.HBLTransforms),
+			"CneeTransforms":  blTransformOptions(item.CneeTransforms),
+			"MarksTransforms": blTransformOptions(item.MarksTransforms),
+		},
+	})
+}
+
+func supplierImportProfilesPath(supplierID int64) string {
+	return "/admin/suppliers/" + strconv.FormatInt(supplierID, 10) + "/import_profiles"
+}
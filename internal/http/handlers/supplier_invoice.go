@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"skycontainers/internal/pagination"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/tariff"
+	"skycontainers/internal/view"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xuri/excelize/v2"
+)
+
+const supplierInvoicesPath = "/admin/invoices"
+
+// parseInvoiceMonth reads a "2006-01" month into its first and last day.
+func parseInvoiceMonth(value string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", strings.TrimSpace(value), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, start.AddDate(0, 1, -1), nil
+}
+
+// previousInvoiceMonth is the month invoices are usually generated for.
+func previousInvoiceMonth() string {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0).Format("2006-01")
+}
+
+// buildSupplierInvoices turns a month of activity into draft invoices, one
+// per supplier and currency: a handling line per devanned container by its
+// length, a BL line for its BL count, and a storage line per container with
+// chargeable days. Missing handling rates still produce a zero line so the
+// draft shows what needs a rate.
+func buildSupplierInvoices(ctx context.Context, start, end time.Time, supplierID int64) ([]repo.SupplierInvoice, error) {
+	rateRepo := repo.InvoiceRate{}
+	rates, err := rateRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	invoiceRepo := repo.SupplierInvoice{}
+	activity, err := invoiceRepo.ListInvoiceActivity(ctx, start, end, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	storageRows, _, err := calculateStorageCharges(ctx, storageChargeFilters{Start: start, End: end, SupplierID: supplierID})
+	if err != nil {
+		return nil, err
+	}
+
+	var invoices []repo.SupplierInvoice
+	index := make(map[string]int)
+	invoiceFor := func(supplierID int64, supplierName, currency string) *repo.SupplierInvoice {
+		key := strconv.FormatInt(supplierID, 10) + "/" + currency
+		i, ok := index[key]
+		if !ok {
+			i = len(invoices)
+			index[key] = i
+			invoices = append(invoices, repo.SupplierInvoice{
+				SupplierID:   supplierID,
+				SupplierName: supplierName,
+				PeriodStart:  start,
+				PeriodEnd:    end,
+				Currency:     currency,
+			})
+		}
+		return &invoices[i]
+	}
+
+	for _, item := range activity {
+		containerID := item.ContainerID
+		currency := tariff.DefaultCurrency
+		var handlingFee, blFee int64
+		if rate := repo.MatchInvoiceRate(rates, item.SupplierID); rate != nil {
+			currency = rate.Currency
+			handlingFee, _ = rate.HandlingFee(item.LengthFT)
+			blFee = rate.BLFee
+		}
+		invoice := invoiceFor(item.SupplierID, item.SupplierName, currency)
+		invoice.Lines = append(invoice.Lines, repo.SupplierInvoiceLine{
+			Kind:        repo.InvoiceLineHandling,
+			ContainerID: &containerID,
+			ContainerNo: item.ContainerNo,
+			Description: fmt.Sprintf("%s %dft 작업 (%s)", item.ContainerTypeCode, item.LengthFT, item.ProcessingDate.Format("2006-01-02")),
+			Quantity:    1,
+			UnitPrice:   handlingFee,
+			Amount:      handlingFee,
+		})
+		if item.BLCount > 0 {
+			invoice.Lines = append(invoice.Lines, repo.SupplierInvoiceLine{
+				Kind:        repo.InvoiceLineBL,
+				ContainerID: &containerID,
+				ContainerNo: item.ContainerNo,
+				Description: fmt.Sprintf("BL %d건", item.BLCount),
+				Quantity:    item.BLCount,
+				UnitPrice:   blFee,
+				Amount:      blFee * int64(item.BLCount),
+			})
+		}
+	}
+
+	for _, row := range storageRows {
+		if row.Charge.ChargeableDays == 0 {
+			continue
+		}
+		containerID := row.Container.ID
+		// Tiered rates have no single unit price; the line then shows only
+		// the amount.
+		var unitPrice int64
+		if row.Charge.Amount%int64(row.Charge.ChargeableDays) == 0 {
+			unitPrice = row.Charge.Amount / int64(row.Charge.ChargeableDays)
+		}
+		invoice := invoiceFor(row.Container.SupplierID, row.Container.SupplierName, row.Currency)
+		invoice.Lines = append(invoice.Lines, repo.SupplierInvoiceLine{
+			Kind:        repo.InvoiceLineStorage,
+			ContainerID: &containerID,
+			ContainerNo: row.Container.ContainerNo,
+			Description: fmt.Sprintf("보관 %d일 (%d일차까지)", row.Charge.ChargeableDays, row.Charge.StorageDay),
+			Quantity:    row.Charge.ChargeableDays,
+			UnitPrice:   unitPrice,
+			Amount:      row.Charge.Amount,
+		})
+	}
+	return invoices, nil
+}
+
+func ListSupplierInvoices(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceInvoices, 0, "청구서"); !ok {
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filter := repo.SupplierInvoiceFilter{
+		Status: repo.InvoiceStatus(strings.TrimSpace(r.URL.Query().Get("status"))),
+	}
+	filter.SupplierID, _ = strconv.ParseInt(r.URL.Query().Get("supplier_id"), 10, 64)
+	month := strings.TrimSpace(r.URL.Query().Get("month"))
+	var errMsg string
+	if month != "" {
+		start, _, err := parseInvoiceMonth(month)
+		if err != nil {
+			errMsg = "청구월 형식이 올바르지 않습니다."
+		} else {
+			filter.PeriodStart = &start
+		}
+	}
+
+	pager := pagination.NewPager(0, page, 20)
+	repoItem := repo.SupplierInvoice{}
+	list, total, err := repoItem.List(r.Context(), pager, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pager = pagination.NewPager(total, page, 20)
+
+	supplierRepo := repo.Supplier{}
+	suppliers, err := supplierRepo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	view.Render(w, r, "invoices_list.html", view.PageData{
+		Title: "청구서",
+		Error: errMsg,
+		Data: map[string]interface{}{
+			"Items":         list,
+			"Pager":         pager,
+			"Suppliers":     suppliers,
+			"Month":         month,
+			"SupplierID":    filter.SupplierID,
+			"Status":        string(filter.Status),
+			"GenerateMonth": previousInvoiceMonth(),
+			"Statuses":      []repo.InvoiceStatus{repo.InvoiceDraft, repo.InvoiceIssued, repo.InvoicePaid},
+		},
+	})
+}
+
+// PostGenerateSupplierInvoices creates or refreshes the drafts of a month.
+// Issued and paid invoices are never touched.
+func PostGenerateSupplierInvoices(w http.ResponseWriter, r *http.Request) {
+	user, ok := requirePermission(w, r, policy.ActionCreate, policy.ResourceInvoices, 0, "청구서 생성")
+	if !ok {
+		return
+	}
+	month := r.FormValue("month")
+	start, end, err := parseInvoiceMonth(month)
+	if err != nil {
+		redirectWithError(w, r, supplierInvoicesPath, "청구월을 선택해 주세요.")
+		return
+	}
+	supplierID, _ := strconv.ParseInt(r.FormValue("supplier_id"), 10, 64)
+	backPath := supplierInvoicesPath + "?month=" + start.Format("2006-01")
+
+	invoices, err := buildSupplierInvoices(r.Context(), start, end, supplierID)
+	if err != nil {
+		redirectWithError(w, r, backPath, "청구 내역 계산 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	if len(invoices) == 0 {
+		redirectWithError(w, r, backPath, start.Format("2006-01")+" 청구할 작업/보관 내역이 없습니다.")
+		return
+	}
+
+	created, updated := 0, 0
+	var locked []string
+	for i := range invoices {
+		isNew, err := invoices[i].SaveDraft(r.Context(), user.ID)
+		switch {
+		case errors.Is(err, repo.ErrInvoiceLocked):
+			locked = append(locked, invoices[i].SupplierName)
+		case err != nil:
+			redirectWithError(w, r, backPath, invoices[i].SupplierName+" 청구서 저장 중 오류가 발생했습니다: "+err.Error())
+			return
+		case isNew:
+			created++
+		default:
+			updated++
+		}
+	}
+	msg := fmt.Sprintf("%s 청구서 작성 %d건, 갱신 %d건", start.Format("2006-01"), created, updated)
+	if len(locked) > 0 {
+		msg += fmt.Sprintf(" (이미 발행되어 건너뜀: %s)", strings.Join(locked, ", "))
+	}
+	redirectWithSuccess(w, r, backPath, msg+".")
+}
+
+func ShowSupplierInvoice(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceInvoices, 0, "청구서"); !ok {
+		return
+	}
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.SupplierInvoice{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 청구서입니다.", http.StatusNotFound)
+		return
+	}
+	var moves []repo.InvoiceStatus
+	for _, next := range []repo.InvoiceStatus{repo.InvoiceIssued, repo.InvoicePaid, repo.InvoiceDraft} {
+		if item.Status.CanMoveTo(next) {
+			moves = append(moves, next)
+		}
+	}
+	view.Render(w, r, "invoices_view.html", view.PageData{
+		Title: "청구서 " + item.InvoiceNo,
+		Data: map[string]interface{}{
+			"Item":   item,
+			"Totals": supplierInvoiceKindTotals(item),
+			"Moves":  moves,
+		},
+	})
+}
+
+// supplierInvoiceKindTotal is the subtotal of one line kind.
+type supplierInvoiceKindTotal struct {
+	Kind   repo.InvoiceLineKind
+	Count  int
+	Amount int64
+}
+
+func supplierInvoiceKindTotals(item *repo.SupplierInvoice) []supplierInvoiceKindTotal {
+	totals := []supplierInvoiceKindTotal{
+		{Kind: repo.InvoiceLineHandling},
+		{Kind: repo.InvoiceLineBL},
+		{Kind: repo.InvoiceLineStorage},
+	}
+	for _, line := range item.Lines {
+		for i := range totals {
+			if totals[i].Kind == line.Kind {
+				totals[i].Count += line.Quantity
+				totals[i].Amount += line.Amount
+			}
+		}
+	}
+	return totals
+}
+
+func PostSupplierInvoiceStatus(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	backPath := supplierInvoicesPath + "/" + strconv.FormatInt(id, 10)
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceInvoices, 0, "청구서 상태 변경"); !ok {
+		return
+	}
+	next := repo.InvoiceStatus(r.FormValue("status"))
+	repoItem := repo.SupplierInvoice{}
+	if err := repoItem.SetStatus(r.Context(), id, next); err != nil {
+		if errors.Is(err, repo.ErrInvoiceStatusMove) {
+			redirectWithError(w, r, backPath, err.Error())
+			return
+		}
+		redirectWithError(w, r, backPath, "상태 변경 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, backPath, "청구서를 "+next.Label()+" 상태로 변경했습니다.")
+}
+
+func DeleteSupplierInvoice(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, ok := requirePermission(w, r, policy.ActionDelete, policy.ResourceInvoices, 0, "청구서 삭제"); !ok {
+		return
+	}
+	repoItem := repo.SupplierInvoice{}
+	if err := repoItem.Delete(r.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repo.ErrInvoiceLocked) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func ExportSupplierInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceInvoices, 0, "청구서"); !ok {
+		return
+	}
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.SupplierInvoice{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 청구서입니다.", http.StatusNotFound)
+		return
+	}
+	writeSupplierInvoicePDF(w, r, item, supplierInvoicesPath+"/"+strconv.FormatInt(id, 10))
+}
+
+func ExportSupplierInvoice(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceInvoices, 0, "청구서"); !ok {
+		return
+	}
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.SupplierInvoice{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 청구서입니다.", http.StatusNotFound)
+		return
+	}
+	writeSupplierInvoiceXLSX(w, item)
+}
+
+func writeSupplierInvoicePDF(w http.ResponseWriter, r *http.Request, item *repo.SupplierInvoice, backPath string) {
+	pdf, err := renderSupplierInvoicePDF(item)
+	if err != nil {
+		redirectWithError(w, r, backPath, "PDF 생성 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+item.InvoiceNo+".pdf\"")
+	_, _ = w.Write(pdf)
+}
+
+func writeSupplierInvoiceXLSX(w http.ResponseWriter, item *repo.SupplierInvoice) {
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	summary := [][]interface{}{
+		{"청구서 번호", item.InvoiceNo},
+		{"업체", item.SupplierName},
+		{"청구 기간", item.PeriodStart.Format("2006-01-02") + " ~ " + item.PeriodEnd.Format("2006-01-02")},
+		{"상태", item.Status.Label()},
+		{"통화", item.Currency},
+		{"합계", tariff.AmountValue(item.Total, item.Currency)},
+	}
+	for i, values := range summary {
+		row := strconv.Itoa(i + 1)
+		_ = file.SetCellValue(sheet, "A"+row, values[0])
+		_ = file.SetCellValue(sheet, "B"+row, values[1])
+	}
+
+	headerRow := len(summary) + 2
+	headers := []string{"구분", "컨테이너 번호", "내용", "수량", "단가", "금액"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, headerRow)
+		_ = file.SetCellValue(sheet, cell, header)
+	}
+	for i, line := range item.Lines {
+		row := strconv.Itoa(headerRow + i + 1)
+		_ = file.SetCellValue(sheet, "A"+row, line.Kind.Label())
+		_ = file.SetCellValue(sheet, "B"+row, line.ContainerNo)
+		_ = file.SetCellValue(sheet, "C"+row, line.Description)
+		_ = file.SetCellValue(sheet, "D"+row, line.Quantity)
+		if line.UnitPrice > 0 {
+			_ = file.SetCellValue(sheet, "E"+row, tariff.AmountValue(line.UnitPrice, item.Currency))
+		}
+		_ = file.SetCellValue(sheet, "F"+row, tariff.AmountValue(line.Amount, item.Currency))
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+item.InvoiceNo+".xlsx\"")
+	if err := file.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
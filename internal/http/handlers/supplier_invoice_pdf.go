@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"strconv"
+
+	"skycontainers/internal/pdfdoc"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/tariff"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// supplierInvoicePDFColumns are the line table columns in millimetres; they add
+// up to the A4 width inside 15mm margins.
+var supplierInvoicePDFColumns = []struct {
+	Title string
+	Width float64
+	Align string
+}{
+	{"구분", 22, "C"},
+	{"컨테이너 번호", 34, "C"},
+	{"내용", 62, "L"},
+	{"수량", 14, "R"},
+	{"단가", 24, "R"},
+	{"금액", 24, "R"},
+}
+
+const supplierInvoiceMargin = 15.0
+
+// renderSupplierInvoicePDF prints the invoice on A4: a title block with the
+// supplier and period, the line table repeated across pages, then the
+// subtotals per kind and the total.
+func renderSupplierInvoicePDF(item *repo.SupplierInvoice) ([]byte, error) {
+	pdf, err := pdfdoc.New("P", "A4")
+	if err != nil {
+		return nil, err
+	}
+	pdf.SetTitle("청구서 "+item.InvoiceNo, true)
+	pdf.SetMargins(supplierInvoiceMargin, supplierInvoiceMargin, supplierInvoiceMargin)
+	pdf.AddPage()
+
+	pdf.SetFont(pdfdoc.FontFamily, "B", 20)
+	pdf.CellFormat(0, 12, "청 구 서", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont(pdfdoc.FontFamily, "", 10)
+	info := [][2]string{
+		{"청구서 번호", item.InvoiceNo},
+		{"업체", item.SupplierName},
+		{"청구 기간", item.PeriodStart.Format("2006-01-02") + " ~ " + item.PeriodEnd.Format("2006-01-02")},
+		{"상태", item.Status.Label()},
+	}
+	if item.IssuedAt != nil {
+		info = append(info, [2]string{"발행일", item.IssuedAt.Format("2006-01-02")})
+	}
+	for _, row := range info {
+		pdf.SetFont(pdfdoc.FontFamily, "B", 10)
+		pdf.CellFormat(30, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont(pdfdoc.FontFamily, "", 10)
+		pdf.CellFormat(0, 7, row[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	_, pageH := pdf.GetPageSize()
+	const rowH = 7.0
+	drawSupplierInvoiceHeader(pdf, rowH)
+	pdf.SetFont(pdfdoc.FontFamily, "", 9)
+	for _, line := range item.Lines {
+		if pdf.GetY()+rowH > pageH-supplierInvoiceMargin {
+			pdf.AddPage()
+			drawSupplierInvoiceHeader(pdf, rowH)
+			pdf.SetFont(pdfdoc.FontFamily, "", 9)
+		}
+		unitPrice := ""
+		if line.UnitPrice > 0 {
+			unitPrice = tariff.FormatAmount(line.UnitPrice, item.Currency)
+		}
+		values := []string{
+			line.Kind.Label(),
+			line.ContainerNo,
+			line.Description,
+			strconv.Itoa(line.Quantity),
+			unitPrice,
+			tariff.FormatAmount(line.Amount, item.Currency),
+		}
+		for i, col := range supplierInvoicePDFColumns {
+			pdf.CellFormat(col.Width, rowH, fitSupplierInvoiceText(pdf, values[i], col.Width-2), "1", 0, col.Align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	totals := supplierInvoiceKindTotals(item)
+	if pdf.GetY()+rowH*float64(len(totals)+2) > pageH-supplierInvoiceMargin {
+		pdf.AddPage()
+	}
+	pdf.Ln(4)
+	labelW := 0.0
+	for _, col := range supplierInvoicePDFColumns[:len(supplierInvoicePDFColumns)-1] {
+		labelW += col.Width
+	}
+	amountW := supplierInvoicePDFColumns[len(supplierInvoicePDFColumns)-1].Width
+	pdf.SetFont(pdfdoc.FontFamily, "", 10)
+	for _, total := range totals {
+		if total.Amount == 0 && total.Count == 0 {
+			continue
+		}
+		pdf.CellFormat(labelW, rowH, total.Kind.Label()+" 소계", "", 0, "R", false, 0, "")
+		pdf.CellFormat(amountW, rowH, tariff.FormatAmount(total.Amount, item.Currency), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont(pdfdoc.FontFamily, "B", 11)
+	pdf.CellFormat(labelW, rowH+1, "합계 ("+item.Currency+")", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(amountW, rowH+1, tariff.FormatAmount(item.Total, item.Currency), "T", 1, "R", false, 0, "")
+
+	if item.Memo != "" {
+		pdf.Ln(6)
+		pdf.SetFont(pdfdoc.FontFamily, "", 9)
+		pdf.MultiCell(0, 5, item.Memo, "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawSupplierInvoiceHeader(pdf *gofpdf.Fpdf, rowH float64) {
+	pdf.SetFont(pdfdoc.FontFamily, "B", 9)
+	pdf.SetFillColor(240, 240, 240)
+	for _, col := range supplierInvoicePDFColumns {
+		pdf.CellFormat(col.Width, rowH, col.Title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// fitSupplierInvoiceText shortens text that would overflow its cell.
+func fitSupplierInvoiceText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
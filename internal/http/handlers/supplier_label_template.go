@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"skycontainers/internal/policy"
+	"skycontainers/internal/repo"
+	"skycontainers/internal/view"
+	"skycontainers/internal/zpl"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func ShowSupplierLabelTemplate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSuppliers, 0, "업체 관리"); !ok {
+		return
+	}
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoSup := repo.Supplier{}
+	supplier, err := repoSup.GetByID(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+	current, err := repoSup.GetLabelTemplate(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	renderSupplierLabelTemplate(w, r, supplier, current, "")
+}
+
+func PostUpdateSupplierLabelTemplate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionUpdate, policy.ResourceSuppliers, 0, "라벨 템플릿 수정"); !ok {
+		return
+	}
+	supplierID, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoSup := repo.Supplier{}
+	supplier, err := repoSup.GetByID(r.Context(), supplierID)
+	if err != nil {
+		http.Error(w, "찾을 수 없는 항목입니다.", http.StatusNotFound)
+		return
+	}
+
+	text := strings.TrimSpace(strings.ReplaceAll(r.FormValue("zpl_template"), "\r\n", "\n"))
+	if r.FormValue("reset") == "1" {
+		text = ""
+	}
+	if text != "" {
+		if err := zpl.Validate(text); err != nil {
+			renderSupplierLabelTemplate(w, r, supplier, text, err.Error())
+			return
+		}
+	}
+	if err := repoSup.UpdateLabelTemplate(r.Context(), supplierID, text); err != nil {
+		renderSupplierLabelTemplate(w, r, supplier, text, "저장 중 오류가 발생했습니다: "+err.Error())
+		return
+	}
+	redirectWithSuccess(w, r, "/admin/suppliers/"+strconv.FormatInt(supplierID, 10)+"/label_template", "라벨 템플릿을 저장했습니다.")
+}
+
+func renderSupplierLabelTemplate(w http.ResponseWriter, r *http.Request, supplier *repo.Supplier, current string, message string) {
+	view.Render(w, r, "suppliers_label_template.html", view.PageData{
+		Title: supplier.Name + " 라벨 템플릿",
+		Error: message,
+		Data: map[string]interface{}{
+			"Supplier":        supplier,
+			"Template":        current,
+			"DefaultTemplate": zpl.DefaultTemplate,
+		},
+	})
+}
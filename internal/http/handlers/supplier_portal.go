@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
+	"skycontainers/internal/pagination"
 	"skycontainers/internal/policy"
 	"skycontainers/internal/repo"
 	"skycontainers/internal/view"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func ShowSupplierPortal(w http.ResponseWriter, r *http.Request) {
@@ -32,3 +36,94 @@ func ShowSupplierPortal(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// supplierPortalSupplierID is the supplier the logged-in user belongs to, or
+// 0 for accounts not linked to one.
+func supplierPortalSupplierID(r *http.Request) (int64, error) {
+	userID, ok := currentUserID(r.Context())
+	if !ok {
+		return 0, nil
+	}
+	userRepo := repo.User{}
+	item, err := userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		return 0, err
+	}
+	if item.SupplierID == nil {
+		return 0, nil
+	}
+	return *item.SupplierID, nil
+}
+
+func ListSupplierPortalInvoices(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSupplierPortal, 0, "청구서 조회"); !ok {
+		return
+	}
+	supplierID, err := supplierPortalSupplierID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	var list []repo.SupplierInvoice
+	pager := pagination.NewPager(0, page, 20)
+	if supplierID > 0 {
+		repoItem := repo.SupplierInvoice{}
+		var total int
+		list, total, err = repoItem.ListForSupplier(r.Context(), pager, supplierID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pager = pagination.NewPager(total, page, 20)
+	}
+	view.Render(w, r, "supplier_portal_invoices.html", view.PageData{
+		Title: "청구서 조회",
+		Data: map[string]interface{}{
+			"Items":       list,
+			"Pager":       pager,
+			"HasSupplier": supplierID > 0,
+		},
+	})
+}
+
+// supplierPortalInvoice loads an invoice the user's supplier may see. Drafts
+// and other suppliers' invoices are reported as missing.
+func supplierPortalInvoice(w http.ResponseWriter, r *http.Request) (*repo.SupplierInvoice, bool) {
+	if _, ok := requirePermission(w, r, policy.ActionRead, policy.ResourceSupplierPortal, 0, "청구서 조회"); !ok {
+		return nil, false
+	}
+	supplierID, err := supplierPortalSupplierID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	repoItem := repo.SupplierInvoice{}
+	item, err := repoItem.GetByID(r.Context(), id)
+	if err != nil || supplierID == 0 || item.SupplierID != supplierID || item.Status == repo.InvoiceDraft {
+		http.Error(w, "찾을 수 없는 청구서입니다.", http.StatusNotFound)
+		return nil, false
+	}
+	return item, true
+}
+
+func ExportSupplierPortalInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	item, ok := supplierPortalInvoice(w, r)
+	if !ok {
+		return
+	}
+	writeSupplierInvoicePDF(w, r, item, "/supplier/invoices")
+}
+
+func ExportSupplierPortalInvoice(w http.ResponseWriter, r *http.Request) {
+	item, ok := supplierPortalInvoice(w, r)
+	if !ok {
+		return
+	}
+	writeSupplierInvoiceXLSX(w, item)
+}
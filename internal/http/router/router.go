@@ -49,10 +49,15 @@ func NewRouter() *chi.Mux {
 			r.Get("/dashboard", handlers.ShowDashboard)
 			r.Route("/io_management", func(r chi.Router) {
 				r.Get("/", handlers.ShowIOManagement)
+				r.Post("/bulk", handlers.PostIOBulk)
 				r.Get("/{id}/inbound_modal", handlers.ShowInboundModal)
 				r.Post("/{id}/inbound", handlers.PostIOInbound)
+				r.Post("/{id}/start_devanning", handlers.PostIOStartDevanning)
 				r.Post("/{id}/processing", handlers.PostIOProcessing)
+				r.Get("/{id}/outbound_modal", handlers.ShowOutboundModal)
 				r.Post("/{id}/outbound", handlers.PostIOOutbound)
+				r.Get("/{id}/undo_modal", handlers.ShowIOUndoModal)
+				r.Post("/{id}/undo", handlers.PostIOUndo)
 			})
 			r.Route("/container_types", func(r chi.Router) {
 				r.Get("/", handlers.ListContainerTypes)
@@ -70,11 +75,26 @@ func NewRouter() *chi.Mux {
 				r.Get("/{id}/edit", handlers.ShowEditSupplier)
 				r.Post("/{id}/edit", handlers.PostUpdateSupplier)
 				r.Delete("/{id}", handlers.DeleteSupplier)
+				r.Get("/{id}/import_profiles", handlers.ListSupplierImportProfiles)
+				r.Get("/{id}/import_profiles/new", handlers.ShowCreateSupplierImportProfile)
+				r.Post("/{id}/import_profiles", handlers.PostCreateSupplierImportProfile)
+				r.Get("/{id}/import_profiles/{profileID}/edit", handlers.ShowEditSupplierImportProfile)
+				r.Post("/{id}/import_profiles/{profileID}/edit", handlers.PostUpdateSupplierImportProfile)
+				r.Delete("/{id}/import_profiles/{profileID}", handlers.DeleteSupplierImportProfile)
+				r.Get("/{id}/label_template", handlers.ShowSupplierLabelTemplate)
+				r.Post("/{id}/label_template", handlers.PostUpdateSupplierLabelTemplate)
+				r.Get("/{id}/card_template", handlers.ShowSupplierCardTemplate)
+				r.Post("/{id}/card_template", handlers.PostUpdateSupplierCardTemplate)
+				r.Post("/{id}/card_template/reset", handlers.PostResetSupplierCardTemplate)
+				r.Get("/{id}/card_template/preview", handlers.ShowSupplierCardTemplatePreview)
+				r.Get("/{id}/card_template/logo", handlers.GetSupplierCardTemplateLogo)
 			})
 
 			r.Route("/containers", func(r chi.Router) {
 				r.Get("/", handlers.ListContainers)
 				r.Get("/export", handlers.ExportContainers)
+				r.Get("/number_issues", handlers.ShowContainerNumberIssues)
+				r.Post("/number_issues/normalize", handlers.PostNormalizeContainerNumbers)
 				r.Get("/new", handlers.ShowCreateContainer)
 				r.Post("/", handlers.PostCreateContainer)
 				r.Get("/{id}/edit", handlers.ShowEditContainer)
@@ -95,6 +115,10 @@ func NewRouter() *chi.Mux {
 				r.Get("/", handlers.ShowPolicySettings)
 				r.Post("/", handlers.PostUpdatePolicySettings)
 			})
+			r.Route("/settings", func(r chi.Router) {
+				r.Get("/", handlers.ShowSettings)
+				r.Post("/", handlers.PostUpdateSettings)
+			})
 
 			r.Route("/reports", func(r chi.Router) {
 				r.Get("/", handlers.ListReports)
@@ -110,13 +134,30 @@ func NewRouter() *chi.Mux {
 				r.Get("/", handlers.ListBLMarkings)
 				r.Get("/export", handlers.ExportBLMarkings)
 				r.Get("/cargo_card", handlers.ShowBLCargoCards)
+				r.Get("/cargo_card/pdf", handlers.ExportBLCargoCardsPDF)
+				r.Get("/labels", handlers.ShowBLLabels)
+				r.Get("/labels/pdf", handlers.ExportBLLabelsPDF)
+				r.Get("/labels/zpl", handlers.ExportBLLabelsZPL)
+				r.Post("/labels/print", handlers.PostPrintBLLabels)
 				r.Post("/apply_unipass", handlers.PostApplyUnipassFiltered)
+				r.Get("/unipass_batches/{id}", handlers.ShowUnipassBatchProgress)
+				r.Get("/unipass_lookup", handlers.ShowUnipassLookup)
+				r.Post("/unipass_lookup", handlers.PostUnipassLookupCreate)
 				r.Post("/delete_filtered", handlers.PostDeleteBLMarkingsFiltered)
 				r.Get("/new", handlers.ShowCreateBLMarking)
 				r.Post("/", handlers.PostCreateBLMarking)
 				r.Post("/upload", handlers.PostUploadBLMarkings)
+				r.Get("/imports", handlers.ListBLMarkingImports)
+				r.Get("/imports/{id}", handlers.ShowBLMarkingImport)
+				r.Post("/imports/{id}/commit", handlers.PostCommitBLMarkingImport)
+				r.Post("/imports/{id}/cancel", handlers.PostCancelBLMarkingImport)
+				r.Get("/imports/{id}/rejected", handlers.ExportBLMarkingImportRejected)
+				r.Post("/imports/{id}/rollback", handlers.PostRollbackBLMarkingImport)
 				r.Get("/validate-container", handlers.ValidateBLMarkingContainer)
 				r.Get("/{id}/edit", handlers.ShowEditBLMarking)
+				r.Get("/{id}/unipass_events", handlers.ShowBLMarkingUnipassEvents)
+				r.Get("/{id}/position_moves", handlers.ShowBLMarkingPositionMoves)
+				r.Post("/{id}/unipass_candidate", handlers.PostSelectUnipassCandidate)
 				r.Post("/{id}/edit", handlers.PostUpdateBLMarking)
 				r.Post("/{id}/status", handlers.PostUpdateBLMarkingStatus)
 				r.Delete("/{id}", handlers.DeleteBLMarking)
@@ -132,18 +173,51 @@ func NewRouter() *chi.Mux {
 				r.Delete("/{id}", handlers.DeleteBLPosition)
 			})
 
-			r.Route("/carnumbers", func(r chi.Router) {
-				r.Get("/", handlers.ListCarNumbers)
-				r.Get("/new", handlers.ShowCreateCarNumber)
-				r.Post("/", handlers.PostCreateCarNumber)
-				r.Get("/{id}/edit", handlers.ShowEditCarNumber)
-				r.Post("/{id}/edit", handlers.PostUpdateCarNumber)
-				r.Delete("/{id}", handlers.DeleteCarNumber)
+			r.Get("/carnumbers", handlers.RedirectCarNumbers)
+			r.Route("/gate_transactions", func(r chi.Router) {
+				r.Get("/", handlers.ShowGateReport)
+				r.Get("/export", handlers.ExportGateReport)
+				r.Get("/legacy_export", handlers.ExportLegacyCarNumbers)
+				r.Get("/new", handlers.ShowCreateGateTransaction)
+				r.Post("/", handlers.PostCreateGateTransaction)
+				r.Delete("/{id}", handlers.DeleteGateTransaction)
+			})
+			r.Route("/storage_tariffs", func(r chi.Router) {
+				r.Get("/", handlers.ListStorageTariffs)
+				r.Get("/new", handlers.ShowCreateStorageTariff)
+				r.Post("/", handlers.PostCreateStorageTariff)
+				r.Get("/{id}/edit", handlers.ShowEditStorageTariff)
+				r.Post("/{id}/edit", handlers.PostUpdateStorageTariff)
+				r.Delete("/{id}", handlers.DeleteStorageTariff)
+			})
+			r.Route("/storage_charges", func(r chi.Router) {
+				r.Get("/", handlers.ShowStorageCharges)
+				r.Get("/export", handlers.ExportStorageCharges)
+			})
+			r.Route("/invoice_rates", func(r chi.Router) {
+				r.Get("/", handlers.ListInvoiceRates)
+				r.Get("/new", handlers.ShowCreateInvoiceRate)
+				r.Post("/", handlers.PostCreateInvoiceRate)
+				r.Get("/{id}/edit", handlers.ShowEditInvoiceRate)
+				r.Post("/{id}/edit", handlers.PostUpdateInvoiceRate)
+				r.Delete("/{id}", handlers.DeleteInvoiceRate)
+			})
+			r.Route("/invoices", func(r chi.Router) {
+				r.Get("/", handlers.ListSupplierInvoices)
+				r.Post("/generate", handlers.PostGenerateSupplierInvoices)
+				r.Get("/{id}", handlers.ShowSupplierInvoice)
+				r.Post("/{id}/status", handlers.PostSupplierInvoiceStatus)
+				r.Get("/{id}/pdf", handlers.ExportSupplierInvoicePDF)
+				r.Get("/{id}/export", handlers.ExportSupplierInvoice)
+				r.Delete("/{id}", handlers.DeleteSupplierInvoice)
 			})
 		})
 
 		r.Route("/supplier", func(r chi.Router) {
 			r.Get("/portal", handlers.ShowSupplierPortal)
+			r.Get("/invoices", handlers.ListSupplierPortalInvoices)
+			r.Get("/invoices/{id}/pdf", handlers.ExportSupplierPortalInvoicePDF)
+			r.Get("/invoices/{id}/export", handlers.ExportSupplierPortalInvoice)
 		})
 	})
 
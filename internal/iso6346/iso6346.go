@@ -0,0 +1,133 @@
+// Package iso6346 validates and normalizes freight container numbers
+// (owner code, category identifier, serial number and check digit).
+package iso6346
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Length is the length of a normalized container number, e.g. CSQU3054383.
+const Length = 11
+
+// Error explains why a container number is not a valid ISO 6346 number.
+type Error struct {
+	Number string
+	Reason string
+	// Suggestion is the number with a corrected check digit when only the
+	// check digit is wrong.
+	Suggestion string
+}
+
+func (e *Error) Error() string {
+	if e.Number == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("컨테이너 번호 %s: %s", e.Number, e.Reason)
+}
+
+// IsInvalid reports whether err comes from Validate.
+func IsInvalid(err error) bool {
+	var target *Error
+	return errors.As(err, &target)
+}
+
+// Normalize uppercases the number and drops the spaces, hyphens, dots and
+// slashes that are often printed between its parts, plus the byte order mark
+// a CSV export can leave on the first cell.
+func Normalize(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if unicode.IsSpace(r) || r == '-' || r == '.' || r == '/' || r == '\ufeff' {
+			continue
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// Validate checks an already normalized number.
+func Validate(number string) error {
+	invalid := func(reason string) error {
+		return &Error{Number: number, Reason: reason}
+	}
+	if number == "" {
+		return &Error{Reason: "컨테이너 번호를 입력해 주세요."}
+	}
+	if len(number) != Length {
+		return invalid(fmt.Sprintf("영문 4자리와 숫자 7자리, 모두 %d자리여야 합니다.", Length))
+	}
+	for i := 0; i < 3; i++ {
+		if number[i] < 'A' || number[i] > 'Z' {
+			return invalid("앞 3자리 소유자 코드는 영문이어야 합니다.")
+		}
+	}
+	switch number[3] {
+	case 'U', 'J', 'Z':
+	default:
+		return invalid("4번째 자리 장비 구분은 U, J, Z 중 하나여야 합니다.")
+	}
+	for i := 4; i < Length; i++ {
+		if number[i] < '0' || number[i] > '9' {
+			return invalid("일련번호 6자리와 체크 디지트는 숫자여야 합니다.")
+		}
+	}
+	digit, _ := CheckDigit(number[:Length-1])
+	if int(number[Length-1]-'0') != digit {
+		return &Error{
+			Number:     number,
+			Reason:     fmt.Sprintf("체크 디지트가 맞지 않습니다. %d이어야 합니다.", digit),
+			Suggestion: number[:Length-1] + string(rune('0'+digit)),
+		}
+	}
+	return nil
+}
+
+// Parse normalizes and validates value, returning the normalized number.
+func Parse(value string) (string, error) {
+	number := Normalize(value)
+	if err := Validate(number); err != nil {
+		return number, err
+	}
+	return number, nil
+}
+
+// CheckDigit computes the check digit of the first ten characters of a
+// container number.
+func CheckDigit(prefix string) (int, error) {
+	if len(prefix) != Length-1 {
+		return 0, fmt.Errorf("체크 디지트는 앞 %d자리로 계산합니다", Length-1)
+	}
+	sum := 0
+	weight := 1
+	for i := 0; i < len(prefix); i++ {
+		value, ok := charValue(prefix[i])
+		if !ok {
+			return 0, fmt.Errorf("계산할 수 없는 문자입니다: %q", prefix[i])
+		}
+		sum += value * weight
+		weight *= 2
+	}
+	return sum % 11 % 10, nil
+}
+
+// charValue maps digits to themselves and letters to 10..38, skipping the
+// multiples of 11.
+func charValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		value := 10
+		for l := byte('A'); l < c; l++ {
+			value++
+			if value%11 == 0 {
+				value++
+			}
+		}
+		return value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,120 @@
+package iso6346
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		number     string
+		valid      bool
+		suggestion string
+	}{
+		{"CSQU3054383", true, ""},
+		{"MSKU9070323", true, ""},
+		{"CSQU0000071", false, "CSQU0000070"},
+		{"CSQU3054384", false, "CSQU3054383"},
+		{"MSKU9070320", false, "MSKU9070323"},
+		{"CSQX3054383", false, ""},
+		{"CSQA3054383", false, ""},
+		{"CS1U3054383", false, ""},
+		{"CSQU305438A", false, ""},
+		{"CSQU305438", false, ""},
+		{"CSQU30543830", false, ""},
+		{"", false, ""},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.number)
+		if tt.valid {
+			if err != nil {
+				t.Errorf("Validate(%q) = %v, want nil", tt.number, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Validate(%q) = nil, want error", tt.number)
+			continue
+		}
+		if !IsInvalid(err) {
+			t.Errorf("Validate(%q) error %v is not an *Error", tt.number, err)
+			continue
+		}
+		if got := err.(*Error).Suggestion; got != tt.suggestion {
+			t.Errorf("Validate(%q) suggestion = %q, want %q", tt.number, got, tt.suggestion)
+		}
+	}
+}
+
+func TestValidateCategory(t *testing.T) {
+	for _, category := range []byte("UJZ") {
+		prefix := "ABC" + string(category) + "123456"
+		digit, err := CheckDigit(prefix)
+		if err != nil {
+			t.Fatalf("CheckDigit(%q): %v", prefix, err)
+		}
+		number := prefix + string(rune('0'+digit))
+		if err := Validate(number); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", number, err)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{"CSQU305438", 3},
+		{"MSKU907032", 3},
+		// Sum % 11 is 10 here, which ISO 6346 maps to 0.
+		{"CSQU000007", 0},
+	}
+	for _, tt := range tests {
+		got, err := CheckDigit(tt.prefix)
+		if err != nil {
+			t.Errorf("CheckDigit(%q) error: %v", tt.prefix, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CheckDigit(%q) = %d, want %d", tt.prefix, got, tt.want)
+		}
+	}
+
+	for _, prefix := range []string{"CSQU30543", "CSQU3054383", "CSQU30543!"} {
+		if _, err := CheckDigit(prefix); err == nil {
+			t.Errorf("CheckDigit(%q) = nil error, want error", prefix)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"CSQU3054383", "CSQU3054383"},
+		{"csqu3054383", "CSQU3054383"},
+		{" CSQU 305438 3 ", "CSQU3054383"},
+		{"CSQU-305438-3", "CSQU3054383"},
+		{"CSQU.305438.3", "CSQU3054383"},
+		{"CSQU 305438/3", "CSQU3054383"},
+		{"\ufeffCSQU3054383", "CSQU3054383"},
+		{"CSQU\t3054383\n", "CSQU3054383"},
+		{"", ""},
+		{" - . / ", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.value); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	number, err := Parse("csqu-305438-3")
+	if err != nil || number != "CSQU3054383" {
+		t.Errorf("Parse = %q, %v; want CSQU3054383, nil", number, err)
+	}
+	number, err = Parse("csqu 305438 4")
+	if !IsInvalid(err) || number != "CSQU3054384" {
+		t.Errorf("Parse = %q, %v; want CSQU3054384 with an *Error", number, err)
+	}
+}
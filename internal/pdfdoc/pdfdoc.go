@@ -0,0 +1,173 @@
+package pdfdoc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"skycontainers/internal/scancode"
+	"strings"
+	"sync"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// FontFamily is the family name the Korean font is registered under.
+const FontFamily = "korean"
+
+var fontCandidates = []string{
+	"web/static/fonts/NanumGothic.ttf",
+	"/usr/share/fonts/truetype/nanum/NanumGothic.ttf",
+	"/usr/share/fonts/nanum/NanumGothic.ttf",
+}
+
+var boldFontCandidates = []string{
+	"web/static/fonts/NanumGothicBold.ttf",
+	"/usr/share/fonts/truetype/nanum/NanumGothicBold.ttf",
+	"/usr/share/fonts/nanum/NanumGothicBold.ttf",
+}
+
+var ErrFontNotFound = errors.New("PDF용 한글 글꼴을 찾을 수 없습니다")
+
+var (
+	fontMu   sync.Mutex
+	fontData []byte
+	boldData []byte
+)
+
+// New creates a document in millimetres with the Korean font registered as
+// FontFamily (regular and bold). Without a bold file the regular font is
+// used for both styles.
+func New(orientation string, size string) (*gofpdf.Fpdf, error) {
+	return newDocument(&gofpdf.InitType{OrientationStr: orientation, UnitStr: "mm", SizeStr: size})
+}
+
+// NewCustom is New for a page size given in millimetres, e.g. labels.
+func NewCustom(width, height float64) (*gofpdf.Fpdf, error) {
+	// gofpdf swaps a custom size for "L", so the size is always given as-is
+	// with portrait orientation.
+	return newDocument(&gofpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           gofpdf.SizeType{Wd: width, Ht: height},
+	})
+}
+
+func newDocument(init *gofpdf.InitType) (*gofpdf.Fpdf, error) {
+	regular, bold, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+	pdf := gofpdf.NewCustom(init)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	pdf.AddUTF8FontFromBytes(FontFamily, "", regular)
+	pdf.AddUTF8FontFromBytes(FontFamily, "B", bold)
+	if err := pdf.Error(); err != nil {
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// loadFonts reads the font files once. PDF_FONT_PATH and PDF_FONT_BOLD_PATH
+// take precedence over the bundled locations.
+func loadFonts() ([]byte, []byte, error) {
+	fontMu.Lock()
+	defer fontMu.Unlock()
+	if fontData != nil {
+		return fontData, boldData, nil
+	}
+
+	regular, path, err := readFirst(os.Getenv("PDF_FONT_PATH"), fontCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if regular == nil {
+		return nil, nil, fmt.Errorf("%w: PDF_FONT_PATH를 설정하거나 %s 파일을 준비해 주세요", ErrFontNotFound, fontCandidates[0])
+	}
+	bold, _, err := readFirst(os.Getenv("PDF_FONT_BOLD_PATH"), boldFontCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bold == nil {
+		bold = regular
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".ttf") {
+		return nil, nil, fmt.Errorf("PDF 글꼴은 TTF 파일이어야 합니다: %s", path)
+	}
+
+	fontData = regular
+	boldData = bold
+	return fontData, boldData, nil
+}
+
+func readFirst(configured string, candidates []string) ([]byte, string, error) {
+	configured = strings.TrimSpace(configured)
+	if configured != "" {
+		data, err := os.ReadFile(configured)
+		if err != nil {
+			return nil, configured, fmt.Errorf("PDF 글꼴을 읽을 수 없습니다 (%s): %w", configured, err)
+		}
+		return data, configured, nil
+	}
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return data, path, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// SetHexColor sets the text colour from a "#RRGGBB" value and reports whether
+// the value could be parsed.
+func SetHexColor(pdf *gofpdf.Fpdf, value string) bool {
+	r, g, b, ok := ParseHexColor(value)
+	if !ok {
+		return false
+	}
+	pdf.SetTextColor(r, g, b)
+	return true
+}
+
+// ParseHexColor parses "#RGB" or "#RRGGBB".
+func ParseHexColor(value string) (int, int, int, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return 0, 0, 0, false
+	}
+	var r, g, b int
+	if _, err := fmt.Sscanf(value, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return 0, 0, 0, false
+	}
+	return r, g, b, true
+}
+
+// DrawCode draws a barcode or QR matrix into the box with quiet modules
+// around it. Linear codes fill the box height; QR codes stay square and are
+// centred.
+func DrawCode(pdf *gofpdf.Fpdf, m scancode.Matrix, x, y, w, h float64, quiet int) {
+	if m.Width == 0 {
+		return
+	}
+	moduleW := w / float64(m.Width+quiet*2)
+	moduleH := h
+	offsetX := x + float64(quiet)*moduleW
+	offsetY := y
+	if m.Height > 1 {
+		side := w
+		if h < side {
+			side = h
+		}
+		moduleW = side / float64(m.Width+quiet*2)
+		moduleH = moduleW
+		offsetX = x + (w-side)/2 + float64(quiet)*moduleW
+		offsetY = y + (h-side)/2 + float64(quiet)*moduleW
+	}
+	pdf.SetFillColor(0, 0, 0)
+	for _, run := range m.Runs() {
+		pdf.Rect(offsetX+float64(run.X)*moduleW, offsetY+float64(run.Y)*moduleH, float64(run.Len)*moduleW, moduleH, "F")
+	}
+}
@@ -29,9 +29,12 @@ const (
 	ResourceSuppliers      Resource = "suppliers"
 	ResourceBLPositions    Resource = "bl_positions"
 	ResourceCarNumbers     Resource = "carnumbers"
+	ResourceStorageTariffs Resource = "storage_tariffs"
+	ResourceInvoices       Resource = "invoices"
 	ResourceUsers          Resource = "users"
 	ResourceSupplierPortal Resource = "supplier_portal"
 	ResourcePolicies       Resource = "policies"
+	ResourceSettings       Resource = "settings"
 )
 
 const (
@@ -98,9 +101,12 @@ func Resources() []Resource {
 		ResourceSuppliers,
 		ResourceBLPositions,
 		ResourceCarNumbers,
+		ResourceStorageTariffs,
+		ResourceInvoices,
 		ResourceUsers,
 		ResourceSupplierPortal,
 		ResourcePolicies,
+		ResourceSettings,
 	}
 }
 
@@ -164,7 +170,9 @@ func defaultAllowByRole(role string, action Action, resource Resource) bool {
 			return action == ActionRead
 		case ResourceUsers:
 			return action == ActionRead
-		case ResourceContainerTypes, ResourceSuppliers, ResourceBLPositions, ResourceCarNumbers:
+		case ResourceSettings:
+			return action == ActionRead || action == ActionUpdate
+		case ResourceContainerTypes, ResourceSuppliers, ResourceBLPositions, ResourceCarNumbers, ResourceStorageTariffs, ResourceInvoices:
 			return action == ActionRead || action == ActionCreate || action == ActionUpdate || action == ActionDelete
 		case ResourcePolicies:
 			return false
@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Keys of the app_settings table.
+const (
+	SettingLabelPrinterAddr = "label_printer_addr"
+)
+
+type AppSetting struct{}
+
+// Get returns the stored value, or "" when the key has never been saved.
+func (r *AppSetting) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := DB.QueryRow(ctx, `SELECT value FROM app_settings WHERE key = $1`, key).Scan(&value)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return value, nil
+}
+
+func (r *AppSetting) Set(ctx context.Context, key, value string, userID int64) error {
+	_, err := DB.Exec(ctx,
+		`INSERT INTO app_settings (key, value, updated_by, updated_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (key)
+		 DO UPDATE SET value = $2, updated_by = $3, updated_at = $4`,
+		key, value, userID, time.Now())
+	return err
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BLImportProfile describes how one supplier lays out its BL marking
+// spreadsheet. Column values are either header text (matched against the
+// header row) or a column letter such as "C".
+type BLImportProfile struct {
+	ID              int64
+	SupplierID      int64
+	SupplierName    string
+	Name            string
+	SheetName       string
+	HeaderRow       int
+	HBLColumn       string
+	CneeColumn      string
+	MarksColumn     string
+	ContainerColumn string
+	HBLTransforms   string
+	CneeTransforms  string
+	MarksTransforms string
+	IsActive        bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Label is the name shown in the upload form.
+func (p BLImportProfile) Label() string {
+	if p.SupplierName == "" {
+		return p.Name
+	}
+	return p.SupplierName + " - " + p.Name
+}
+
+const blImportProfileColumns = `p.id, p.supplier_id, COALESCE(s.name, ''), p.name, p.sheet_name, p.header_row,
+	        p.hbl_column, p.cnee_column, p.marks_column, p.container_column,
+	        p.hbl_transforms, p.cnee_transforms, p.marks_transforms,
+	        p.is_active, p.created_at, p.updated_at`
+
+func scanBLImportProfile(row pgx.Row) (BLImportProfile, error) {
+	var item BLImportProfile
+	err := row.Scan(
+		&item.ID,
+		&item.SupplierID,
+		&item.SupplierName,
+		&item.Name,
+		&item.SheetName,
+		&item.HeaderRow,
+		&item.HBLColumn,
+		&item.CneeColumn,
+		&item.MarksColumn,
+		&item.ContainerColumn,
+		&item.HBLTransforms,
+		&item.CneeTransforms,
+		&item.MarksTransforms,
+		&item.IsActive,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	return item, err
+}
+
+func (r *BLImportProfile) ListBySupplier(ctx context.Context, supplierID int64) ([]BLImportProfile, error) {
+	return r.list(ctx,
+		`SELECT `+blImportProfileColumns+`
+		   FROM bl_import_profiles p
+		   LEFT JOIN suppliers s ON s.id = p.supplier_id
+		  WHERE p.supplier_id = $1
+		  ORDER BY p.name ASC, p.id ASC`, supplierID)
+}
+
+// ListActive returns the profiles offered in the upload form, skipping those
+// of inactive suppliers.
+func (r *BLImportProfile) ListActive(ctx context.Context) ([]BLImportProfile, error) {
+	return r.list(ctx,
+		`SELECT `+blImportProfileColumns+`
+		   FROM bl_import_profiles p
+		   JOIN suppliers s ON s.id = p.supplier_id
+		  WHERE p.is_active = true AND s.is_active = true
+		  ORDER BY s.name ASC, p.name ASC, p.id ASC`)
+}
+
+func (r *BLImportProfile) list(ctx context.Context, query string, args ...any) ([]BLImportProfile, error) {
+	rows, err := DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []BLImportProfile
+	for rows.Next() {
+		item, err := scanBLImportProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func (r *BLImportProfile) GetByID(ctx context.Context, id int64) (*BLImportProfile, error) {
+	item, err := scanBLImportProfile(DB.QueryRow(ctx,
+		`SELECT `+blImportProfileColumns+`
+		   FROM bl_import_profiles p
+		   LEFT JOIN suppliers s ON s.id = p.supplier_id
+		  WHERE p.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *BLImportProfile) Create(ctx context.Context) error {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	return DB.QueryRow(ctx,
+		`INSERT INTO bl_import_profiles
+		 (supplier_id, name, sheet_name, header_row, hbl_column, cnee_column, marks_column, container_column,
+		  hbl_transforms, cnee_transforms, marks_transforms, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		 RETURNING id`,
+		r.SupplierID,
+		r.Name,
+		r.SheetName,
+		r.HeaderRow,
+		r.HBLColumn,
+		r.CneeColumn,
+		r.MarksColumn,
+		r.ContainerColumn,
+		r.HBLTransforms,
+		r.CneeTransforms,
+		r.MarksTransforms,
+		r.IsActive,
+		r.CreatedAt,
+		r.UpdatedAt,
+	).Scan(&r.ID)
+}
+
+func (r *BLImportProfile) Update(ctx context.Context) error {
+	r.UpdatedAt = time.Now()
+	_, err := DB.Exec(ctx,
+		`UPDATE bl_import_profiles SET
+		 name = $1,
+		 sheet_name = $2,
+		 header_row = $3,
+		 hbl_column = $4,
+		 cnee_column = $5,
+		 marks_column = $6,
+		 container_column = $7,
+		 hbl_transforms = $8,
+		 cnee_transforms = $9,
+		 marks_transforms = $10,
+		 is_active = $11,
+		 updated_at = $12
+		 WHERE id = $13`,
+		r.Name,
+		r.SheetName,
+		r.HeaderRow,
+		r.HBLColumn,
+		r.CneeColumn,
+		r.MarksColumn,
+		r.ContainerColumn,
+		r.HBLTransforms,
+		r.CneeTransforms,
+		r.MarksTransforms,
+		r.IsActive,
+		r.UpdatedAt,
+		r.ID,
+	)
+	return err
+}
+
+func (r *BLImportProfile) Delete(ctx context.Context, id int64) error {
+	_, err := DB.Exec(ctx, `DELETE FROM bl_import_profiles WHERE id = $1`, id)
+	return err
+}
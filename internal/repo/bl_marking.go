@@ -2,11 +2,13 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"skycontainers/internal/pagination"
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -24,11 +26,27 @@ type BLMarking struct {
 	IsActive       bool
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	SupplierID     int64
 	SupplierName   string
 	SupplierShort  string
 	SupplierColor  string
 	FrmUnipass     *string
 	HasUnipass     bool
+	LookupPending  bool
+	NeedsSelection bool
+	CargMtNo       string
+	CustomsStatus  string
+	ProgressStatus string
+	ArrivalDate    *time.Time
+}
+
+type BLMarkingFilter struct {
+	ContainerNo    string
+	HBLNo          string
+	UnassignedOnly bool
+	UnipassStatus  string
+	CustomsStatus  string
+	Sort           string
 }
 
 type BLMarkingUnipassTarget struct {
@@ -36,30 +54,49 @@ type BLMarkingUnipassTarget struct {
 	HBLNo string
 }
 
-func (r *BLMarking) List(ctx context.Context, p pagination.Pager, containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) ([]BLMarking, int, error) {
+func buildBLMarkingFilter(f BLMarkingFilter) (string, []interface{}) {
 	conditions := []string{"1=1"}
 	args := make([]interface{}, 0, 4)
-	if strings.TrimSpace(containerNo) != "" {
+	if strings.TrimSpace(f.ContainerNo) != "" {
 		conditions = append(conditions, fmt.Sprintf("c.container_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(containerNo)+"%")
+		args = append(args, "%"+strings.TrimSpace(f.ContainerNo)+"%")
 	}
-	if strings.TrimSpace(hblNo) != "" {
+	if strings.TrimSpace(f.HBLNo) != "" {
 		conditions = append(conditions, fmt.Sprintf("b.hbl_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(hblNo)+"%")
+		args = append(args, "%"+strings.TrimSpace(f.HBLNo)+"%")
 	}
-	if unassignedOnly {
+	if f.UnassignedOnly {
 		conditions = append(conditions, "(b.bl_position_id IS NULL OR b.bl_position_id = 0)")
 	}
-	if strings.EqualFold(unipassStatus, "y") {
+	if strings.EqualFold(f.UnipassStatus, "y") {
 		conditions = append(conditions, "b.frm_unipass IS NOT NULL")
 	}
-	if strings.EqualFold(unipassStatus, "n") {
+	if strings.EqualFold(f.UnipassStatus, "n") {
 		conditions = append(conditions, "b.frm_unipass IS NULL")
 	}
-	whereClause := strings.Join(conditions, " AND ")
+	if strings.TrimSpace(f.CustomsStatus) != "" {
+		conditions = append(conditions, fmt.Sprintf("uc.customs_status = $%d", len(args)+1))
+		args = append(args, strings.TrimSpace(f.CustomsStatus))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func blMarkingOrderBy(sort string) string {
+	switch strings.ToLower(strings.TrimSpace(sort)) {
+	case "customs_status":
+		return "uc.customs_status ASC NULLS LAST, b.id DESC"
+	case "arrival_date":
+		return "uc.arrival_date DESC NULLS LAST, b.id DESC"
+	default:
+		return "b.id DESC"
+	}
+}
+
+func (r *BLMarking) List(ctx context.Context, p pagination.Pager, filter BLMarkingFilter) ([]BLMarking, int, error) {
+	whereClause, args := buildBLMarkingFilter(filter)
 
 	var total int
-	countQuery := "SELECT count(*) FROM bl_markings b LEFT JOIN containers c ON c.id = b.container_id WHERE " + whereClause
+	countQuery := "SELECT count(*) FROM bl_markings b LEFT JOIN containers c ON c.id = b.container_id LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id WHERE " + whereClause
 	err := DB.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
@@ -69,16 +106,20 @@ func (r *BLMarking) List(ctx context.Context, p pagination.Pager, containerNo st
 	offsetIndex := len(args) + 2
 		rows, err := DB.Query(ctx,
 			fmt.Sprintf(`SELECT b.id, b.container_id, b.user_id, b.bl_position_id, b.hbl_no, b.marks, b.cnee, b.is_active, b.created_at,
-													 c.container_no, p.name, u.name, s.name, s.short_name,
-													 CASE WHEN b.frm_unipass IS NULL THEN false ELSE true END
+													 c.container_no, p.path, u.name, s.name, s.short_name,
+													 CASE WHEN b.frm_unipass IS NULL THEN false ELSE true END,
+													 COALESCE(uc.customs_status, ''), COALESCE(uc.progress_status, ''), uc.arrival_date,
+													 EXISTS (SELECT 1 FROM unipass_jobs j WHERE j.bl_marking_id = b.id AND j.status IN ('pending', 'running')),
+													 EXISTS (SELECT 1 FROM bl_unipass_candidates bc WHERE bc.bl_marking_id = b.id)
 											 FROM bl_markings b
 											 LEFT JOIN containers c ON c.id = b.container_id
 											 LEFT JOIN suppliers s ON s.id = c.supplier_id
 						LEFT JOIN bl_positions p ON p.id = b.bl_position_id
 						LEFT JOIN users u ON u.id = b.user_id
+						LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
 			WHERE %s
-			ORDER BY b.id DESC
-			LIMIT $%d OFFSET $%d`, whereClause, limitIndex, offsetIndex),
+			ORDER BY %s
+			LIMIT $%d OFFSET $%d`, whereClause, blMarkingOrderBy(filter.Sort), limitIndex, offsetIndex),
 		append(args, p.PageSize, p.Offset())...)
 	if err != nil {
 		return nil, 0, err
@@ -111,6 +152,11 @@ func (r *BLMarking) List(ctx context.Context, p pagination.Pager, containerNo st
 			&supplierName,
 			&supplierShort,
 			&item.HasUnipass,
+			&item.CustomsStatus,
+			&item.ProgressStatus,
+			&item.ArrivalDate,
+			&item.LookupPending,
+			&item.NeedsSelection,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -143,38 +189,21 @@ func (r *BLMarking) List(ctx context.Context, p pagination.Pager, containerNo st
 	return list, total, nil
 }
 
-func (r *BLMarking) ListForExport(ctx context.Context, containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) ([]BLMarking, error) {
-	conditions := []string{"1=1"}
-	args := make([]interface{}, 0, 3)
-	if strings.TrimSpace(containerNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("c.container_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(containerNo)+"%")
-	}
-	if strings.TrimSpace(hblNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("b.hbl_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(hblNo)+"%")
-	}
-	if unassignedOnly {
-		conditions = append(conditions, "(b.bl_position_id IS NULL OR b.bl_position_id = 0)")
-	}
-	if strings.EqualFold(unipassStatus, "y") {
-		conditions = append(conditions, "b.frm_unipass IS NOT NULL")
-	}
-	if strings.EqualFold(unipassStatus, "n") {
-		conditions = append(conditions, "b.frm_unipass IS NULL")
-	}
-	whereClause := strings.Join(conditions, " AND ")
+func (r *BLMarking) ListForExport(ctx context.Context, filter BLMarkingFilter) ([]BLMarking, error) {
+	whereClause, args := buildBLMarkingFilter(filter)
 
 	rows, err := DB.Query(ctx,
 		fmt.Sprintf(`SELECT b.id, b.container_id, b.user_id, b.bl_position_id, b.hbl_no, b.marks, b.cnee, b.is_active, b.created_at,
-											c.container_no, p.name, u.name, s.name
+											c.container_no, p.path, u.name, s.name,
+											COALESCE(uc.customs_status, ''), COALESCE(uc.progress_status, ''), uc.arrival_date
 									FROM bl_markings b
 									LEFT JOIN containers c ON c.id = b.container_id
 									LEFT JOIN suppliers s ON s.id = c.supplier_id
 					LEFT JOIN bl_positions p ON p.id = b.bl_position_id
 					LEFT JOIN users u ON u.id = b.user_id
+					LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
 					WHERE %s
-					ORDER BY b.id DESC`, whereClause),
+					ORDER BY %s`, whereClause, blMarkingOrderBy(filter.Sort)),
 		args...)
 	if err != nil {
 		return nil, err
@@ -204,6 +233,9 @@ func (r *BLMarking) ListForExport(ctx context.Context, containerNo string, hblNo
 			&positionName,
 			&userName,
 			&supplierName,
+			&item.CustomsStatus,
+			&item.ProgressStatus,
+			&item.ArrivalDate,
 		)
 		if err != nil {
 			return nil, err
@@ -233,61 +265,37 @@ func (r *BLMarking) ListForExport(ctx context.Context, containerNo string, hblNo
 	return list, nil
 }
 
-func (r *BLMarking) DeleteByFilters(ctx context.Context, containerNo string, hblNo string, unassignedOnly bool) (int64, error) {
-	conditions := []string{"1=1"}
-	args := make([]interface{}, 0, 3)
-	if strings.TrimSpace(containerNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("b.container_id IN (SELECT id FROM containers WHERE container_no ILIKE $%d)", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(containerNo)+"%")
-	}
-	if strings.TrimSpace(hblNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("b.hbl_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(hblNo)+"%")
-	}
-	if unassignedOnly {
-		conditions = append(conditions, "(b.bl_position_id IS NULL OR b.bl_position_id = 0)")
-	}
-	whereClause := strings.Join(conditions, " AND ")
+// DeleteByFilters removes every BL the list would show for filter, using the
+// same joins as List so the customs status filter is honoured.
+func (r *BLMarking) DeleteByFilters(ctx context.Context, filter BLMarkingFilter) (int64, error) {
+	whereClause, args := buildBLMarkingFilter(filter)
 
-	result, err := DB.Exec(ctx, "DELETE FROM bl_markings b WHERE "+whereClause, args...)
+	result, err := DB.Exec(ctx,
+		`DELETE FROM bl_markings WHERE id IN (
+		     SELECT b.id FROM bl_markings b
+		       LEFT JOIN containers c ON c.id = b.container_id
+		       LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
+		      WHERE `+whereClause+`)`, args...)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
 
-func (r *BLMarking) ListForCargoCard(ctx context.Context, containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) ([]BLMarking, error) {
-	conditions := []string{"1=1"}
-	args := make([]interface{}, 0, 3)
-	if strings.TrimSpace(containerNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("c.container_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(containerNo)+"%")
-	}
-	if strings.TrimSpace(hblNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("b.hbl_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(hblNo)+"%")
-	}
-	if unassignedOnly {
-		conditions = append(conditions, "(b.bl_position_id IS NULL OR b.bl_position_id = 0)")
-	}
-	if strings.EqualFold(unipassStatus, "y") {
-		conditions = append(conditions, "b.frm_unipass IS NOT NULL")
-	}
-	if strings.EqualFold(unipassStatus, "n") {
-		conditions = append(conditions, "b.frm_unipass IS NULL")
-	}
-	whereClause := strings.Join(conditions, " AND ")
+func (r *BLMarking) ListForCargoCard(ctx context.Context, filter BLMarkingFilter) ([]BLMarking, error) {
+	whereClause, args := buildBLMarkingFilter(filter)
 
 		rows, err := DB.Query(ctx,
 			fmt.Sprintf(`SELECT b.id, b.container_id, b.user_id, b.bl_position_id, b.hbl_no, b.marks, b.cnee, b.is_active, b.created_at,
-																			 c.container_no, p.name, u.name, s.name, s.color, b.frm_unipass
+																			 c.container_no, p.path, u.name, s.name, s.color, b.frm_unipass, COALESCE(uc.carg_mt_no, ''), COALESCE(s.id, 0)
 																			 FROM bl_markings b
 																			 LEFT JOIN containers c ON c.id = b.container_id
 																			 LEFT JOIN suppliers s ON s.id = c.supplier_id
 										LEFT JOIN bl_positions p ON p.id = b.bl_position_id
-										LEFT JOIN users u ON u.id = b.user_id   
+										LEFT JOIN users u ON u.id = b.user_id
+										LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
 					WHERE %s
-					ORDER BY b.id DESC`, whereClause),
+					ORDER BY %s`, whereClause, blMarkingOrderBy(filter.Sort)),
 		args...)
 	if err != nil {
 		return nil, err
@@ -321,6 +329,8 @@ func (r *BLMarking) ListForCargoCard(ctx context.Context, containerNo string, hb
 			&supplierName,
 			&supplierColor,
 			&frmUnipass,
+			&item.CargMtNo,
+			&item.SupplierID,
 		)
 		if err != nil {
 			return nil, err
@@ -357,32 +367,14 @@ func (r *BLMarking) ListForCargoCard(ctx context.Context, containerNo string, hb
 	return list, nil
 }
 
-func (r *BLMarking) ListForUnipassApply(ctx context.Context, containerNo string, hblNo string, unassignedOnly bool, unipassStatus string) ([]BLMarkingUnipassTarget, error) {
-	conditions := []string{"1=1"}
-	args := make([]interface{}, 0, 3)
-	if strings.TrimSpace(containerNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("c.container_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(containerNo)+"%")
-	}
-	if strings.TrimSpace(hblNo) != "" {
-		conditions = append(conditions, fmt.Sprintf("b.hbl_no ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(hblNo)+"%")
-	}
-	if unassignedOnly {
-		conditions = append(conditions, "(b.bl_position_id IS NULL OR b.bl_position_id = 0)")
-	}
-	if strings.EqualFold(unipassStatus, "y") {
-		conditions = append(conditions, "b.frm_unipass IS NOT NULL")
-	}
-	if strings.EqualFold(unipassStatus, "n") {
-		conditions = append(conditions, "b.frm_unipass IS NULL")
-	}
-	whereClause := strings.Join(conditions, " AND ")
+func (r *BLMarking) ListForUnipassApply(ctx context.Context, filter BLMarkingFilter) ([]BLMarkingUnipassTarget, error) {
+	whereClause, args := buildBLMarkingFilter(filter)
 
 	rows, err := DB.Query(ctx,
 		fmt.Sprintf(`SELECT b.id, b.hbl_no
 					FROM bl_markings b
 					LEFT JOIN containers c ON c.id = b.container_id
+					LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
 					WHERE %s
 					ORDER BY b.id DESC`, whereClause),
 		args...)
@@ -403,17 +395,84 @@ func (r *BLMarking) ListForUnipassApply(ctx context.Context, containerNo string,
 	return list, nil
 }
 
-func (r *BLMarking) UpdateUnipassXML(ctx context.Context, id int64, xmlData *string) error {
-	_, err := DB.Exec(ctx,
+// UpdateUnipassResult stores the raw response and its normalized cargo row
+// together so the list filters never disagree with the stored XML. Status
+// changes are appended to bl_unipass_events in the same transaction, and any
+// pending candidates are cleared.
+func (r *BLMarking) UpdateUnipassResult(ctx context.Context, id int64, xmlData string, cargo *BLUnipassCargo) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx,
 		`UPDATE bl_markings SET
 		 frm_unipass = $1,
 		 updated_at = $2
 		 WHERE id = $3`,
 		xmlData,
-		time.Now(),
+		now,
 		id,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	if cargo != nil {
+		cargo.BLMarkingID = id
+		cargo.FetchedAt = now
+		if err := recordBLUnipassEvent(ctx, tx, cargo); err != nil {
+			return err
+		}
+		if err := upsertBLUnipassCargo(ctx, tx, cargo); err != nil {
+			return err
+		}
+	}
+	if err := replaceBLUnipassCandidates(ctx, tx, id, nil, now); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateUnipassCandidates stores a response with several cargo records that
+// could not be matched to the BL. The normalized cargo row is left as it was
+// until a user picks one of the candidates.
+func (r *BLMarking) UpdateUnipassCandidates(ctx context.Context, id int64, xmlData string, candidates []BLUnipassCandidate) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx,
+		`UPDATE bl_markings SET
+		 frm_unipass = $1,
+		 updated_at = $2
+		 WHERE id = $3`,
+		xmlData,
+		now,
+		id,
+	); err != nil {
+		return err
+	}
+	if err := replaceBLUnipassCandidates(ctx, tx, id, candidates, now); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetUnipassXML returns the stored UNIPASS response of a BL, or nil when it
+// has not been looked up yet.
+func (r *BLMarking) GetUnipassXML(ctx context.Context, id int64) (*string, error) {
+	var xmlData pgtype.Text
+	if err := DB.QueryRow(ctx, `SELECT frm_unipass::text FROM bl_markings WHERE id = $1`, id).Scan(&xmlData); err != nil {
+		return nil, err
+	}
+	if !xmlData.Valid {
+		return nil, nil
+	}
+	return &xmlData.String, nil
 }
 
 func (r *BLMarking) GetByID(ctx context.Context, id int64) (*BLMarking, error) {
@@ -459,6 +518,21 @@ func (r *BLMarking) GetByID(ctx context.Context, id int64) (*BLMarking, error) {
 }
 
 func (r *BLMarking) GetByHBLNo(ctx context.Context, hblNo string) (*BLMarking, error) {
+	return getBLMarkingByHBLNo(ctx, hblNo, true)
+}
+
+// blMarkingHBLMatchOrder picks the row an HBL number refers to when
+// deactivated copies exist: the active one first, then the oldest.
+const blMarkingHBLMatchOrder = "b.is_active DESC, b.id ASC"
+
+// GetByHBLNoForImport also matches a deactivated BL, so an upload updates and
+// reactivates it instead of inserting a duplicate. ImportBatch.Commit looks
+// rows up in the same order.
+func (r *BLMarking) GetByHBLNoForImport(ctx context.Context, hblNo string) (*BLMarking, error) {
+	return getBLMarkingByHBLNo(ctx, hblNo, false)
+}
+
+func getBLMarkingByHBLNo(ctx context.Context, hblNo string, activeOnly bool) (*BLMarking, error) {
 	var item BLMarking
 	var blPositionID pgtype.Int8
 	var containerNo pgtype.Text
@@ -466,12 +540,14 @@ func (r *BLMarking) GetByHBLNo(ctx context.Context, hblNo string) (*BLMarking, e
 	var positionName pgtype.Text
 	err := DB.QueryRow(ctx,
 	`SELECT b.id, b.container_id, b.user_id, b.bl_position_id, b.hbl_no, b.marks, b.cnee, b.is_active, b.created_at, b.updated_at,
-						c.container_no, s.name, p.name
+						c.container_no, s.name, p.path
 				FROM bl_markings b
 				LEFT JOIN containers c ON c.id = b.container_id
 		LEFT JOIN suppliers s ON s.id = c.supplier_id
 		LEFT JOIN bl_positions p ON p.id = b.bl_position_id
-		WHERE b.hbl_no = $1 AND b.is_active = true`, hblNo).
+		WHERE b.hbl_no = $1 AND ($2 = false OR b.is_active = true)
+		ORDER BY `+blMarkingHBLMatchOrder+`
+		LIMIT 1`, hblNo, activeOnly).
 		Scan(
 			&item.ID,
 		&item.ContainerID,
@@ -506,13 +582,135 @@ func (r *BLMarking) GetByHBLNo(ctx context.Context, hblNo string) (*BLMarking, e
 	return &item, nil
 }
 
-func (r *BLMarking) Create(ctx context.Context) error {
+// ContainerNosByHBLNo returns the container each of the given HBLs is already
+// marked on, keyed by HBL number. HBLs without a BL marking are absent.
+func (r *BLMarking) ContainerNosByHBLNo(ctx context.Context, hblNos []string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(hblNos) == 0 {
+		return result, nil
+	}
+	rows, err := DB.Query(ctx,
+		`SELECT b.hbl_no, COALESCE(c.container_no, '')
+		   FROM bl_markings b
+		   LEFT JOIN containers c ON c.id = b.container_id
+		  WHERE b.hbl_no = ANY($1)`, hblNos)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hblNo, containerNo string
+		if err := rows.Scan(&hblNo, &containerNo); err != nil {
+			return nil, err
+		}
+		result[hblNo] = containerNo
+	}
+	return result, nil
+}
+
+type BLRegisterResult struct {
+	Inserted       int
+	Reactivated    int
+	Skipped        int
+	UnipassBatchID int64
+}
+
+// RegisterHBLs creates a BL on containerID for every HBL not already
+// registered, reactivates a deleted BL with the same HBL, and queues its UNIPASS lookup under a new batch, all in one
+// transaction, so a failure part way through leaves nothing behind. Marks
+// defaults to the HBL number when empty.
+func (r *BLMarking) RegisterHBLs(ctx context.Context, containerID int64, userID int64, hblNos []string, marks string) (*BLRegisterResult, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	result := &BLRegisterResult{}
+	now := time.Now()
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO unipass_job_batches (user_id, source, created_at)
+		 VALUES ($1, $2, $3)
+		 RETURNING id`,
+		userID, UnipassBatchMBL, now).
+		Scan(&result.UnipassBatchID); err != nil {
+		return nil, err
+	}
+
+	for _, hblNo := range hblNos {
+		itemMarks := marks
+		if itemMarks == "" {
+			itemMarks = hblNo
+		}
+
+		// A deactivated BL with the same HBL is brought back onto the
+		// container instead of being duplicated, as an upload does.
+		var id int64
+		var isActive bool
+		err := tx.QueryRow(ctx,
+			`SELECT id, is_active FROM bl_markings b WHERE b.hbl_no = $1
+			  ORDER BY `+blMarkingHBLMatchOrder+` LIMIT 1 FOR UPDATE`, hblNo).
+			Scan(&id, &isActive)
+		switch {
+		case err == nil && isActive:
+			result.Skipped++
+			continue
+		case err == nil:
+			if _, err := tx.Exec(ctx,
+				`UPDATE bl_markings SET
+				 container_id = $1,
+				 user_id = $2,
+				 marks = $3,
+				 is_active = true,
+				 updated_at = $4
+				 WHERE id = $5`,
+				containerID, userID, itemMarks, now, id); err != nil {
+				return nil, err
+			}
+			result.Reactivated++
+		case errors.Is(err, pgx.ErrNoRows):
+			if err := tx.QueryRow(ctx,
+				`INSERT INTO bl_markings
+				 (container_id, user_id, hbl_no, marks, cnee, is_active, created_at, updated_at)
+				 VALUES ($1, $2, $3, $4, '', true, $5, $5)
+				 RETURNING id`,
+				containerID, userID, hblNo, itemMarks, now).
+				Scan(&id); err != nil {
+				return nil, err
+			}
+			result.Inserted++
+		default:
+			return nil, err
+		}
+		if err := enqueueUnipassJob(ctx, tx, &result.UnipassBatchID, id, hblNo); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Inserted == 0 && result.Reactivated == 0 {
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Create inserts the BL; a starting position is logged as its first move.
+func (r *BLMarking) Create(ctx context.Context, mover BLPositionMover) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	r.CreatedAt = time.Now()
 	r.UpdatedAt = time.Now()
-	_, err := DB.Exec(ctx,
+	err = tx.QueryRow(ctx,
 		`INSERT INTO bl_markings
 		 (container_id, user_id, bl_position_id, hbl_no, marks, cnee, is_active, frm_unipass, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 RETURNING id`,
 		r.ContainerID,
 		r.UserID,
 		r.BLPositionID,
@@ -523,13 +721,31 @@ func (r *BLMarking) Create(ctx context.Context) error {
 		r.FrmUnipass,
 		r.CreatedAt,
 		r.UpdatedAt,
-	)
-	return err
+	).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+	if err := recordBLPositionMove(ctx, tx, r.ID, nil, r.BLPositionID, mover); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-func (r *BLMarking) Update(ctx context.Context) error {
+// Update saves the BL and logs a move when its position changed.
+func (r *BLMarking) Update(ctx context.Context, mover BLPositionMover) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var from *int64
+	if err := tx.QueryRow(ctx,
+		`SELECT bl_position_id FROM bl_markings WHERE id = $1 FOR UPDATE`, r.ID).Scan(&from); err != nil {
+		return err
+	}
 	r.UpdatedAt = time.Now()
-	_, err := DB.Exec(ctx,
+	_, err = tx.Exec(ctx,
 		`UPDATE bl_markings SET
 		 container_id = $1,
 		 user_id = $2,
@@ -550,11 +766,29 @@ func (r *BLMarking) Update(ctx context.Context) error {
 		r.UpdatedAt,
 		r.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := recordBLPositionMove(ctx, tx, r.ID, from, r.BLPositionID, mover); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-func (r *BLMarking) UpdatePosition(ctx context.Context, id int64, positionID int64) error {
-	_, err := DB.Exec(ctx,
+// UpdatePosition moves the BL to positionID and logs the move.
+func (r *BLMarking) UpdatePosition(ctx context.Context, id int64, positionID int64, mover BLPositionMover) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var from *int64
+	if err := tx.QueryRow(ctx,
+		`SELECT bl_position_id FROM bl_markings WHERE id = $1 FOR UPDATE`, id).Scan(&from); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
 		`UPDATE bl_markings SET
 		 bl_position_id = $1,
 		 updated_at = $2
@@ -563,7 +797,13 @@ func (r *BLMarking) UpdatePosition(ctx context.Context, id int64, positionID int
 		time.Now(),
 		id,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := recordBLPositionMove(ctx, tx, id, from, &positionID, mover); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *BLMarking) Delete(ctx context.Context, id int64) error {
@@ -572,3 +812,48 @@ func (r *BLMarking) Delete(ctx context.Context, id int64) error {
 		time.Now(), id)
 	return err
 }
+
+// BLMarkingLabel is one thermal label row with the supplier's ZPL template.
+type BLMarkingLabel struct {
+	HBLNo         string
+	Marks         string
+	Cnee          string
+	ContainerNo   string
+	PositionName  string
+	SupplierID    int64
+	SupplierName  string
+	SupplierShort string
+	ZPLTemplate   string
+}
+
+// ListForLabels returns the same BL set as ListForCargoCard for label output.
+func (r *BLMarking) ListForLabels(ctx context.Context, filter BLMarkingFilter) ([]BLMarkingLabel, error) {
+	whereClause, args := buildBLMarkingFilter(filter)
+
+	rows, err := DB.Query(ctx,
+		fmt.Sprintf(`SELECT b.hbl_no, b.marks, COALESCE(b.cnee, ''), COALESCE(c.container_no, ''), COALESCE(p.path, ''),
+		        COALESCE(s.id, 0), COALESCE(s.name, ''), COALESCE(s.short_name, ''), COALESCE(s.zpl_template, '')
+		 FROM bl_markings b
+		 LEFT JOIN containers c ON c.id = b.container_id
+		 LEFT JOIN suppliers s ON s.id = c.supplier_id
+		 LEFT JOIN bl_positions p ON p.id = b.bl_position_id
+		 LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
+		 WHERE %s
+		 ORDER BY %s`, whereClause, blMarkingOrderBy(filter.Sort)),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []BLMarkingLabel
+	for rows.Next() {
+		var item BLMarkingLabel
+		if err := rows.Scan(&item.HBLNo, &item.Marks, &item.Cnee, &item.ContainerNo, &item.PositionName,
+			&item.SupplierID, &item.SupplierName, &item.SupplierShort, &item.ZPLTemplate); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
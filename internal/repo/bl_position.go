@@ -2,57 +2,138 @@ package repo
 
 import (
 	"context"
-	"skycontainers/internal/pagination"
+	"errors"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BLLocationType is the level of a warehouse location.
+type BLLocationType string
+
+const (
+	BLLocationZone  BLLocationType = "zone"
+	BLLocationRow   BLLocationType = "row"
+	BLLocationRack  BLLocationType = "rack"
+	BLLocationLevel BLLocationType = "level"
+)
+
+// BLLocationTypes lists the location types from the top down.
+var BLLocationTypes = []BLLocationType{BLLocationZone, BLLocationRow, BLLocationRack, BLLocationLevel}
+
+func (t BLLocationType) Label() string {
+	switch t {
+	case BLLocationZone:
+		return "구역"
+	case BLLocationRow:
+		return "열"
+	case BLLocationRack:
+		return "랙"
+	case BLLocationLevel:
+		return "단"
+	default:
+		return string(t)
+	}
+}
+
+func (t BLLocationType) Valid() bool {
+	for _, item := range BLLocationTypes {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Child is the usual type of a location placed under t.
+func (t BLLocationType) Child() BLLocationType {
+	for i, item := range BLLocationTypes {
+		if item == t && i+1 < len(BLLocationTypes) {
+			return BLLocationTypes[i+1]
+		}
+	}
+	return BLLocationLevel
+}
+
+// BLPositionPathSeparator joins the names of a location and its ancestors.
+const BLPositionPathSeparator = " > "
+
+var (
+	ErrBLPositionParentCycle = errors.New("상위 위치로 자기 자신이나 하위 위치를 지정할 수 없습니다.")
+	ErrBLPositionHasChildren = errors.New("하위 위치가 있어 삭제할 수 없습니다. 하위 위치를 먼저 삭제해 주세요.")
 )
 
+// BLPosition is a warehouse location node. Path is the full name including
+// its ancestors and is what BL lists show as the position name.
 type BLPosition struct {
-	ID        int64
-	Name      string
-	IsActive  bool
-	UserID    int64
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              int64
+	ParentID        *int64
+	Name            string
+	Path            string
+	Depth           int
+	LocationType    BLLocationType
+	CapacityPallets *int
+	CapacityCBM     *float64
+	IsActive        bool
+	UserID          int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	// Filled by ListTree.
+	ChildCount int
+	BLCount    int
 }
 
-func (r *BLPosition) List(ctx context.Context, p pagination.Pager) ([]BLPosition, int, error) {
-	var total int
-	err := DB.QueryRow(ctx, "SELECT count(*) FROM bl_positions").Scan(&total)
-	if err != nil {
-		return nil, 0, err
+// CapacityLabel describes the capacity, e.g. "24 팔레트 · 35.5 CBM".
+func (r BLPosition) CapacityLabel() string {
+	var parts []string
+	if r.CapacityPallets != nil {
+		parts = append(parts, strconv.Itoa(*r.CapacityPallets)+" 팔레트")
 	}
+	if r.CapacityCBM != nil {
+		parts = append(parts, strconv.FormatFloat(*r.CapacityCBM, 'f', -1, 64)+" CBM")
+	}
+	return strings.Join(parts, " · ")
+}
 
+// ListTree returns every location depth first, siblings by name.
+func (r *BLPosition) ListTree(ctx context.Context) ([]BLPosition, error) {
 	rows, err := DB.Query(ctx,
-		`SELECT id, name, is_active, user_id
-                FROM bl_positions
-                ORDER BY id DESC
-                LIMIT $1 OFFSET $2`,
-		p.PageSize, p.Offset())
+		`SELECT p.id, p.parent_id, p.name, p.path, p.depth, p.location_type, p.capacity_pallets, p.capacity_cbm,
+		        p.is_active, p.user_id,
+		        (SELECT count(*) FROM bl_positions c WHERE c.parent_id = p.id),
+		        (SELECT count(*) FROM bl_markings b WHERE b.bl_position_id = p.id AND b.is_active = true)
+		   FROM bl_positions p`)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	var list []BLPosition
 	for rows.Next() {
 		var item BLPosition
-		err := rows.Scan(&item.ID, &item.Name, &item.IsActive, &item.UserID)
+		err := rows.Scan(&item.ID, &item.ParentID, &item.Name, &item.Path, &item.Depth, &item.LocationType,
+			&item.CapacityPallets, &item.CapacityCBM, &item.IsActive, &item.UserID, &item.ChildCount, &item.BLCount)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		list = append(list, item)
 	}
-
-	return list, total, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sortBLPositionTree(list, false), nil
 }
 
+// ListAll returns the locations that can be picked: active ones whose
+// ancestors are all active, in tree order.
 func (r *BLPosition) ListAll(ctx context.Context) ([]BLPosition, error) {
 	rows, err := DB.Query(ctx,
-		`SELECT id, name
-                FROM bl_positions
-                WHERE is_active = true
-                ORDER BY id DESC`)
+		`SELECT id, parent_id, name, path, depth, location_type, is_active
+                FROM bl_positions`)
 	if err != nil {
 		return nil, err
 	}
@@ -61,23 +142,65 @@ func (r *BLPosition) ListAll(ctx context.Context) ([]BLPosition, error) {
 	var list []BLPosition
 	for rows.Next() {
 		var item BLPosition
-		err := rows.Scan(&item.ID, &item.Name)
+		err := rows.Scan(&item.ID, &item.ParentID, &item.Name, &item.Path, &item.Depth, &item.LocationType, &item.IsActive)
 		if err != nil {
 			return nil, err
 		}
 		list = append(list, item)
 	}
-	return list, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sortBLPositionTree(list, true), nil
+}
+
+// sortBLPositionTree orders nodes depth first with siblings by name. With
+// activeOnly, inactive nodes are dropped together with their subtrees.
+func sortBLPositionTree(list []BLPosition, activeOnly bool) []BLPosition {
+	children := make(map[int64][]int)
+	for i, item := range list {
+		var parentID int64
+		if item.ParentID != nil {
+			parentID = *item.ParentID
+		}
+		children[parentID] = append(children[parentID], i)
+	}
+	for _, idx := range children {
+		sort.Slice(idx, func(a, b int) bool {
+			return strings.ToLower(list[idx[a]].Name) < strings.ToLower(list[idx[b]].Name)
+		})
+	}
+
+	sorted := make([]BLPosition, 0, len(list))
+	var walk func(parentID int64)
+	walk = func(parentID int64) {
+		for _, i := range children[parentID] {
+			if activeOnly && !list[i].IsActive {
+				continue
+			}
+			sorted = append(sorted, list[i])
+			walk(list[i].ID)
+		}
+	}
+	walk(0)
+	return sorted
 }
 
 func (r *BLPosition) GetByID(ctx context.Context, id int64) (*BLPosition, error) {
 	var item BLPosition
 	err := DB.QueryRow(ctx,
-		`SELECT id, name, is_active, user_id, created_at, updated_at
+		`SELECT id, parent_id, name, path, depth, location_type, capacity_pallets, capacity_cbm,
+                is_active, user_id, created_at, updated_at
                 FROM bl_positions WHERE id = $1`, id).
 		Scan(
 			&item.ID,
+			&item.ParentID,
 			&item.Name,
+			&item.Path,
+			&item.Depth,
+			&item.LocationType,
+			&item.CapacityPallets,
+			&item.CapacityCBM,
 			&item.IsActive,
 			&item.UserID,
 			&item.CreatedAt,
@@ -89,59 +212,152 @@ func (r *BLPosition) GetByID(ctx context.Context, id int64) (*BLPosition, error)
 	return &item, nil
 }
 
+// placeUnder sets Path and Depth from the parent and rejects a parent that
+// is the node itself or one of its descendants.
+func (r *BLPosition) placeUnder(ctx context.Context, tx pgx.Tx) error {
+	if r.ParentID == nil {
+		r.Path = r.Name
+		r.Depth = 0
+		return nil
+	}
+	if r.ID > 0 {
+		var cycle bool
+		err := tx.QueryRow(ctx,
+			`WITH RECURSIVE subtree AS (
+			     SELECT id FROM bl_positions WHERE id = $1
+			     UNION ALL
+			     SELECT c.id FROM bl_positions c JOIN subtree s ON c.parent_id = s.id
+			 )
+			 SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)`,
+			r.ID, *r.ParentID).Scan(&cycle)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrBLPositionParentCycle
+		}
+	}
+	var parentPath string
+	var parentDepth int
+	if err := tx.QueryRow(ctx, `SELECT path, depth FROM bl_positions WHERE id = $1`, *r.ParentID).
+		Scan(&parentPath, &parentDepth); err != nil {
+		return err
+	}
+	r.Path = parentPath + BLPositionPathSeparator + r.Name
+	r.Depth = parentDepth + 1
+	return nil
+}
+
 func (r *BLPosition) Create(ctx context.Context) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.placeUnder(ctx, tx); err != nil {
+		return err
+	}
 	r.CreatedAt = time.Now()
 	r.UpdatedAt = time.Now()
 	r.IsActive = true
-	_, err := DB.Exec(ctx,
+	err = tx.QueryRow(ctx,
 		`INSERT INTO bl_positions
-                 (name, is_active, created_at, updated_at, user_id)
-                 VALUES ($1, $2, $3, $4, $5)`,
+                 (parent_id, name, path, depth, location_type, capacity_pallets, capacity_cbm,
+                  is_active, created_at, updated_at, user_id)
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+                 RETURNING id`,
+		r.ParentID,
 		r.Name,
+		r.Path,
+		r.Depth,
+		r.LocationType,
+		r.CapacityPallets,
+		r.CapacityCBM,
 		r.IsActive,
 		r.CreatedAt,
 		r.UpdatedAt,
 		r.UserID,
-	)
-	return err
+	).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
+// Update saves the node and rewrites the path of its whole subtree, since a
+// rename or move changes every descendant's full name.
 func (r *BLPosition) Update(ctx context.Context) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.placeUnder(ctx, tx); err != nil {
+		return err
+	}
 	r.UpdatedAt = time.Now()
-	_, err := DB.Exec(ctx,
+	_, err = tx.Exec(ctx,
 		`UPDATE bl_positions SET
-                 name = $1,
-                 is_active = $2,
-                 updated_at = $3,
-                 user_id = $4
-                 WHERE id = $5`,
+                 parent_id = $1,
+                 name = $2,
+                 path = $3,
+                 depth = $4,
+                 location_type = $5,
+                 capacity_pallets = $6,
+                 capacity_cbm = $7,
+                 is_active = $8,
+                 updated_at = $9,
+                 user_id = $10
+                 WHERE id = $11`,
+		r.ParentID,
 		r.Name,
+		r.Path,
+		r.Depth,
+		r.LocationType,
+		r.CapacityPallets,
+		r.CapacityCBM,
 		r.IsActive,
 		r.UpdatedAt,
 		r.UserID,
 		r.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`WITH RECURSIVE tree AS (
+		     SELECT c.id, $2::text || $3::text || c.name AS path, $4::int + 1 AS depth
+		       FROM bl_positions c WHERE c.parent_id = $1
+		     UNION ALL
+		     SELECT c.id, t.path || $3::text || c.name, t.depth + 1
+		       FROM bl_positions c JOIN tree t ON c.parent_id = t.id
+		 )
+		 UPDATE bl_positions b SET path = tree.path, depth = tree.depth
+		   FROM tree WHERE b.id = tree.id`,
+		r.ID, r.Path, BLPositionPathSeparator, r.Depth)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-func (r *BLPosition) ExistsByName(ctx context.Context, name string, excludeID *int64) (bool, error) {
+// ExistsByName reports whether a sibling under parentID already uses name.
+func (r *BLPosition) ExistsByName(ctx context.Context, name string, parentID *int64, excludeID *int64) (bool, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return false, nil
 	}
-	var count int
+	var exclude int64
 	if excludeID != nil {
-		err := DB.QueryRow(ctx,
-			`SELECT count(*) FROM bl_positions WHERE lower(name) = lower($1) AND id <> $2`,
-			name, *excludeID).Scan(&count)
-		if err != nil {
-			return false, err
-		}
-		return count > 0, nil
+		exclude = *excludeID
 	}
+	var count int
 	err := DB.QueryRow(ctx,
-		`SELECT count(*) FROM bl_positions WHERE lower(name) = lower($1)`,
-		name).Scan(&count)
+		`SELECT count(*) FROM bl_positions
+		  WHERE COALESCE(parent_id, 0) = COALESCE($2, 0) AND lower(name) = lower($1) AND id <> $3`,
+		name, parentID, exclude).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -149,6 +365,13 @@ func (r *BLPosition) ExistsByName(ctx context.Context, name string, excludeID *i
 }
 
 func (r *BLPosition) Delete(ctx context.Context, id int64) error {
+	var hasChildren bool
+	if err := DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bl_positions WHERE parent_id = $1)`, id).Scan(&hasChildren); err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrBLPositionHasChildren
+	}
 	_, err := DB.Exec(ctx, "DELETE FROM bl_positions WHERE id = $1", id)
 	return err
 }
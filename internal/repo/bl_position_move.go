@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// BLPositionMoveSource is where a position change came from.
+type BLPositionMoveSource string
+
+const (
+	BLMoveMobileScan     BLPositionMoveSource = "mobile_scan"
+	BLMoveAdmin          BLPositionMoveSource = "admin"
+	BLMoveImport         BLPositionMoveSource = "import"
+	BLMoveImportRollback BLPositionMoveSource = "import_rollback"
+)
+
+func (s BLPositionMoveSource) Label() string {
+	switch s {
+	case BLMoveMobileScan:
+		return "모바일 스캔"
+	case BLMoveAdmin:
+		return "관리자 수정"
+	case BLMoveImport:
+		return "엑셀 업로드"
+	case BLMoveImportRollback:
+		return "업로드 취소"
+	default:
+		return string(s)
+	}
+}
+
+// BLPositionMover is who moved a BL and from which device.
+type BLPositionMover struct {
+	UserID int64
+	Device string
+	Source BLPositionMoveSource
+}
+
+// BLPositionMove is one position change of a BL. The paths are copied at
+// move time so the history still reads right after locations are renamed
+// or deleted.
+type BLPositionMove struct {
+	ID             int64
+	BLMarkingID    int64
+	FromPositionID *int64
+	ToPositionID   *int64
+	FromPath       string
+	ToPath         string
+	Source         BLPositionMoveSource
+	UserID         *int64
+	UserName       string
+	Device         string
+	MovedAt        time.Time
+}
+
+// DeviceLabel shortens the stored User-Agent to the kind of device.
+func (m BLPositionMove) DeviceLabel() string {
+	ua := m.Device
+	switch {
+	case ua == "":
+		return ""
+	case strings.Contains(ua, "iPhone"):
+		return "iPhone"
+	case strings.Contains(ua, "iPad"):
+		return "iPad"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Macintosh"):
+		return "Mac"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	default:
+		return "기타"
+	}
+}
+
+// recordBLPositionMove logs a position change; nothing is written when the
+// position stays the same.
+func recordBLPositionMove(ctx context.Context, db execer, blMarkingID int64, from, to *int64, mover BLPositionMover) error {
+	if sameInt64(from, to) {
+		return nil
+	}
+	var userID *int64
+	if mover.UserID > 0 {
+		userID = &mover.UserID
+	}
+	device := mover.Device
+	if len([]rune(device)) > 255 {
+		device = string([]rune(device)[:255])
+	}
+	_, err := db.Exec(ctx,
+		`INSERT INTO bl_position_moves
+		 (bl_marking_id, from_position_id, to_position_id, from_path, to_path, source, user_id, device, moved_at)
+		 VALUES ($1, $2, $3,
+		         COALESCE((SELECT path FROM bl_positions WHERE id = $2), ''),
+		         COALESCE((SELECT path FROM bl_positions WHERE id = $3), ''),
+		         $4, $5, $6, $7)`,
+		blMarkingID, from, to, mover.Source, userID, device, time.Now())
+	return err
+}
+
+// ListByBLMarkingID returns the moves of a BL, latest first.
+func (r *BLPositionMove) ListByBLMarkingID(ctx context.Context, blMarkingID int64) ([]BLPositionMove, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT m.id, m.bl_marking_id, m.from_position_id, m.to_position_id, m.from_path, m.to_path, m.source,
+		        m.user_id, COALESCE(u.name, ''), m.device, m.moved_at
+		   FROM bl_position_moves m
+		   LEFT JOIN users u ON u.id = m.user_id
+		  WHERE m.bl_marking_id = $1
+		  ORDER BY m.moved_at DESC, m.id DESC`, blMarkingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []BLPositionMove
+	for rows.Next() {
+		var item BLPositionMove
+		if err := rows.Scan(&item.ID, &item.BLMarkingID, &item.FromPositionID, &item.ToPositionID, &item.FromPath, &item.ToPath,
+			&item.Source, &item.UserID, &item.UserName, &item.Device, &item.MovedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BLUnipassCandidate is one of several cargo records UNIPASS returned for an
+// HBL that could not be matched automatically.
+type BLUnipassCandidate struct {
+	BLMarkingID    int64
+	CargMtNo       string
+	MblNo          string
+	CntrNo         string
+	ArrivalDate    *time.Time
+	VesselName     string
+	CustomsStatus  string
+	ProgressStatus string
+	CreatedAt      time.Time
+}
+
+func (r *BLUnipassCandidate) ListByBLMarkingID(ctx context.Context, blMarkingID int64) ([]BLUnipassCandidate, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT bl_marking_id, carg_mt_no, COALESCE(mbl_no, ''), COALESCE(cntr_no, ''), arrival_date,
+		        COALESCE(vessel_name, ''), COALESCE(customs_status, ''), COALESCE(progress_status, ''), created_at
+		   FROM bl_unipass_candidates
+		  WHERE bl_marking_id = $1
+		  ORDER BY arrival_date DESC NULLS LAST, carg_mt_no ASC`, blMarkingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []BLUnipassCandidate
+	for rows.Next() {
+		var item BLUnipassCandidate
+		if err := rows.Scan(
+			&item.BLMarkingID,
+			&item.CargMtNo,
+			&item.MblNo,
+			&item.CntrNo,
+			&item.ArrivalDate,
+			&item.VesselName,
+			&item.CustomsStatus,
+			&item.ProgressStatus,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func replaceBLUnipassCandidates(ctx context.Context, tx pgx.Tx, blMarkingID int64, items []BLUnipassCandidate, now time.Time) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM bl_unipass_candidates WHERE bl_marking_id = $1`, blMarkingID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO bl_unipass_candidates
+			 (bl_marking_id, carg_mt_no, mbl_no, cntr_no, arrival_date, vessel_name, customs_status, progress_status, created_at)
+			 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9)
+			 ON CONFLICT (bl_marking_id, carg_mt_no) DO NOTHING`,
+			blMarkingID,
+			item.CargMtNo,
+			item.MblNo,
+			item.CntrNo,
+			item.ArrivalDate,
+			item.VesselName,
+			item.CustomsStatus,
+			item.ProgressStatus,
+			now,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
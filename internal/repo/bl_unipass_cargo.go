@@ -0,0 +1,136 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type BLUnipassCargo struct {
+	BLMarkingID    int64
+	CargMtNo       string
+	MblNo          string
+	ArrivalDate    *time.Time
+	VesselName     string
+	Weight         *float64
+	WeightUnit     string
+	PackageCount   *int
+	PackageUnit    string
+	CustomsStatus  string
+	ProgressStatus string
+	ProcessedAt    *time.Time
+	FetchedAt      time.Time
+}
+
+func (r *BLUnipassCargo) GetByBLMarkingID(ctx context.Context, blMarkingID int64) (*BLUnipassCargo, error) {
+	var item BLUnipassCargo
+	err := DB.QueryRow(ctx,
+		`SELECT bl_marking_id, COALESCE(carg_mt_no, ''), COALESCE(mbl_no, ''), arrival_date, COALESCE(vessel_name, ''),
+		        weight::float8, COALESCE(weight_unit, ''), package_count, COALESCE(package_unit, ''),
+		        COALESCE(customs_status, ''), COALESCE(progress_status, ''), processed_at, fetched_at
+		   FROM bl_unipass_cargo
+		  WHERE bl_marking_id = $1`, blMarkingID).
+		Scan(
+			&item.BLMarkingID,
+			&item.CargMtNo,
+			&item.MblNo,
+			&item.ArrivalDate,
+			&item.VesselName,
+			&item.Weight,
+			&item.WeightUnit,
+			&item.PackageCount,
+			&item.PackageUnit,
+			&item.CustomsStatus,
+			&item.ProgressStatus,
+			&item.ProcessedAt,
+			&item.FetchedAt,
+		)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListCustomsStatuses returns the distinct customs statuses seen so far, for
+// the list screen filter.
+func (r *BLUnipassCargo) ListCustomsStatuses(ctx context.Context) ([]string, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT DISTINCT customs_status
+		   FROM bl_unipass_cargo
+		  WHERE customs_status IS NOT NULL AND customs_status <> ''
+		  ORDER BY customs_status ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// ContainerMBLNo returns the MBL most other BLs on the same container have
+// resolved to, or "" when none has. It helps pick the right record when
+// UNIPASS returns several for an HBL.
+func (r *BLUnipassCargo) ContainerMBLNo(ctx context.Context, blMarkingID int64) (string, error) {
+	var mblNo string
+	err := DB.QueryRow(ctx,
+		`SELECT uc.mbl_no
+		   FROM bl_markings b
+		   JOIN bl_markings o ON o.container_id = b.container_id AND o.id <> b.id
+		   JOIN bl_unipass_cargo uc ON uc.bl_marking_id = o.id
+		  WHERE b.id = $1 AND uc.mbl_no IS NOT NULL
+		  GROUP BY uc.mbl_no
+		  ORDER BY count(*) DESC, uc.mbl_no ASC
+		  LIMIT 1`, blMarkingID).
+		Scan(&mblNo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return mblNo, err
+}
+
+func upsertBLUnipassCargo(ctx context.Context, tx pgx.Tx, item *BLUnipassCargo) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO bl_unipass_cargo
+		 (bl_marking_id, carg_mt_no, mbl_no, arrival_date, vessel_name, weight, weight_unit,
+		  package_count, package_unit, customs_status, progress_status, processed_at, fetched_at)
+		 VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, ''),
+		         $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12, $13)
+		 ON CONFLICT (bl_marking_id) DO UPDATE SET
+		 carg_mt_no = EXCLUDED.carg_mt_no,
+		 mbl_no = EXCLUDED.mbl_no,
+		 arrival_date = EXCLUDED.arrival_date,
+		 vessel_name = EXCLUDED.vessel_name,
+		 weight = EXCLUDED.weight,
+		 weight_unit = EXCLUDED.weight_unit,
+		 package_count = EXCLUDED.package_count,
+		 package_unit = EXCLUDED.package_unit,
+		 customs_status = EXCLUDED.customs_status,
+		 progress_status = EXCLUDED.progress_status,
+		 processed_at = EXCLUDED.processed_at,
+		 fetched_at = EXCLUDED.fetched_at`,
+		item.BLMarkingID,
+		item.CargMtNo,
+		item.MblNo,
+		item.ArrivalDate,
+		item.VesselName,
+		item.Weight,
+		item.WeightUnit,
+		item.PackageCount,
+		item.PackageUnit,
+		item.CustomsStatus,
+		item.ProgressStatus,
+		item.ProcessedAt,
+		item.FetchedAt,
+	)
+	return err
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type BLUnipassEvent struct {
+	ID             int64
+	BLMarkingID    int64
+	CustomsStatus  string
+	ProgressStatus string
+	ProcessedAt    *time.Time
+	CreatedAt      time.Time
+}
+
+// ListByBLMarkingID returns the status history of a BL, oldest first.
+func (r *BLUnipassEvent) ListByBLMarkingID(ctx context.Context, blMarkingID int64) ([]BLUnipassEvent, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT id, bl_marking_id, COALESCE(customs_status, ''), COALESCE(progress_status, ''), processed_at, created_at
+		   FROM bl_unipass_events
+		  WHERE bl_marking_id = $1
+		  ORDER BY created_at ASC, id ASC`, blMarkingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []BLUnipassEvent
+	for rows.Next() {
+		var item BLUnipassEvent
+		if err := rows.Scan(
+			&item.ID,
+			&item.BLMarkingID,
+			&item.CustomsStatus,
+			&item.ProgressStatus,
+			&item.ProcessedAt,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// recordBLUnipassEvent appends a history row when the customs or progress
+// status differs from what was stored before this fetch.
+func recordBLUnipassEvent(ctx context.Context, tx pgx.Tx, item *BLUnipassCargo) error {
+	var customsStatus, progressStatus string
+	err := tx.QueryRow(ctx,
+		`SELECT COALESCE(customs_status, ''), COALESCE(progress_status, '')
+		   FROM bl_unipass_cargo
+		  WHERE bl_marking_id = $1
+		  FOR UPDATE`, item.BLMarkingID).
+		Scan(&customsStatus, &progressStatus)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if customsStatus == item.CustomsStatus && progressStatus == item.ProgressStatus {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO bl_unipass_events
+		 (bl_marking_id, customs_status, progress_status, processed_at, created_at)
+		 VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5)`,
+		item.BLMarkingID,
+		item.CustomsStatus,
+		item.ProgressStatus,
+		item.ProcessedAt,
+		item.FetchedAt,
+	)
+	return err
+}
@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CargoCardTemplate is a supplier's cargo card layout. Suppliers without a
+// row print the default card.
+type CargoCardTemplate struct {
+	ID              int64
+	SupplierID      int64
+	Title           string
+	Fields          string
+	ExtraFields     string
+	ShowOverlay     bool
+	HasLogo         bool
+	Logo            []byte
+	LogoContentType string
+	UpdatedBy       int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+const cargoCardTemplateSelect = `SELECT id, supplier_id, title, fields, extra_fields, show_overlay,
+	logo IS NOT NULL, COALESCE(logo, ''::bytea), logo_content_type, created_at, updated_at
+	FROM cargo_card_templates`
+
+func scanCargoCardTemplate(row pgx.Row) (*CargoCardTemplate, error) {
+	var item CargoCardTemplate
+	if err := row.Scan(&item.ID, &item.SupplierID, &item.Title, &item.Fields, &item.ExtraFields, &item.ShowOverlay,
+		&item.HasLogo, &item.Logo, &item.LogoContentType, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetBySupplier returns nil without an error when the supplier uses the
+// default card.
+func (r *CargoCardTemplate) GetBySupplier(ctx context.Context, supplierID int64) (*CargoCardTemplate, error) {
+	item, err := scanCargoCardTemplate(DB.QueryRow(ctx, cargoCardTemplateSelect+" WHERE supplier_id = $1", supplierID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *CargoCardTemplate) ListBySupplierIDs(ctx context.Context, supplierIDs []int64) ([]CargoCardTemplate, error) {
+	if len(supplierIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := DB.Query(ctx, cargoCardTemplateSelect+" WHERE supplier_id = ANY($1)", supplierIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []CargoCardTemplate
+	for rows.Next() {
+		item, err := scanCargoCardTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *item)
+	}
+	return list, rows.Err()
+}
+
+// Save creates or replaces the supplier's template. The stored logo is kept
+// unless replaceLogo is set, in which case r.Logo (possibly empty) replaces it.
+func (r *CargoCardTemplate) Save(ctx context.Context, replaceLogo bool) error {
+	now := time.Now()
+	var logo []byte
+	if len(r.Logo) > 0 {
+		logo = r.Logo
+	}
+	_, err := DB.Exec(ctx,
+		`INSERT INTO cargo_card_templates (supplier_id, title, fields, extra_fields, show_overlay, logo, logo_content_type, updated_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		 ON CONFLICT (supplier_id) DO UPDATE SET
+		   title = EXCLUDED.title,
+		   fields = EXCLUDED.fields,
+		   extra_fields = EXCLUDED.extra_fields,
+		   show_overlay = EXCLUDED.show_overlay,
+		   logo = CASE WHEN $10 THEN EXCLUDED.logo ELSE cargo_card_templates.logo END,
+		   logo_content_type = CASE WHEN $10 THEN EXCLUDED.logo_content_type ELSE cargo_card_templates.logo_content_type END,
+		   updated_by = EXCLUDED.updated_by,
+		   updated_at = EXCLUDED.updated_at`,
+		r.SupplierID, r.Title, r.Fields, r.ExtraFields, r.ShowOverlay, logo, r.LogoContentType, r.UpdatedBy, now, replaceLogo)
+	return err
+}
+
+// DeleteBySupplier returns the supplier to the default card.
+func (r *CargoCardTemplate) DeleteBySupplier(ctx context.Context, supplierID int64) error {
+	_, err := DB.Exec(ctx, "DELETE FROM cargo_card_templates WHERE supplier_id = $1", supplierID)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"skycontainers/internal/iso6346"
 	"skycontainers/internal/pagination"
 	"strings"
 	"time"
@@ -18,6 +19,7 @@ type Container struct {
 	ContainerTypeName     string
 	ContainerNo           string
 	ContainerStatus       string
+	State                 ContainerState
 	SupplierID            int64
 	SupplierName          string
 	BookingNo             string
@@ -29,8 +31,12 @@ type Container struct {
 	OutboundDate          *time.Time
 	ProcessingCancelledAt *time.Time
 	ProcessingCancelledBy int64
-	CreatedAt             time.Time
-	UpdatedAt             time.Time
+	// CancelledByName and CancelReason describe the last undo, for the I/O
+	// management list.
+	CancelledByName string
+	CancelReason    string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 var ErrContainerUnavailable = errors.New("등록되지 않았거나 이미 출고된 컨테이너입니다.")
@@ -38,18 +44,28 @@ var ErrContainerUnavailable = errors.New("등록되지 않았거나 이미 출
 var ErrInvalidIOStage = errors.New("invalid io stage")
 
 func (r *Container) ListForIOManagement(ctx context.Context, p pagination.Pager, stage string) ([]Container, int, error) {
-	whereClause := "c.outbound_date IS NULL"
-	switch strings.ToLower(strings.TrimSpace(stage)) {
+	stage = strings.ToLower(strings.TrimSpace(stage))
+	var whereClause string
+	switch stage {
 	case "", "inbound":
-		whereClause = whereClause + " AND c.inbound_date IS NULL AND c.processing_date IS NULL"
+		whereClause = "c.state = 'announced'"
 	case "work", "processing":
-		whereClause = whereClause + " AND c.inbound_date IS NOT NULL AND c.processing_date IS NULL"
+		whereClause = "c.state IN ('gated_in', 'devanning')"
 	case "outbound":
-		whereClause = whereClause + " AND c.inbound_date IS NOT NULL AND c.processing_date IS NOT NULL"
+		whereClause = "c.state = 'devanned'"
+	case "done":
+		whereClause = "c.state = 'gated_out'"
 	default:
 		return nil, 0, ErrInvalidIOStage
 	}
 
+	// Finished containers are listed by the latest gate-out so a wrong one
+	// is easy to find and undo.
+	order := "c.id DESC"
+	if stage == "done" {
+		order = "c.outbound_date DESC NULLS LAST, c.updated_at DESC"
+	}
+
 	var total int
 	countQuery := "SELECT count(*) FROM containers c WHERE " + whereClause
 	if err := DB.QueryRow(ctx, countQuery).Scan(&total); err != nil {
@@ -60,12 +76,17 @@ func (r *Container) ListForIOManagement(ctx context.Context, p pagination.Pager,
 		`SELECT c.id, c.container_no, c.container_status, c.supplier_id,
                         c.booking_no, c.memo, c.car_no,
                         c.inbound_date, c.processing_date, c.outbound_date,
-                        ct.code, ct.name, s.name, c.user_id
+                        ct.code, ct.name, s.name, c.user_id, c.state,
+                        c.processing_cancelled_at, COALESCE(cu.name, ''),
+                        COALESCE((SELECT e.reason FROM container_events e
+                                   WHERE e.container_id = c.id AND e.reason <> ''
+                                   ORDER BY e.id DESC LIMIT 1), '')
                  FROM containers c
                  LEFT JOIN container_types ct ON ct.id = c.containers_type_id
                  LEFT JOIN suppliers s ON s.id = c.supplier_id
+                 LEFT JOIN users cu ON cu.id = c.processing_cancelled_by AND c.processing_cancelled_at IS NOT NULL
                  WHERE `+whereClause+`
-                 ORDER BY c.id DESC
+                 ORDER BY `+order+`
                  LIMIT $1 OFFSET $2`, p.PageSize, p.Offset())
 	if err != nil {
 		return nil, 0, err
@@ -90,6 +111,10 @@ func (r *Container) ListForIOManagement(ctx context.Context, p pagination.Pager,
 			&item.ContainerTypeName,
 			&item.SupplierName,
 			&item.UserID,
+			&item.State,
+			&item.ProcessingCancelledAt,
+			&item.CancelledByName,
+			&item.CancelReason,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -98,45 +123,16 @@ func (r *Container) ListForIOManagement(ctx context.Context, p pagination.Pager,
 	return list, total, nil
 }
 
-func (r *Container) MarkInboundToday(ctx context.Context, id int64) error {
-	tag, err := DB.Exec(ctx, `UPDATE containers
-                SET inbound_date = CURRENT_DATE, updated_at = NOW()
-                WHERE id = $1 AND outbound_date IS NULL AND inbound_date IS NULL AND processing_date IS NULL`, id)
-	if err != nil {
-		return err
-	}
-	if tag.RowsAffected() == 0 {
-		return ErrContainerUnavailable
-	}
-	return nil
+func (r *Container) MarkInboundToday(ctx context.Context, id int64, userID int64, gate *GateTransaction) error {
+	return r.GateTransition(ctx, id, TransitionGateIn, time.Now(), userID, gate)
 }
 
-func (r *Container) MarkProcessingToday(ctx context.Context, id int64) error {
-	tag, err := DB.Exec(ctx, `UPDATE containers
-                SET processing_date = CURRENT_DATE, updated_at = NOW()
-                WHERE id = $1 AND outbound_date IS NULL
-                  AND inbound_date IS NOT NULL AND processing_date IS NULL`, id)
-	if err != nil {
-		return err
-	}
-	if tag.RowsAffected() == 0 {
-		return ErrContainerUnavailable
-	}
-	return nil
+func (r *Container) MarkProcessingToday(ctx context.Context, id int64, userID int64) error {
+	return r.Transition(ctx, id, TransitionDevan, time.Now(), userID, "")
 }
 
-func (r *Container) MarkOutboundToday(ctx context.Context, id int64) error {
-	tag, err := DB.Exec(ctx, `UPDATE containers
-                SET outbound_date = CURRENT_DATE, updated_at = NOW()
-                WHERE id = $1 AND outbound_date IS NULL
-                  AND inbound_date IS NOT NULL AND processing_date IS NOT NULL`, id)
-	if err != nil {
-		return err
-	}
-	if tag.RowsAffected() == 0 {
-		return ErrContainerUnavailable
-	}
-	return nil
+func (r *Container) MarkOutboundToday(ctx context.Context, id int64, userID int64, gate *GateTransaction) error {
+	return r.GateTransition(ctx, id, TransitionGateOut, time.Now(), userID, gate)
 }
 
 func buildContainerFilter(containerNo string, supplierID int64, inboundStart *time.Time, inboundEnd *time.Time, processingStart *time.Time, processingEnd *time.Time, outboundStart *time.Time, outboundEnd *time.Time) (string, []interface{}) {
@@ -192,7 +188,7 @@ func (r *Container) List(ctx context.Context, p pagination.Pager, containerNo st
 	offsetIndex := len(args) + 2
 	rows, err := DB.Query(ctx,
 		fmt.Sprintf(`SELECT c.id, c.container_no, c.container_status, c.supplier_id, c.inbound_date, c.processing_date, c.outbound_date, c.car_no,      
-                        ct.code, ct.name, s.name, c.user_id
+                        ct.code, ct.name, s.name, c.user_id, c.state
                  FROM containers c
                  LEFT JOIN container_types ct ON ct.id = c.containers_type_id   
                  LEFT JOIN suppliers s ON s.id = c.supplier_id
@@ -221,6 +217,7 @@ func (r *Container) List(ctx context.Context, p pagination.Pager, containerNo st
 			&item.ContainerTypeName,
 			&item.SupplierName,
 			&item.UserID,
+			&item.State,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -362,17 +359,22 @@ func (r *Container) ListAvailableForBLMarking(ctx context.Context) ([]Container,
 	return list, nil
 }
 
+// FindAvailableByNo rejects numbers that fail ISO 6346 before looking them
+// up, and matches the canonical number that Create and Update store. Legacy
+// rows saved with spaces or lowercase letters are found once
+// NormalizeNumbers has rewritten them.
 func (r *Container) FindAvailableByNo(ctx context.Context, containerNo string) (*Container, error) {
-	number := strings.TrimSpace(containerNo)
-	if number == "" {
-		return nil, errors.New("컨테이너 번호를 입력해 주세요.")
+	number, err := iso6346.Parse(containerNo)
+	if err != nil {
+		return nil, err
 	}
 
 	var item Container
-	err := DB.QueryRow(ctx,
+	err = DB.QueryRow(ctx,
 		`SELECT id, container_no
 		 FROM containers
-		 WHERE outbound_date IS NULL AND lower(container_no) = lower($1)
+		 WHERE outbound_date IS NULL AND container_no = $1
+		 ORDER BY id DESC
 		 LIMIT 1`, number).
 		Scan(&item.ID, &item.ContainerNo)
 	if err != nil {
@@ -410,7 +412,7 @@ func (r *Container) GetByID(ctx context.Context, id int64) (*Container, error) {
 	err := DB.QueryRow(ctx,
 		`SELECT id, containers_type_id, container_no, container_status, supplier_id, booking_no, car_no,
 		        memo, user_id, inbound_date, processing_date, outbound_date, processing_cancelled_at,
-		        processing_cancelled_by, created_at, updated_at
+		        processing_cancelled_by, state, created_at, updated_at
 		FROM containers WHERE id = $1`, id).
 		Scan(
 			&item.ID,
@@ -427,6 +429,7 @@ func (r *Container) GetByID(ctx context.Context, id int64) (*Container, error) {
 			&item.OutboundDate,
 			&item.ProcessingCancelledAt,
 			&item.ProcessingCancelledBy,
+			&item.State,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		)
@@ -436,17 +439,114 @@ func (r *Container) GetByID(ctx context.Context, id int64) (*Container, error) {
 	return &item, nil
 }
 
+// ContainerNumberIssue is a stored container number that is not in canonical
+// ISO 6346 form. Reason is empty when only spacing or case differ.
+type ContainerNumberIssue struct {
+	ID           int64
+	ContainerNo  string
+	Normalized   string
+	Reason       string
+	Suggestion   string
+	SupplierName string
+	InboundDate  *time.Time
+	OutboundDate *time.Time
+}
+
+// ListNumberIssues checks every stored container number. The check digit is
+// computed here, so the whole table is scanned.
+func (r *Container) ListNumberIssues(ctx context.Context) ([]ContainerNumberIssue, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT c.id, c.container_no, COALESCE(s.name, ''), c.inbound_date, c.outbound_date
+		 FROM containers c
+		 LEFT JOIN suppliers s ON s.id = c.supplier_id
+		 ORDER BY c.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []ContainerNumberIssue
+	for rows.Next() {
+		var item ContainerNumberIssue
+		if err := rows.Scan(&item.ID, &item.ContainerNo, &item.SupplierName, &item.InboundDate, &item.OutboundDate); err != nil {
+			return nil, err
+		}
+		item.Normalized = iso6346.Normalize(item.ContainerNo)
+		if err := iso6346.Validate(item.Normalized); err != nil {
+			var invalid *iso6346.Error
+			if errors.As(err, &invalid) {
+				item.Reason = invalid.Reason
+				item.Suggestion = invalid.Suggestion
+			}
+		} else if item.Normalized == item.ContainerNo {
+			continue
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// NormalizeNumbers rewrites the stored numbers that only differ from their
+// canonical form in spacing or case. Invalid numbers are left for a person to
+// fix.
+func (r *Container) NormalizeNumbers(ctx context.Context) (int, error) {
+	issues, err := r.ListNumberIssues(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	now := time.Now()
+	for _, issue := range issues {
+		if issue.Reason != "" {
+			continue
+		}
+		if _, err := DB.Exec(ctx, "UPDATE containers SET container_no = $1, updated_at = $2 WHERE id = $3",
+			issue.Normalized, now, issue.ID); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// normalizeNumber stores the number in its canonical form and refuses numbers
+// that fail ISO 6346.
+func (r *Container) normalizeNumber() error {
+	number, err := iso6346.Parse(r.ContainerNo)
+	if err != nil {
+		return err
+	}
+	r.ContainerNo = number
+	return nil
+}
+
 func (r *Container) Create(ctx context.Context) error {
+	if err := r.normalizeNumber(); err != nil {
+		return err
+	}
+	state, err := containerStateFromDates(r.InboundDate, r.ProcessingDate, r.OutboundDate)
+	if err != nil {
+		return err
+	}
+	r.State = state
 	r.CreatedAt = time.Now()
 	r.UpdatedAt = time.Now()
-	_, err := DB.Exec(ctx,
+
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = tx.QueryRow(ctx,
 		`INSERT INTO containers
 		 (containers_type_id, container_no, container_status, supplier_id, booking_no, car_no,
 		  memo, user_id, inbound_date, processing_date, outbound_date, processing_cancelled_at,
-		  processing_cancelled_by, created_at, updated_at)
+		  processing_cancelled_by, state, created_at, updated_at)
 		 VALUES ($1, $2, $3, $4, $5, $6,
 		         $7, $8, $9, $10, $11, $12,
-		         $13, $14, $15)`,
+		         $13, $14, $15, $16)
+		 RETURNING id`,
 		r.ContainerTypeID,
 		r.ContainerNo,
 		r.ContainerStatus,
@@ -460,15 +560,47 @@ func (r *Container) Create(ctx context.Context) error {
 		r.OutboundDate,
 		r.ProcessingCancelledAt,
 		r.ProcessingCancelledBy,
+		r.State,
 		r.CreatedAt,
 		r.UpdatedAt,
-	)
-	return err
+	).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+	if err := recordContainerEvent(ctx, tx, r.ID, containerEventRegister, "", r.State, nil, "", r.UserID, r.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *Container) Update(ctx context.Context) error {
+	if err := r.normalizeNumber(); err != nil {
+		return err
+	}
+	state, err := containerStateFromDates(r.InboundDate, r.ProcessingDate, r.OutboundDate)
+	if err != nil {
+		return err
+	}
 	r.UpdatedAt = time.Now()
-	_, err := DB.Exec(ctx,
+
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// The form may correct recorded dates but not move the container to
+	// another stage; that goes through Transition so it is logged.
+	var current ContainerState
+	if err := tx.QueryRow(ctx, `SELECT state FROM containers WHERE id = $1 FOR UPDATE`, r.ID).Scan(&current); err != nil {
+		return err
+	}
+	if state != current && !(current == ContainerDevanning && state == ContainerGatedIn) {
+		return ErrContainerStateEdit
+	}
+	r.State = current
+
+	_, err = tx.Exec(ctx,
 		`UPDATE containers SET
 		 containers_type_id = $1,
 		 container_no = $2,
@@ -501,10 +633,53 @@ func (r *Container) Update(ctx context.Context) error {
 		r.UpdatedAt,
 		r.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *Container) Delete(ctx context.Context, id int64) error {
 	_, err := DB.Exec(ctx, "DELETE FROM containers WHERE id = $1", id)
 	return err
 }
+
+// ListForStorageCharges returns the containers that were in the yard on any
+// day of [start, end], optionally for one supplier.
+func (r *Container) ListForStorageCharges(ctx context.Context, start, end time.Time, supplierID int64) ([]Container, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT c.id, c.container_no, c.containers_type_id, COALESCE(ct.code, ''), c.supplier_id, COALESCE(s.name, ''),
+		        c.inbound_date, c.outbound_date
+		   FROM containers c
+		   LEFT JOIN container_types ct ON ct.id = c.containers_type_id
+		   LEFT JOIN suppliers s ON s.id = c.supplier_id
+		  WHERE c.inbound_date IS NOT NULL
+		    AND c.inbound_date <= $2
+		    AND (c.outbound_date IS NULL OR c.outbound_date >= $1)
+		    AND ($3 = 0 OR c.supplier_id = $3)
+		  ORDER BY s.name ASC, c.inbound_date ASC, c.container_no ASC`,
+		start, end, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Container
+	for rows.Next() {
+		var item Container
+		if err := rows.Scan(
+			&item.ID,
+			&item.ContainerNo,
+			&item.ContainerTypeID,
+			&item.ContainerTypeCode,
+			&item.SupplierID,
+			&item.SupplierName,
+			&item.InboundDate,
+			&item.OutboundDate,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
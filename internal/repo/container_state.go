@@ -0,0 +1,446 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skycontainers/internal/iso6346"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ContainerState is the lifecycle stage of a container in the yard.
+type ContainerState string
+
+const (
+	ContainerAnnounced ContainerState = "announced"
+	ContainerGatedIn   ContainerState = "gated_in"
+	ContainerDevanning ContainerState = "devanning"
+	ContainerDevanned  ContainerState = "devanned"
+	ContainerGatedOut  ContainerState = "gated_out"
+)
+
+func (s ContainerState) Label() string {
+	switch s {
+	case ContainerAnnounced:
+		return "입고예정"
+	case ContainerGatedIn:
+		return "입고"
+	case ContainerDevanning:
+		return "작업중"
+	case ContainerDevanned:
+		return "작업완료"
+	case ContainerGatedOut:
+		return "출고"
+	default:
+		return string(s)
+	}
+}
+
+// ContainerTransition names a move between states. It is stored as the event
+// of the container_events row.
+type ContainerTransition string
+
+const (
+	TransitionGateIn          ContainerTransition = "gate_in"
+	TransitionStartDevanning  ContainerTransition = "start_devanning"
+	TransitionDevan           ContainerTransition = "devan"
+	TransitionGateOut         ContainerTransition = "gate_out"
+	TransitionUndoGateIn      ContainerTransition = "undo_gate_in"
+	TransitionCancelDevanning ContainerTransition = "cancel_devanning"
+	TransitionUndoDevan       ContainerTransition = "undo_devan"
+	TransitionUndoGateOut     ContainerTransition = "undo_gate_out"
+
+	// containerEventRegister is logged when a container is created; it is not
+	// a transition anyone can request.
+	containerEventRegister ContainerTransition = "register"
+)
+
+type containerTransitionRule struct {
+	From  []ContainerState
+	To    ContainerState
+	Label string
+	// Column is the date column the transition sets, or clears when Undo is
+	// set. Empty for transitions without a date of their own.
+	Column string
+	Undo   bool
+}
+
+var containerTransitionRules = map[ContainerTransition]containerTransitionRule{
+	TransitionGateIn:          {From: []ContainerState{ContainerAnnounced}, To: ContainerGatedIn, Label: "입고", Column: "inbound_date"},
+	TransitionStartDevanning:  {From: []ContainerState{ContainerGatedIn}, To: ContainerDevanning, Label: "작업 시작"},
+	TransitionDevan:           {From: []ContainerState{ContainerGatedIn, ContainerDevanning}, To: ContainerDevanned, Label: "작업확인", Column: "processing_date"},
+	TransitionGateOut:         {From: []ContainerState{ContainerDevanned}, To: ContainerGatedOut, Label: "출고", Column: "outbound_date"},
+	TransitionUndoGateIn:      {From: []ContainerState{ContainerGatedIn}, To: ContainerAnnounced, Label: "입고 취소", Column: "inbound_date", Undo: true},
+	TransitionCancelDevanning: {From: []ContainerState{ContainerDevanning}, To: ContainerGatedIn, Label: "작업 시작 취소", Undo: true},
+	TransitionUndoDevan:       {From: []ContainerState{ContainerDevanned}, To: ContainerGatedIn, Label: "작업확인 취소", Column: "processing_date", Undo: true},
+	TransitionUndoGateOut:     {From: []ContainerState{ContainerGatedOut}, To: ContainerDevanned, Label: "출고 취소", Column: "outbound_date", Undo: true},
+}
+
+// IsUndo reports whether the transition reverses an earlier one. Undo
+// transitions need a reason.
+func (t ContainerTransition) IsUndo() bool {
+	return containerTransitionRules[t].Undo
+}
+
+func (t ContainerTransition) Label() string {
+	if t == containerEventRegister {
+		return "등록"
+	}
+	if rule, ok := containerTransitionRules[t]; ok {
+		return rule.Label
+	}
+	return string(t)
+}
+
+// ContainerStateError rejects a transition that is not allowed from the
+// container's current state.
+type ContainerStateError struct {
+	ContainerNo string
+	State       ContainerState
+	Transition  ContainerTransition
+}
+
+func (e *ContainerStateError) Error() string {
+	prefix := ""
+	if e.ContainerNo != "" {
+		prefix = e.ContainerNo + ": "
+	}
+	return fmt.Sprintf("%s%s 단계에서는 %s할 수 없습니다.", prefix, e.State.Label(), e.Transition.Label())
+}
+
+// IsContainerStateError reports whether err rejected a transition because of
+// the current state.
+func IsContainerStateError(err error) bool {
+	var target *ContainerStateError
+	return errors.As(err, &target)
+}
+
+var ErrContainerStateEdit = errors.New("입고/작업/출고 단계는 입출고관리에서 변경해 주세요. 수정에서는 이미 기록된 날짜만 고칠 수 있습니다.")
+
+var ErrContainerReasonRequired = errors.New("취소 사유를 입력해 주세요.")
+
+var ErrContainerDateOrder = errors.New("작업일은 입고일이, 출고일은 작업일이 있어야 입력할 수 있습니다.")
+
+// ContainerDateError rejects a transition dated before the stage it follows,
+// e.g. an outbound date earlier than the processing date.
+type ContainerDateError struct {
+	ContainerNo   string
+	Transition    ContainerTransition
+	Date          time.Time
+	PreviousLabel string
+	Previous      time.Time
+}
+
+func (e *ContainerDateError) Error() string {
+	prefix := ""
+	if e.ContainerNo != "" {
+		prefix = e.ContainerNo + ": "
+	}
+	return fmt.Sprintf("%s%s(%s)보다 이른 날짜(%s)로 %s할 수 없습니다.", prefix, e.PreviousLabel,
+		e.Previous.Format("2006-01-02"), e.Date.Format("2006-01-02"), e.Transition.Label())
+}
+
+// ContainerEvent is one row of a container's lifecycle history.
+type ContainerEvent struct {
+	ID          int64
+	ContainerID int64
+	Event       ContainerTransition
+	FromState   ContainerState
+	ToState     ContainerState
+	EventDate   *time.Time
+	Reason      string
+	UserID      *int64
+	UserName    string
+	CreatedAt   time.Time
+}
+
+// Transition moves a container to the next state and logs the event. date is
+// the inbound, processing or outbound date the transition records.
+func (r *Container) Transition(ctx context.Context, id int64, transition ContainerTransition, date time.Time, userID int64, reason string) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := applyContainerTransition(ctx, tx, id, transition, date, userID, reason); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GateTransition is Transition for gate_in and gate_out. When gate is set, it
+// is recorded for the container in the same transaction.
+func (r *Container) GateTransition(ctx context.Context, id int64, transition ContainerTransition, date time.Time, userID int64, gate *GateTransaction) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := applyContainerTransition(ctx, tx, id, transition, date, userID, ""); err != nil {
+		return err
+	}
+	if gate != nil {
+		if err := attachGateTransaction(ctx, tx, gate, transition, []int64{id}, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// attachGateTransaction records gate for the containers that just crossed the
+// gate. Seal numbers already on gate.Containers are kept by container ID.
+func attachGateTransaction(ctx context.Context, tx pgx.Tx, gate *GateTransaction, transition ContainerTransition, ids []int64, userID int64) error {
+	direction, ok := gateDirectionFor(transition)
+	if !ok {
+		return fmt.Errorf("%s은(는) 게이트를 통과하는 처리가 아닙니다", transition.Label())
+	}
+	seals := make(map[int64]string, len(gate.Containers))
+	for _, item := range gate.Containers {
+		seals[item.ContainerID] = item.SealNo
+	}
+	gate.Direction = direction
+	gate.Containers = make([]GateTransactionContainer, 0, len(ids))
+	for _, id := range ids {
+		gate.Containers = append(gate.Containers, GateTransactionContainer{ContainerID: id, SealNo: seals[id]})
+	}
+	return gate.insert(ctx, tx, userID)
+}
+
+// ContainerTransitionResult is the outcome of one container in a bulk
+// transition.
+type ContainerTransitionResult struct {
+	ID          int64
+	ContainerNo string
+	OK          bool
+	Message     string
+}
+
+// BulkTransition applies the same transition to every container in one
+// transaction. Containers in the wrong state, missing, rejected by allow, with
+// an invalid number on gate-in or a date before their previous stage are
+// reported and skipped; any other error rolls the whole batch back. When
+// gate is set, the containers that moved are recorded on it.
+func (r *Container) BulkTransition(ctx context.Context, ids []int64, transition ContainerTransition, date time.Time, userID int64, allow func(ownerID int64) bool, gate *GateTransaction) ([]ContainerTransitionResult, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	results := make([]ContainerTransitionResult, 0, len(ids))
+	for _, id := range ids {
+		result := ContainerTransitionResult{ID: id}
+		var ownerID int64
+		err := tx.QueryRow(ctx, `SELECT container_no, user_id FROM containers WHERE id = $1`, id).Scan(&result.ContainerNo, &ownerID)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return nil, err
+			}
+			result.Message = ErrContainerUnavailable.Error()
+			results = append(results, result)
+			continue
+		}
+		if allow != nil && !allow(ownerID) {
+			result.Message = "권한이 없습니다."
+			results = append(results, result)
+			continue
+		}
+
+		err = applyContainerTransition(ctx, tx, id, transition, date, userID, "")
+		var stateErr *ContainerStateError
+		var dateErr *ContainerDateError
+		var numberErr *iso6346.Error
+		switch {
+		case err == nil:
+			result.OK = true
+			result.Message = transition.Label() + " 완료"
+		case errors.As(err, &stateErr):
+			result.Message = fmt.Sprintf("%s 단계라 %s할 수 없습니다.", stateErr.State.Label(), transition.Label())
+		case errors.As(err, &dateErr):
+			result.Message = fmt.Sprintf("%s(%s)보다 이른 날짜로는 %s할 수 없습니다.", dateErr.PreviousLabel, dateErr.Previous.Format("2006-01-02"), transition.Label())
+		case errors.As(err, &numberErr):
+			result.Message = numberErr.Reason
+		default:
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if gate != nil {
+		var moved []int64
+		for _, result := range results {
+			if result.OK {
+				moved = append(moved, result.ID)
+			}
+		}
+		if len(moved) > 0 {
+			if err := attachGateTransaction(ctx, tx, gate, transition, moved, userID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// applyContainerTransition locks the container row, checks the transition
+// against its current state and writes the new state and event in tx. Undoing
+// a gate move also takes the container off its gate transaction. Gate-in
+// also requires a valid ISO 6346 number, and a dated transition may not fall
+// before the date of the stage it follows.
+func applyContainerTransition(ctx context.Context, tx pgx.Tx, id int64, transition ContainerTransition, date time.Time, userID int64, reason string) error {
+	rule, ok := containerTransitionRules[transition]
+	if !ok {
+		return fmt.Errorf("unknown container transition %q", transition)
+	}
+	reason = strings.TrimSpace(reason)
+	if rule.Undo && reason == "" {
+		return ErrContainerReasonRequired
+	}
+
+	var containerNo string
+	var state ContainerState
+	var inboundDate, processingDate *time.Time
+	err := tx.QueryRow(ctx,
+		`SELECT container_no, state, inbound_date, processing_date FROM containers WHERE id = $1 FOR UPDATE`, id).
+		Scan(&containerNo, &state, &inboundDate, &processingDate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrContainerUnavailable
+		}
+		return err
+	}
+	allowed := false
+	for _, from := range rule.From {
+		if from == state {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return &ContainerStateError{ContainerNo: containerNo, State: state, Transition: transition}
+	}
+	if transition == TransitionGateIn {
+		if err := iso6346.Validate(iso6346.Normalize(containerNo)); err != nil {
+			return err
+		}
+	}
+
+	var eventDate *time.Time
+	if !rule.Undo {
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+		eventDate = &day
+
+		previous, previousLabel := inboundDate, "입고일"
+		if rule.Column == "outbound_date" {
+			previous, previousLabel = processingDate, "작업일"
+		}
+		if rule.Column != "inbound_date" && previous != nil && day.Format("2006-01-02") < previous.Format("2006-01-02") {
+			return &ContainerDateError{
+				ContainerNo:   containerNo,
+				Transition:    transition,
+				Date:          day,
+				PreviousLabel: previousLabel,
+				Previous:      *previous,
+			}
+		}
+	}
+	now := time.Now()
+	sets := "state = $2, updated_at = $3"
+	args := []interface{}{id, rule.To, now}
+	if rule.Undo {
+		args = append(args, userID)
+		sets += fmt.Sprintf(", processing_cancelled_at = $3, processing_cancelled_by = $%d", len(args))
+	}
+	if rule.Column != "" {
+		args = append(args, eventDate)
+		sets += fmt.Sprintf(", %s = $%d", rule.Column, len(args))
+	}
+	_, err = tx.Exec(ctx, `UPDATE containers SET `+sets+` WHERE id = $1`, args...)
+	if err != nil {
+		return err
+	}
+	switch transition {
+	case TransitionUndoGateIn:
+		err = detachGateTransaction(ctx, tx, id, GateIn)
+	case TransitionUndoGateOut:
+		err = detachGateTransaction(ctx, tx, id, GateOut)
+	}
+	if err != nil {
+		return err
+	}
+	return recordContainerEvent(ctx, tx, id, transition, state, rule.To, eventDate, reason, userID, now)
+}
+
+func recordContainerEvent(ctx context.Context, tx pgx.Tx, containerID int64, event ContainerTransition, from ContainerState, to ContainerState, eventDate *time.Time, reason string, userID int64, now time.Time) error {
+	var user *int64
+	if userID > 0 {
+		user = &userID
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO container_events (container_id, event, from_state, to_state, event_date, reason, user_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		containerID, event, from, to, eventDate, reason, user, now)
+	return err
+}
+
+// containerStateFromDates derives the state a set of stage dates implies.
+// There is no date for devanning, so it reads as gated in.
+func containerStateFromDates(inbound, processing, outbound *time.Time) (ContainerState, error) {
+	switch {
+	case outbound != nil && (processing == nil || inbound == nil):
+		return "", ErrContainerDateOrder
+	case processing != nil && inbound == nil:
+		return "", ErrContainerDateOrder
+	case outbound != nil:
+		return ContainerGatedOut, nil
+	case processing != nil:
+		return ContainerDevanned, nil
+	case inbound != nil:
+		return ContainerGatedIn, nil
+	default:
+		return ContainerAnnounced, nil
+	}
+}
+
+// ListByContainerID returns a container's history, oldest first.
+func (r *ContainerEvent) ListByContainerID(ctx context.Context, containerID int64) ([]ContainerEvent, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT e.id, e.container_id, e.event, e.from_state, e.to_state, e.event_date, e.reason, e.user_id,
+		        COALESCE(u.name, ''), e.created_at
+		   FROM container_events e
+		   LEFT JOIN users u ON u.id = e.user_id
+		  WHERE e.container_id = $1
+		  ORDER BY e.created_at ASC, e.id ASC`, containerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []ContainerEvent
+	for rows.Next() {
+		var item ContainerEvent
+		if err := rows.Scan(
+			&item.ID,
+			&item.ContainerID,
+			&item.Event,
+			&item.FromState,
+			&item.ToState,
+			&item.EventDate,
+			&item.Reason,
+			&item.UserID,
+			&item.UserName,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,302 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GateDirection tells whether a truck came into or left the yard.
+type GateDirection string
+
+const (
+	GateIn  GateDirection = "in"
+	GateOut GateDirection = "out"
+	// GateUnknown marks entries carried over from the old car number log,
+	// which never recorded a direction. It cannot be chosen for new entries.
+	GateUnknown GateDirection = "unknown"
+)
+
+func (d GateDirection) Label() string {
+	switch d {
+	case GateIn:
+		return "반입"
+	case GateOut:
+		return "반출"
+	case GateUnknown:
+		return "구분 없음"
+	default:
+		return string(d)
+	}
+}
+
+// Valid reports whether d is a direction a new entry may have.
+func (d GateDirection) Valid() bool {
+	return d == GateIn || d == GateOut
+}
+
+var ErrGateTruckPlateRequired = errors.New("차량번호를 입력해 주세요.")
+
+// MaxTruckPlateLength is the longest plate containers.car_no can hold; the
+// plate is copied there for every container the truck moves.
+const MaxTruckPlateLength = 30
+
+var ErrGateTruckPlateTooLong = fmt.Errorf("차량번호는 %d자 이내로 입력해 주세요.", MaxTruckPlateLength)
+
+var ErrGateDirectionInvalid = errors.New("반입/반출 구분이 올바르지 않습니다.")
+
+// GateTransaction is one truck passing the gate, with the containers it
+// brought in or took out.
+type GateTransaction struct {
+	ID          int64
+	Direction   GateDirection
+	TruckPlate  string
+	DriverName  string
+	DriverPhone string
+	GateAt      time.Time
+	Memo        string
+	UserID      *int64
+	UserName    string
+	CreatedAt   time.Time
+	Containers  []GateTransactionContainer
+}
+
+// GateTransactionContainer is a container moved by a gate transaction.
+type GateTransactionContainer struct {
+	ContainerID       int64
+	ContainerNo       string
+	ContainerTypeCode string
+	SupplierName      string
+	SealNo            string
+}
+
+// gateDirectionFor maps the container transitions that cross the gate to
+// their direction.
+func gateDirectionFor(transition ContainerTransition) (GateDirection, bool) {
+	switch transition {
+	case TransitionGateIn:
+		return GateIn, true
+	case TransitionGateOut:
+		return GateOut, true
+	default:
+		return "", false
+	}
+}
+
+func (r *GateTransaction) normalize() error {
+	r.TruckPlate = strings.Join(strings.Fields(r.TruckPlate), " ")
+	r.DriverName = strings.TrimSpace(r.DriverName)
+	r.DriverPhone = strings.TrimSpace(r.DriverPhone)
+	r.Memo = strings.TrimSpace(r.Memo)
+	for i := range r.Containers {
+		r.Containers[i].SealNo = strings.ToUpper(strings.TrimSpace(r.Containers[i].SealNo))
+	}
+	if r.TruckPlate == "" {
+		return ErrGateTruckPlateRequired
+	}
+	if utf8.RuneCountInString(r.TruckPlate) > MaxTruckPlateLength {
+		return ErrGateTruckPlateTooLong
+	}
+	if !r.Direction.Valid() {
+		return ErrGateDirectionInvalid
+	}
+	if r.GateAt.IsZero() {
+		r.GateAt = time.Now()
+	}
+	return nil
+}
+
+// Create records a gate transaction on its own, for trucks that did not move
+// a container handled in I/O management.
+func (r *GateTransaction) Create(ctx context.Context, userID int64) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.insert(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insert writes the transaction and its containers in tx, and copies the
+// truck plate onto each container's car_no.
+func (r *GateTransaction) insert(ctx context.Context, tx pgx.Tx, userID int64) error {
+	if err := r.normalize(); err != nil {
+		return err
+	}
+	r.CreatedAt = time.Now()
+	if userID > 0 {
+		r.UserID = &userID
+	}
+	err := tx.QueryRow(ctx,
+		`INSERT INTO gate_transactions (direction, truck_plate, driver_name, driver_phone, gate_at, memo, user_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id`,
+		r.Direction, r.TruckPlate, r.DriverName, r.DriverPhone, r.GateAt, r.Memo, r.UserID, r.CreatedAt,
+	).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range r.Containers {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO gate_transaction_containers (gate_transaction_id, container_id, seal_no)
+			 VALUES ($1, $2, $3)`,
+			r.ID, item.ContainerID, item.SealNo); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE containers SET car_no = $2, updated_at = $3 WHERE id = $1`,
+			item.ContainerID, r.TruckPlate, r.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detachGateTransaction removes a container from the latest gate transaction
+// of the given direction, used when its gate-in or gate-out is undone so the
+// gate report no longer counts the move. The truck entry itself stays.
+func detachGateTransaction(ctx context.Context, tx pgx.Tx, containerID int64, direction GateDirection) error {
+	_, err := tx.Exec(ctx,
+		`DELETE FROM gate_transaction_containers
+		  WHERE container_id = $1
+		    AND gate_transaction_id = (
+		        SELECT gc.gate_transaction_id
+		          FROM gate_transaction_containers gc
+		          JOIN gate_transactions g ON g.id = gc.gate_transaction_id
+		         WHERE gc.container_id = $1 AND g.direction = $2
+		         ORDER BY g.gate_at DESC, g.id DESC
+		         LIMIT 1
+		    )`, containerID, direction)
+	return err
+}
+
+// ListByDay returns the gate transactions of one local day in gate order,
+// each with its containers.
+func (r *GateTransaction) ListByDay(ctx context.Context, day time.Time) ([]GateTransaction, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 1)
+
+	rows, err := DB.Query(ctx,
+		`SELECT g.id, g.direction, g.truck_plate, g.driver_name, g.driver_phone, g.gate_at, g.memo,
+		        g.user_id, COALESCE(u.name, ''), g.created_at
+		   FROM gate_transactions g
+		   LEFT JOIN users u ON u.id = g.user_id
+		  WHERE g.gate_at >= $1 AND g.gate_at < $2
+		  ORDER BY g.gate_at ASC, g.id ASC`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []GateTransaction
+	index := make(map[int64]int)
+	var ids []int64
+	for rows.Next() {
+		var item GateTransaction
+		if err := rows.Scan(
+			&item.ID,
+			&item.Direction,
+			&item.TruckPlate,
+			&item.DriverName,
+			&item.DriverPhone,
+			&item.GateAt,
+			&item.Memo,
+			&item.UserID,
+			&item.UserName,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		index[item.ID] = len(list)
+		ids = append(ids, item.ID)
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return list, nil
+	}
+
+	containerRows, err := DB.Query(ctx,
+		`SELECT gc.gate_transaction_id, c.id, c.container_no, COALESCE(ct.code, ''), COALESCE(s.name, ''), gc.seal_no
+		   FROM gate_transaction_containers gc
+		   JOIN containers c ON c.id = gc.container_id
+		   LEFT JOIN container_types ct ON ct.id = c.containers_type_id
+		   LEFT JOIN suppliers s ON s.id = c.supplier_id
+		  WHERE gc.gate_transaction_id = ANY($1)
+		  ORDER BY c.container_no ASC`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer containerRows.Close()
+
+	for containerRows.Next() {
+		var gateID int64
+		var item GateTransactionContainer
+		if err := containerRows.Scan(&gateID, &item.ContainerID, &item.ContainerNo, &item.ContainerTypeCode, &item.SupplierName, &item.SealNo); err != nil {
+			return nil, err
+		}
+		if i, ok := index[gateID]; ok {
+			list[i].Containers = append(list[i].Containers, item)
+		}
+	}
+	return list, containerRows.Err()
+}
+
+// LegacyCarNumber is a row of the old car number log that the gate
+// transaction migration could not carry over, usually because its log_date
+// is not a real date.
+type LegacyCarNumber struct {
+	ID        int64
+	LogDate   string
+	CarNo     string
+	CreatedAt time.Time
+}
+
+const legacyCarNumberWhere = `NOT EXISTS (SELECT 1 FROM gate_transactions g WHERE g.carnumber_id = n.id)`
+
+// CountLegacyCarNumbers returns how many old car number rows were left behind.
+func (r *GateTransaction) CountLegacyCarNumbers(ctx context.Context) (int, error) {
+	var count int
+	err := DB.QueryRow(ctx, `SELECT count(*) FROM carnumbers n WHERE `+legacyCarNumberWhere).Scan(&count)
+	return count, err
+}
+
+// ListLegacyCarNumbers returns the old car number rows that were left behind.
+func (r *GateTransaction) ListLegacyCarNumbers(ctx context.Context) ([]LegacyCarNumber, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT n.id, n.log_date, n.car_no, n.created_at
+		   FROM carnumbers n
+		  WHERE `+legacyCarNumberWhere+`
+		  ORDER BY n.created_at ASC, n.id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []LegacyCarNumber
+	for rows.Next() {
+		var item LegacyCarNumber
+		if err := rows.Scan(&item.ID, &item.LogDate, &item.CarNo, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func (r *GateTransaction) Delete(ctx context.Context, id int64) error {
+	_, err := DB.Exec(ctx, "DELETE FROM gate_transactions WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,643 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skycontainers/internal/pagination"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ImportPreview    = "preview"
+	ImportCommitted  = "committed"
+	ImportCancelled  = "cancelled"
+	ImportRolledBack = "rolled_back"
+)
+
+const (
+	ImportRowNew    = "new"
+	ImportRowUpdate = "update"
+	ImportRowSkip   = "skip"
+	ImportRowError  = "error"
+)
+
+// ErrImportNotPreview is returned when a batch was already committed or
+// cancelled.
+var ErrImportNotPreview = errors.New("import batch is not in preview")
+
+// ErrImportNotCommitted is returned when rolling back a batch that was never
+// committed or has already been rolled back.
+var ErrImportNotCommitted = errors.New("import batch is not committed")
+
+// ImportBatch is one uploaded BL marking file. Rows are classified when the
+// file is uploaded and only written to bl_markings once the batch is
+// committed.
+type ImportBatch struct {
+	ID             int64
+	UserID         int64
+	UserName       string
+	ContainerID    *int64
+	ContainerNo    string
+	BLPositionID   *int64
+	ProfileID      *int64
+	ProfileName    string
+	FileName       string
+	Status         string
+	UnipassBatchID *int64
+	CreatedAt      time.Time
+	CommittedAt    *time.Time
+	RolledBackAt   *time.Time
+	RolledBackBy   string
+	NewCount       int
+	UpdateCount    int
+	SkipCount      int
+	ErrorCount     int
+}
+
+func (b ImportBatch) Total() int {
+	return b.NewCount + b.UpdateCount + b.SkipCount + b.ErrorCount
+}
+
+type ImportBatchRow struct {
+	ID                int64
+	BatchID           int64
+	RowNo             int
+	HBLNo             string
+	Cnee              string
+	Marks             string
+	ContainerID       *int64
+	ContainerNo       string
+	SourceContainerNo string
+	BLPositionID      *int64
+	BLPositionName    string
+	Action            string
+	Message           string
+	BLMarkingID       *int64
+	OldContainerID    *int64
+	OldContainerNo    string
+	OldBLPositionID   *int64
+	OldBLPositionName string
+	OldMarks          string
+	OldCnee           string
+	OldIsActive       bool
+}
+
+func (r ImportBatchRow) ContainerChanged() bool {
+	return r.Action == ImportRowUpdate && !sameInt64(r.ContainerID, r.OldContainerID)
+}
+
+// PositionChanged is only true when the upload set a position; leaving it
+// empty keeps the current one.
+func (r ImportBatchRow) PositionChanged() bool {
+	return r.Action == ImportRowUpdate && r.BLPositionID != nil && !sameInt64(r.BLPositionID, r.OldBLPositionID)
+}
+
+func (r ImportBatchRow) MarksChanged() bool {
+	return r.Action == ImportRowUpdate && r.Marks != r.OldMarks
+}
+
+func (r ImportBatchRow) CneeChanged() bool {
+	return r.Action == ImportRowUpdate && r.Cnee != r.OldCnee
+}
+
+// Reactivates is true when the upload matches a deactivated BL; committing
+// turns it back on instead of inserting a second row for the same HBL.
+func (r ImportBatchRow) Reactivates() bool {
+	return r.Action == ImportRowUpdate && !r.OldIsActive
+}
+
+func sameInt64(a *int64, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// Create stores the batch and its classified rows in one transaction.
+func (r *ImportBatch) Create(ctx context.Context, rows []ImportBatchRow) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	r.Status = ImportPreview
+	r.CreatedAt = time.Now()
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO import_batches (user_id, container_id, bl_position_id, import_profile_id, file_name, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id`,
+		r.UserID, r.ContainerID, r.BLPositionID, r.ProfileID, r.FileName, r.Status, r.CreatedAt).
+		Scan(&r.ID); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO import_batch_rows
+			 (batch_id, row_no, hbl_no, cnee, marks, container_id, source_container_no, bl_position_id, action, message,
+			  bl_marking_id, old_container_id, old_bl_position_id, old_marks, old_cnee, old_is_active)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+			r.ID,
+			row.RowNo,
+			row.HBLNo,
+			row.Cnee,
+			row.Marks,
+			row.ContainerID,
+			row.SourceContainerNo,
+			row.BLPositionID,
+			row.Action,
+			row.Message,
+			row.BLMarkingID,
+			row.OldContainerID,
+			row.OldBLPositionID,
+			nullableString(row.OldMarks, row.BLMarkingID != nil),
+			nullableString(row.OldCnee, row.BLMarkingID != nil),
+			nullableBool(row.OldIsActive, row.BLMarkingID != nil),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func nullableBool(value bool, valid bool) *bool {
+	if !valid {
+		return nil
+	}
+	return &value
+}
+
+func nullableString(value string, valid bool) *string {
+	if !valid {
+		return nil
+	}
+	return &value
+}
+
+const importBatchSelect = `SELECT ib.id, ib.user_id, COALESCE(u.name, ''), ib.container_id, COALESCE(c.container_no, ''), ib.bl_position_id,
+	        ib.import_profile_id, COALESCE(ip.name, ''), ib.file_name, ib.status, ib.unipass_job_batch_id, ib.created_at, ib.committed_at,
+	        ib.rolled_back_at, COALESCE(rb.name, ''),
+	        count(r.id) FILTER (WHERE r.action = 'new'),
+	        count(r.id) FILTER (WHERE r.action = 'update'),
+	        count(r.id) FILTER (WHERE r.action = 'skip'),
+	        count(r.id) FILTER (WHERE r.action = 'error')
+	   FROM import_batches ib
+	   LEFT JOIN users u ON u.id = ib.user_id
+	   LEFT JOIN containers c ON c.id = ib.container_id
+	   LEFT JOIN bl_import_profiles ip ON ip.id = ib.import_profile_id
+	   LEFT JOIN users rb ON rb.id = ib.rolled_back_by
+	   LEFT JOIN import_batch_rows r ON r.batch_id = ib.id`
+
+const importBatchGroupBy = ` GROUP BY ib.id, u.name, c.container_no, ip.name, rb.name`
+
+func scanImportBatch(row pgx.Row) (ImportBatch, error) {
+	var item ImportBatch
+	err := row.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.UserName,
+		&item.ContainerID,
+		&item.ContainerNo,
+		&item.BLPositionID,
+		&item.ProfileID,
+		&item.ProfileName,
+		&item.FileName,
+		&item.Status,
+		&item.UnipassBatchID,
+		&item.CreatedAt,
+		&item.CommittedAt,
+		&item.RolledBackAt,
+		&item.RolledBackBy,
+		&item.NewCount,
+		&item.UpdateCount,
+		&item.SkipCount,
+		&item.ErrorCount,
+	)
+	return item, err
+}
+
+func (r *ImportBatch) GetByID(ctx context.Context, id int64) (*ImportBatch, error) {
+	item, err := scanImportBatch(DB.QueryRow(ctx,
+		importBatchSelect+` WHERE ib.id = $1`+importBatchGroupBy, id))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// List returns the upload history, newest first. An empty status lists every
+// batch.
+func (r *ImportBatch) List(ctx context.Context, p pagination.Pager, status string) ([]ImportBatch, int, error) {
+	var total int
+	if err := DB.QueryRow(ctx,
+		`SELECT count(*) FROM import_batches WHERE ($1 = '' OR status = $1)`, status).
+		Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := DB.Query(ctx,
+		importBatchSelect+` WHERE ($1 = '' OR ib.status = $1)`+importBatchGroupBy+`
+		  ORDER BY ib.created_at DESC, ib.id DESC
+		  LIMIT $2 OFFSET $3`, status, p.PageSize, p.Offset())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []ImportBatch
+	for rows.Next() {
+		item, err := scanImportBatch(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, item)
+	}
+	return list, total, rows.Err()
+}
+
+func (r *ImportBatch) ListRows(ctx context.Context, batchID int64) ([]ImportBatchRow, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT r.id, r.batch_id, r.row_no, r.hbl_no, r.cnee, r.marks,
+		        r.container_id, COALESCE(c.container_no, ''), r.source_container_no, r.bl_position_id, COALESCE(p.path, ''),
+		        r.action, r.message, r.bl_marking_id,
+		        r.old_container_id, COALESCE(oc.container_no, ''), r.old_bl_position_id, COALESCE(op.path, ''),
+		        COALESCE(r.old_marks, ''), COALESCE(r.old_cnee, ''), COALESCE(r.old_is_active, true)
+		   FROM import_batch_rows r
+		   LEFT JOIN containers c ON c.id = r.container_id
+		   LEFT JOIN bl_positions p ON p.id = r.bl_position_id
+		   LEFT JOIN containers oc ON oc.id = r.old_container_id
+		   LEFT JOIN bl_positions op ON op.id = r.old_bl_position_id
+		  WHERE r.batch_id = $1
+		  ORDER BY r.row_no ASC, r.id ASC`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []ImportBatchRow
+	for rows.Next() {
+		var item ImportBatchRow
+		if err := rows.Scan(
+			&item.ID,
+			&item.BatchID,
+			&item.RowNo,
+			&item.HBLNo,
+			&item.Cnee,
+			&item.Marks,
+			&item.ContainerID,
+			&item.ContainerNo,
+			&item.SourceContainerNo,
+			&item.BLPositionID,
+			&item.BLPositionName,
+			&item.Action,
+			&item.Message,
+			&item.BLMarkingID,
+			&item.OldContainerID,
+			&item.OldContainerNo,
+			&item.OldBLPositionID,
+			&item.OldBLPositionName,
+			&item.OldMarks,
+			&item.OldCnee,
+			&item.OldIsActive,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// ImportCommitError names the spreadsheet row that stopped a commit. The whole
+// batch is rolled back, so nothing from the file has been written.
+type ImportCommitError struct {
+	RowNo int
+	Err   error
+}
+
+func (e *ImportCommitError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.RowNo, e.Err)
+}
+
+func (e *ImportCommitError) Unwrap() error {
+	return e.Err
+}
+
+type ImportCommitResult struct {
+	Inserted       int
+	Updated        int
+	UnipassBatchID int64
+}
+
+// Commit applies the new and update rows of a previewed batch and queues
+// their UNIPASS lookups in one transaction: either every row is written or
+// none is. The batch row is locked first, so a double submit fails with
+// ErrImportNotPreview instead of applying the file twice. The written BLs
+// belong to the uploader; userID is who confirmed, recorded on the UNIPASS
+// batch and on position moves from device.
+func (r *ImportBatch) Commit(ctx context.Context, id int64, userID int64, device string) (*ImportCommitResult, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status string
+	var ownerID int64
+	if err := tx.QueryRow(ctx,
+		`SELECT status, user_id FROM import_batches WHERE id = $1 FOR UPDATE`, id).
+		Scan(&status, &ownerID); err != nil {
+		return nil, err
+	}
+	if status != ImportPreview {
+		return nil, ErrImportNotPreview
+	}
+
+	type pendingRow struct {
+		ID           int64
+		RowNo        int
+		HBLNo        string
+		Cnee         string
+		Marks        string
+		ContainerID  *int64
+		BLPositionID *int64
+	}
+	rows, err := tx.Query(ctx,
+		`SELECT id, row_no, hbl_no, cnee, marks, container_id, bl_position_id
+		   FROM import_batch_rows
+		  WHERE batch_id = $1 AND action IN ('new', 'update')
+		  ORDER BY row_no ASC, id ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	var pending []pendingRow
+	for rows.Next() {
+		var item pendingRow
+		if err := rows.Scan(&item.ID, &item.RowNo, &item.HBLNo, &item.Cnee, &item.Marks, &item.ContainerID, &item.BLPositionID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pending = append(pending, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &ImportCommitResult{}
+	now := time.Now()
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO unipass_job_batches (user_id, source, created_at)
+		 VALUES ($1, $2, $3)
+		 RETURNING id`,
+		userID, UnipassBatchUpload, now).
+		Scan(&result.UnipassBatchID); err != nil {
+		return nil, err
+	}
+
+	available := map[int64]bool{}
+	for _, row := range pending {
+		if row.ContainerID == nil {
+			return nil, &ImportCommitError{RowNo: row.RowNo, Err: ErrContainerUnavailable}
+		}
+		ok, checked := available[*row.ContainerID]
+		if !checked {
+			if err := tx.QueryRow(ctx,
+				`SELECT EXISTS (SELECT 1 FROM containers WHERE id = $1 AND outbound_date IS NULL)`,
+				*row.ContainerID).Scan(&ok); err != nil {
+				return nil, &ImportCommitError{RowNo: row.RowNo, Err: err}
+			}
+			available[*row.ContainerID] = ok
+		}
+		if !ok {
+			return nil, &ImportCommitError{RowNo: row.RowNo, Err: ErrContainerUnavailable}
+		}
+
+		// The HBL is looked up again, the same way the preview does,
+		// because another upload may have created it since. A deactivated
+		// BL is turned back on rather than duplicated. The values it had
+		// right before this write are what a rollback restores.
+		action := ImportRowUpdate
+		var blMarkingID int64
+		var old struct {
+			ContainerID   *int64
+			BLPositionID  *int64
+			Marks         *string
+			Cnee          *string
+			UserID        *int64
+			ImportBatchID *int64
+			IsActive      *bool
+		}
+		err := tx.QueryRow(ctx,
+			`SELECT id, container_id, bl_position_id, marks, cnee, user_id, import_batch_id, is_active
+			   FROM bl_markings b WHERE b.hbl_no = $1
+			  ORDER BY `+blMarkingHBLMatchOrder+` LIMIT 1 FOR UPDATE`, row.HBLNo).
+			Scan(&blMarkingID, &old.ContainerID, &old.BLPositionID, &old.Marks, &old.Cnee, &old.UserID, &old.ImportBatchID, &old.IsActive)
+		switch {
+		case err == nil:
+			_, err = tx.Exec(ctx,
+				`UPDATE bl_markings SET
+				 container_id = $1,
+				 user_id = $2,
+				 bl_position_id = COALESCE($3, bl_position_id),
+				 marks = $4,
+				 cnee = $5,
+				 is_active = true,
+				 import_batch_id = $6,
+				 updated_at = $7
+				 WHERE id = $8`,
+				*row.ContainerID, ownerID, row.BLPositionID, row.Marks, row.Cnee, id, now, blMarkingID)
+		case errors.Is(err, pgx.ErrNoRows):
+			action = ImportRowNew
+			err = tx.QueryRow(ctx,
+				`INSERT INTO bl_markings
+				 (container_id, user_id, bl_position_id, hbl_no, marks, cnee, is_active, import_batch_id, created_at, updated_at)
+				 VALUES ($1, $2, $3, $4, $5, $6, true, $7, $8, $8)
+				 RETURNING id`,
+				*row.ContainerID, ownerID, row.BLPositionID, row.HBLNo, row.Marks, row.Cnee, id, now).
+				Scan(&blMarkingID)
+		}
+		if err != nil {
+			return nil, &ImportCommitError{RowNo: row.RowNo, Err: err}
+		}
+		if row.BLPositionID != nil {
+			mover := BLPositionMover{UserID: userID, Device: device, Source: BLMoveImport}
+			if err := recordBLPositionMove(ctx, tx, blMarkingID, old.BLPositionID, row.BLPositionID, mover); err != nil {
+				return nil, &ImportCommitError{RowNo: row.RowNo, Err: err}
+			}
+		}
+
+		if _, err := tx.Exec(ctx,
+			`UPDATE import_batch_rows SET
+			 action = $1,
+			 bl_marking_id = $2,
+			 old_container_id = $3,
+			 old_bl_position_id = $4,
+			 old_marks = $5,
+			 old_cnee = $6,
+			 old_user_id = $7,
+			 old_import_batch_id = $8,
+			 old_is_active = $9
+			 WHERE id = $10`,
+			action, blMarkingID, old.ContainerID, old.BLPositionID, old.Marks, old.Cnee, old.UserID, old.ImportBatchID, old.IsActive, row.ID); err != nil {
+			return nil, &ImportCommitError{RowNo: row.RowNo, Err: err}
+		}
+		if err := enqueueUnipassJob(ctx, tx, &result.UnipassBatchID, blMarkingID, row.HBLNo); err != nil {
+			return nil, &ImportCommitError{RowNo: row.RowNo, Err: err}
+		}
+		if action == ImportRowNew {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE import_batches SET status = 'committed', committed_at = $1, unipass_job_batch_id = $2
+		 WHERE id = $3`, now, result.UnipassBatchID, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *ImportBatch) Cancel(ctx context.Context, id int64) error {
+	tag, err := DB.Exec(ctx,
+		`UPDATE import_batches SET status = 'cancelled' WHERE id = $1 AND status = 'preview'`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrImportNotPreview
+	}
+	return nil
+}
+
+// ImportRollbackConflictError lists the HBLs that changed after the batch was
+// committed, either by hand or by a later upload. Nothing is rolled back while
+// any remain.
+type ImportRollbackConflictError struct {
+	HBLNos []string
+}
+
+func (e *ImportRollbackConflictError) Error() string {
+	return fmt.Sprintf("%d rows changed since the import", len(e.HBLNos))
+}
+
+// Rollback undoes a committed batch in one transaction: BLs it inserted are
+// deleted and BLs it updated get their previous values back. A BL counts as
+// unchanged only while it is still tagged with this batch and still holds the
+// container, position, marks and consignee the batch left it with.
+func (r *ImportBatch) Rollback(ctx context.Context, id int64, userID int64, device string) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status string
+	if err := tx.QueryRow(ctx,
+		`SELECT status FROM import_batches WHERE id = $1 FOR UPDATE`, id).
+		Scan(&status); err != nil {
+		return err
+	}
+	if status != ImportCommitted {
+		return ErrImportNotCommitted
+	}
+
+	type appliedRow struct {
+		ID          int64
+		HBLNo       string
+		Action      string
+		BLMarkingID int64
+		Unchanged   bool
+	}
+	rows, err := tx.Query(ctx,
+		`SELECT r.id, r.hbl_no, r.action, r.bl_marking_id,
+		        b.import_batch_id IS NOT DISTINCT FROM r.batch_id
+		        AND b.is_active = true
+		        AND b.container_id IS NOT DISTINCT FROM r.container_id
+		        AND b.marks IS NOT DISTINCT FROM r.marks
+		        AND b.cnee IS NOT DISTINCT FROM r.cnee
+		        AND b.bl_position_id IS NOT DISTINCT FROM COALESCE(r.bl_position_id, r.old_bl_position_id)
+		   FROM import_batch_rows r
+		   JOIN bl_markings b ON b.id = r.bl_marking_id
+		  WHERE r.batch_id = $1 AND r.action IN ('new', 'update')
+		  ORDER BY r.row_no ASC, r.id ASC
+		  FOR UPDATE OF b`, id)
+	if err != nil {
+		return err
+	}
+	var applied []appliedRow
+	for rows.Next() {
+		var item appliedRow
+		if err := rows.Scan(&item.ID, &item.HBLNo, &item.Action, &item.BLMarkingID, &item.Unchanged); err != nil {
+			rows.Close()
+			return err
+		}
+		applied = append(applied, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	conflict := &ImportRollbackConflictError{}
+	for _, item := range applied {
+		if !item.Unchanged {
+			conflict.HBLNos = append(conflict.HBLNos, item.HBLNo)
+		}
+	}
+	if len(conflict.HBLNos) > 0 {
+		return conflict
+	}
+
+	now := time.Now()
+	for _, item := range applied {
+		if item.Action == ImportRowNew {
+			if _, err := tx.Exec(ctx, `DELETE FROM bl_markings WHERE id = $1`, item.BLMarkingID); err != nil {
+				return err
+			}
+			continue
+		}
+		// The position is only restored when the upload set one; otherwise
+		// the batch never touched it.
+		var from, to *int64
+		if err := tx.QueryRow(ctx,
+			`SELECT bl_position_id FROM bl_markings WHERE id = $1`, item.BLMarkingID).Scan(&from); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx,
+			`UPDATE bl_markings b SET
+			 container_id = COALESCE(r.old_container_id, b.container_id),
+			 bl_position_id = CASE WHEN r.bl_position_id IS NULL THEN b.bl_position_id ELSE r.old_bl_position_id END,
+			 marks = COALESCE(r.old_marks, b.marks),
+			 cnee = COALESCE(r.old_cnee, b.cnee),
+			 user_id = COALESCE(r.old_user_id, b.user_id),
+			 is_active = COALESCE(r.old_is_active, b.is_active),
+			 import_batch_id = r.old_import_batch_id,
+			 updated_at = $1
+			 FROM import_batch_rows r
+			 WHERE r.id = $2 AND b.id = r.bl_marking_id
+			 RETURNING b.bl_position_id`,
+			now, item.ID).Scan(&to); err != nil {
+			return err
+		}
+		mover := BLPositionMover{UserID: userID, Device: device, Source: BLMoveImportRollback}
+		if err := recordBLPositionMove(ctx, tx, item.BLMarkingID, from, to, mover); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE import_batches SET status = 'rolled_back', rolled_back_at = $1, rolled_back_by = $2
+		 WHERE id = $3`, now, userID, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,217 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"skycontainers/internal/tariff"
+)
+
+// InvoiceRate is the handling and BL fee card for one supplier. A nil
+// SupplierID is the default card for suppliers without their own.
+type InvoiceRate struct {
+	ID           int64
+	SupplierID   *int64
+	SupplierName string
+	Currency     string
+	BLFee        int64
+	HandlingFees []InvoiceHandlingFee
+	Memo         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// InvoiceHandlingFee is the per-container handling fee for a container
+// length (container_types.length_ft).
+type InvoiceHandlingFee struct {
+	LengthFT int16
+	Amount   int64
+}
+
+var ErrInvoiceRateDuplicate = errors.New("이 업체의 청구 요율이 이미 있습니다.")
+
+// HandlingFee returns the fee for a container length and whether one is set.
+func (r InvoiceRate) HandlingFee(lengthFT int16) (int64, bool) {
+	for _, fee := range r.HandlingFees {
+		if fee.LengthFT == lengthFT {
+			return fee.Amount, true
+		}
+	}
+	return 0, false
+}
+
+// MatchInvoiceRate returns the supplier's own card, else the default card.
+func MatchInvoiceRate(list []InvoiceRate, supplierID int64) *InvoiceRate {
+	var fallback *InvoiceRate
+	for i := range list {
+		item := &list[i]
+		if item.SupplierID == nil {
+			fallback = item
+			continue
+		}
+		if *item.SupplierID == supplierID {
+			return item
+		}
+	}
+	return fallback
+}
+
+func (r *InvoiceRate) List(ctx context.Context) ([]InvoiceRate, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT ir.id, ir.supplier_id, COALESCE(s.name, ''), ir.currency, ir.bl_fee, ir.memo, ir.created_at, ir.updated_at
+		   FROM invoice_rates ir
+		   LEFT JOIN suppliers s ON s.id = ir.supplier_id
+		  ORDER BY s.name ASC NULLS FIRST, ir.id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []InvoiceRate
+	index := make(map[int64]int)
+	for rows.Next() {
+		var item InvoiceRate
+		if err := rows.Scan(&item.ID, &item.SupplierID, &item.SupplierName, &item.Currency, &item.BLFee, &item.Memo, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		index[item.ID] = len(list)
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	feeRows, err := DB.Query(ctx,
+		`SELECT invoice_rate_id, length_ft, amount FROM invoice_rate_handling_fees ORDER BY invoice_rate_id, length_ft`)
+	if err != nil {
+		return nil, err
+	}
+	defer feeRows.Close()
+	for feeRows.Next() {
+		var rateID int64
+		var fee InvoiceHandlingFee
+		if err := feeRows.Scan(&rateID, &fee.LengthFT, &fee.Amount); err != nil {
+			return nil, err
+		}
+		if i, ok := index[rateID]; ok {
+			list[i].HandlingFees = append(list[i].HandlingFees, fee)
+		}
+	}
+	return list, feeRows.Err()
+}
+
+func (r *InvoiceRate) GetByID(ctx context.Context, id int64) (*InvoiceRate, error) {
+	var item InvoiceRate
+	err := DB.QueryRow(ctx,
+		`SELECT id, supplier_id, currency, bl_fee, memo, created_at, updated_at FROM invoice_rates WHERE id = $1`, id).
+		Scan(&item.ID, &item.SupplierID, &item.Currency, &item.BLFee, &item.Memo, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT length_ft, amount FROM invoice_rate_handling_fees WHERE invoice_rate_id = $1 ORDER BY length_ft`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fee InvoiceHandlingFee
+		if err := rows.Scan(&fee.LengthFT, &fee.Amount); err != nil {
+			return nil, err
+		}
+		item.HandlingFees = append(item.HandlingFees, fee)
+	}
+	return &item, rows.Err()
+}
+
+func (r *InvoiceRate) validate(ctx context.Context) error {
+	r.Currency = tariff.NormalizeCurrency(r.Currency)
+	sort.Slice(r.HandlingFees, func(i, j int) bool { return r.HandlingFees[i].LengthFT < r.HandlingFees[j].LengthFT })
+
+	var exists bool
+	err := DB.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM invoice_rates WHERE COALESCE(supplier_id, 0) = COALESCE($1, 0) AND id <> $2)`,
+		r.SupplierID, r.ID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrInvoiceRateDuplicate
+	}
+	return nil
+}
+
+func (r *InvoiceRate) Create(ctx context.Context) error {
+	if err := r.validate(ctx); err != nil {
+		return err
+	}
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	err = tx.QueryRow(ctx,
+		`INSERT INTO invoice_rates (supplier_id, currency, bl_fee, memo, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		r.SupplierID, r.Currency, r.BLFee, r.Memo, r.CreatedAt, r.UpdatedAt,
+	).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+	for _, fee := range r.HandlingFees {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO invoice_rate_handling_fees (invoice_rate_id, length_ft, amount) VALUES ($1, $2, $3)`,
+			r.ID, fee.LengthFT, fee.Amount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *InvoiceRate) Update(ctx context.Context) error {
+	if err := r.validate(ctx); err != nil {
+		return err
+	}
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	r.UpdatedAt = time.Now()
+	_, err = tx.Exec(ctx,
+		`UPDATE invoice_rates SET
+		 supplier_id = $1,
+		 currency = $2,
+		 bl_fee = $3,
+		 memo = $4,
+		 updated_at = $5
+		 WHERE id = $6`,
+		r.SupplierID, r.Currency, r.BLFee, r.Memo, r.UpdatedAt, r.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM invoice_rate_handling_fees WHERE invoice_rate_id = $1`, r.ID); err != nil {
+		return err
+	}
+	for _, fee := range r.HandlingFees {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO invoice_rate_handling_fees (invoice_rate_id, length_ft, amount) VALUES ($1, $2, $3)`,
+			r.ID, fee.LengthFT, fee.Amount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *InvoiceRate) Delete(ctx context.Context, id int64) error {
+	_, err := DB.Exec(ctx, "DELETE FROM invoice_rates WHERE id = $1", id)
+	return err
+}
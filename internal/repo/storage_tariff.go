@@ -0,0 +1,268 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"skycontainers/internal/tariff"
+)
+
+// StorageTariff is the storage pricing for one supplier and container type.
+// A nil SupplierID or ContainerTypeID applies to every supplier or type.
+type StorageTariff struct {
+	ID                int64
+	SupplierID        *int64
+	SupplierName      string
+	ContainerTypeID   *int64
+	ContainerTypeCode string
+	Currency          string
+	FreeDays          int
+	Memo              string
+	Tiers             []StorageTariffTier
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// StorageTariffTier charges Rate (in minor units) per day from storage day
+// FromDay onward.
+type StorageTariffTier struct {
+	FromDay int
+	Rate    int64
+}
+
+var ErrStorageTariffDuplicate = errors.New("같은 업체와 규격의 요율이 이미 있습니다.")
+
+var ErrStorageTariffTiersRequired = errors.New("요율 구간을 하나 이상 입력해 주세요.")
+
+// Tariff converts the stored rows to the calculator's form.
+func (r StorageTariff) Tariff() tariff.Tariff {
+	tiers := make([]tariff.Tier, 0, len(r.Tiers))
+	for _, tier := range r.Tiers {
+		tiers = append(tiers, tariff.Tier{FromDay: tier.FromDay, Rate: tier.Rate})
+	}
+	return tariff.Tariff{Currency: r.Currency, FreeDays: r.FreeDays, Tiers: tiers}
+}
+
+// MatchStorageTariff picks the most specific tariff for a container: supplier
+// and type, then supplier only, then type only, then the default.
+func MatchStorageTariff(list []StorageTariff, supplierID, typeID int64) *StorageTariff {
+	var best *StorageTariff
+	bestScore := -1
+	for i := range list {
+		item := &list[i]
+		score := 0
+		if item.SupplierID != nil {
+			if *item.SupplierID != supplierID {
+				continue
+			}
+			score += 2
+		}
+		if item.ContainerTypeID != nil {
+			if *item.ContainerTypeID != typeID {
+				continue
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = item, score
+		}
+	}
+	return best
+}
+
+func (r *StorageTariff) List(ctx context.Context) ([]StorageTariff, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT t.id, t.supplier_id, COALESCE(s.name, ''), t.containers_type_id, COALESCE(ct.code, ''),
+		        t.currency, t.free_days, t.memo, t.created_at, t.updated_at
+		   FROM storage_tariffs t
+		   LEFT JOIN suppliers s ON s.id = t.supplier_id
+		   LEFT JOIN container_types ct ON ct.id = t.containers_type_id
+		  ORDER BY s.name ASC NULLS FIRST, ct.code ASC NULLS FIRST, t.id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []StorageTariff
+	index := make(map[int64]int)
+	for rows.Next() {
+		var item StorageTariff
+		if err := rows.Scan(
+			&item.ID,
+			&item.SupplierID,
+			&item.SupplierName,
+			&item.ContainerTypeID,
+			&item.ContainerTypeCode,
+			&item.Currency,
+			&item.FreeDays,
+			&item.Memo,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		index[item.ID] = len(list)
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tierRows, err := DB.Query(ctx,
+		`SELECT storage_tariff_id, from_day, rate FROM storage_tariff_tiers ORDER BY storage_tariff_id, from_day`)
+	if err != nil {
+		return nil, err
+	}
+	defer tierRows.Close()
+	for tierRows.Next() {
+		var tariffID int64
+		var tier StorageTariffTier
+		if err := tierRows.Scan(&tariffID, &tier.FromDay, &tier.Rate); err != nil {
+			return nil, err
+		}
+		if i, ok := index[tariffID]; ok {
+			list[i].Tiers = append(list[i].Tiers, tier)
+		}
+	}
+	return list, tierRows.Err()
+}
+
+func (r *StorageTariff) GetByID(ctx context.Context, id int64) (*StorageTariff, error) {
+	var item StorageTariff
+	err := DB.QueryRow(ctx,
+		`SELECT id, supplier_id, containers_type_id, currency, free_days, memo, created_at, updated_at
+		   FROM storage_tariffs WHERE id = $1`, id).
+		Scan(&item.ID, &item.SupplierID, &item.ContainerTypeID, &item.Currency, &item.FreeDays, &item.Memo, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT from_day, rate FROM storage_tariff_tiers WHERE storage_tariff_id = $1 ORDER BY from_day`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tier StorageTariffTier
+		if err := rows.Scan(&tier.FromDay, &tier.Rate); err != nil {
+			return nil, err
+		}
+		item.Tiers = append(item.Tiers, tier)
+	}
+	return &item, rows.Err()
+}
+
+// validate normalizes the currency and tiers and checks the scope is free.
+func (r *StorageTariff) validate(ctx context.Context) error {
+	r.Currency = tariff.NormalizeCurrency(r.Currency)
+	if r.FreeDays < 0 {
+		r.FreeDays = 0
+	}
+	tiers := make(map[int]int64)
+	for _, tier := range r.Tiers {
+		if tier.FromDay < 1 {
+			tier.FromDay = 1
+		}
+		tiers[tier.FromDay] = tier.Rate
+	}
+	r.Tiers = r.Tiers[:0]
+	for fromDay, rate := range tiers {
+		r.Tiers = append(r.Tiers, StorageTariffTier{FromDay: fromDay, Rate: rate})
+	}
+	sort.Slice(r.Tiers, func(i, j int) bool { return r.Tiers[i].FromDay < r.Tiers[j].FromDay })
+	if len(r.Tiers) == 0 {
+		return ErrStorageTariffTiersRequired
+	}
+
+	var exists bool
+	err := DB.QueryRow(ctx,
+		`SELECT EXISTS (
+		   SELECT 1 FROM storage_tariffs
+		    WHERE COALESCE(supplier_id, 0) = COALESCE($1, 0)
+		      AND COALESCE(containers_type_id, 0) = COALESCE($2, 0)
+		      AND id <> $3)`,
+		r.SupplierID, r.ContainerTypeID, r.ID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrStorageTariffDuplicate
+	}
+	return nil
+}
+
+func (r *StorageTariff) Create(ctx context.Context) error {
+	if err := r.validate(ctx); err != nil {
+		return err
+	}
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	err = tx.QueryRow(ctx,
+		`INSERT INTO storage_tariffs (supplier_id, containers_type_id, currency, free_days, memo, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id`,
+		r.SupplierID, r.ContainerTypeID, r.Currency, r.FreeDays, r.Memo, r.CreatedAt, r.UpdatedAt,
+	).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+	for _, tier := range r.Tiers {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO storage_tariff_tiers (storage_tariff_id, from_day, rate) VALUES ($1, $2, $3)`,
+			r.ID, tier.FromDay, tier.Rate); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *StorageTariff) Update(ctx context.Context) error {
+	if err := r.validate(ctx); err != nil {
+		return err
+	}
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	r.UpdatedAt = time.Now()
+	_, err = tx.Exec(ctx,
+		`UPDATE storage_tariffs SET
+		 supplier_id = $1,
+		 containers_type_id = $2,
+		 currency = $3,
+		 free_days = $4,
+		 memo = $5,
+		 updated_at = $6
+		 WHERE id = $7`,
+		r.SupplierID, r.ContainerTypeID, r.Currency, r.FreeDays, r.Memo, r.UpdatedAt, r.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM storage_tariff_tiers WHERE storage_tariff_id = $1`, r.ID); err != nil {
+		return err
+	}
+	for _, tier := range r.Tiers {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO storage_tariff_tiers (storage_tariff_id, from_day, rate) VALUES ($1, $2, $3)`,
+			r.ID, tier.FromDay, tier.Rate); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *StorageTariff) Delete(ctx context.Context, id int64) error {
+	_, err := DB.Exec(ctx, "DELETE FROM storage_tariffs WHERE id = $1", id)
+	return err
+}
@@ -98,3 +98,18 @@ func (r *Supplier) Delete(ctx context.Context, id int64) error {
 	_, err := DB.Exec(ctx, "DELETE FROM suppliers WHERE id = $1", id)
 	return err
 }
+
+// GetLabelTemplate returns the supplier's ZPL label template, "" when the
+// default template is used.
+func (r *Supplier) GetLabelTemplate(ctx context.Context, id int64) (string, error) {
+	var template string
+	err := DB.QueryRow(ctx, "SELECT COALESCE(zpl_template, '') FROM suppliers WHERE id = $1", id).Scan(&template)
+	return template, err
+}
+
+func (r *Supplier) UpdateLabelTemplate(ctx context.Context, id int64, template string) error {
+	_, err := DB.Exec(ctx,
+		"UPDATE suppliers SET zpl_template = NULLIF($1, ''), updated_at = $2 WHERE id = $3",
+		template, time.Now(), id)
+	return err
+}
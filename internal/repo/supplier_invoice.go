@@ -0,0 +1,416 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"skycontainers/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InvoiceStatus is where a supplier invoice is in its life: drafts can be
+// regenerated, issued invoices are fixed and visible to the supplier.
+type InvoiceStatus string
+
+const (
+	InvoiceDraft  InvoiceStatus = "draft"
+	InvoiceIssued InvoiceStatus = "issued"
+	InvoicePaid   InvoiceStatus = "paid"
+)
+
+func (s InvoiceStatus) Label() string {
+	switch s {
+	case InvoiceDraft:
+		return "작성중"
+	case InvoiceIssued:
+		return "발행"
+	case InvoicePaid:
+		return "입금완료"
+	default:
+		return string(s)
+	}
+}
+
+// invoiceStatusMoves lists the status changes allowed from each status.
+var invoiceStatusMoves = map[InvoiceStatus][]InvoiceStatus{
+	InvoiceDraft:  {InvoiceIssued},
+	InvoiceIssued: {InvoicePaid, InvoiceDraft},
+	InvoicePaid:   {InvoiceIssued},
+}
+
+// CanMoveTo reports whether s may change to next.
+func (s InvoiceStatus) CanMoveTo(next InvoiceStatus) bool {
+	for _, allowed := range invoiceStatusMoves[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// InvoiceLineKind is what an invoice line charges for.
+type InvoiceLineKind string
+
+const (
+	InvoiceLineHandling InvoiceLineKind = "handling"
+	InvoiceLineBL       InvoiceLineKind = "bl"
+	InvoiceLineStorage  InvoiceLineKind = "storage"
+)
+
+func (k InvoiceLineKind) Label() string {
+	switch k {
+	case InvoiceLineHandling:
+		return "작업료"
+	case InvoiceLineBL:
+		return "BL 수수료"
+	case InvoiceLineStorage:
+		return "보관료"
+	default:
+		return string(k)
+	}
+}
+
+var ErrInvoiceLocked = errors.New("발행된 청구서는 다시 만들거나 삭제할 수 없습니다. 먼저 발행을 취소해 주세요.")
+
+var ErrInvoiceStatusMove = errors.New("이 단계에서는 변경할 수 없는 상태입니다.")
+
+type SupplierInvoice struct {
+	ID           int64
+	InvoiceNo    string
+	SupplierID   int64
+	SupplierName string
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Currency     string
+	Status       InvoiceStatus
+	Total        int64
+	Memo         string
+	IssuedAt     *time.Time
+	PaidAt       *time.Time
+	UserID       *int64
+	UserName     string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Lines        []SupplierInvoiceLine
+}
+
+type SupplierInvoiceLine struct {
+	ID          int64
+	Kind        InvoiceLineKind
+	ContainerID *int64
+	ContainerNo string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+	Amount      int64
+}
+
+// SupplierInvoiceFilter narrows the invoice list. Zero values match all.
+type SupplierInvoiceFilter struct {
+	PeriodStart *time.Time
+	SupplierID  int64
+	Status      InvoiceStatus
+}
+
+// InvoiceActivity is a container devanned in a billing period, with what the
+// handling and BL lines need.
+type InvoiceActivity struct {
+	ContainerID       int64
+	ContainerNo       string
+	ContainerTypeCode string
+	LengthFT          int16
+	SupplierID        int64
+	SupplierName      string
+	ProcessingDate    time.Time
+	BLCount           int
+}
+
+// ListInvoiceActivity returns the containers devanned (processing_date) in
+// [start, end], optionally for one supplier.
+func (r *SupplierInvoice) ListInvoiceActivity(ctx context.Context, start, end time.Time, supplierID int64) ([]InvoiceActivity, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT c.id, c.container_no, COALESCE(ct.code, ''), COALESCE(ct.length_ft, 0), c.supplier_id, COALESCE(s.name, ''),
+		        c.processing_date,
+		        (SELECT count(*) FROM bl_markings b WHERE b.container_id = c.id AND b.is_active = true)
+		   FROM containers c
+		   LEFT JOIN container_types ct ON ct.id = c.containers_type_id
+		   LEFT JOIN suppliers s ON s.id = c.supplier_id
+		  WHERE c.processing_date BETWEEN $1 AND $2
+		    AND ($3 = 0 OR c.supplier_id = $3)
+		  ORDER BY s.name ASC, c.processing_date ASC, c.container_no ASC`,
+		start, end, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []InvoiceActivity
+	for rows.Next() {
+		var item InvoiceActivity
+		if err := rows.Scan(
+			&item.ContainerID,
+			&item.ContainerNo,
+			&item.ContainerTypeCode,
+			&item.LengthFT,
+			&item.SupplierID,
+			&item.SupplierName,
+			&item.ProcessingDate,
+			&item.BLCount,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func buildSupplierInvoiceFilter(filter SupplierInvoiceFilter, supplierOnly bool) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := make([]interface{}, 0, 3)
+	if filter.PeriodStart != nil {
+		args = append(args, *filter.PeriodStart)
+		conditions = append(conditions, fmt.Sprintf("i.period_start = $%d", len(args)))
+	}
+	if filter.SupplierID > 0 {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("i.supplier_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("i.status = $%d", len(args)))
+	}
+	if supplierOnly {
+		conditions = append(conditions, "i.status <> 'draft'")
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+const supplierInvoiceColumns = `i.id, i.invoice_no, i.supplier_id, COALESCE(s.name, ''), i.period_start, i.period_end, i.currency,
+		        i.status, i.total, i.memo, i.issued_at, i.paid_at, i.user_id, COALESCE(u.name, ''), i.created_at, i.updated_at`
+
+func scanSupplierInvoice(row pgx.Row, item *SupplierInvoice) error {
+	return row.Scan(
+		&item.ID,
+		&item.InvoiceNo,
+		&item.SupplierID,
+		&item.SupplierName,
+		&item.PeriodStart,
+		&item.PeriodEnd,
+		&item.Currency,
+		&item.Status,
+		&item.Total,
+		&item.Memo,
+		&item.IssuedAt,
+		&item.PaidAt,
+		&item.UserID,
+		&item.UserName,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+}
+
+func (r *SupplierInvoice) List(ctx context.Context, p pagination.Pager, filter SupplierInvoiceFilter) ([]SupplierInvoice, int, error) {
+	return r.list(ctx, p, filter, false)
+}
+
+// ListForSupplier is List for the supplier portal: issued and paid invoices
+// of one supplier only.
+func (r *SupplierInvoice) ListForSupplier(ctx context.Context, p pagination.Pager, supplierID int64) ([]SupplierInvoice, int, error) {
+	return r.list(ctx, p, SupplierInvoiceFilter{SupplierID: supplierID}, true)
+}
+
+func (r *SupplierInvoice) list(ctx context.Context, p pagination.Pager, filter SupplierInvoiceFilter, supplierOnly bool) ([]SupplierInvoice, int, error) {
+	whereClause, args := buildSupplierInvoiceFilter(filter, supplierOnly)
+
+	var total int
+	err := DB.QueryRow(ctx, fmt.Sprintf(`SELECT count(*) FROM supplier_invoices i WHERE %s`, whereClause), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limitArgs := append(args, p.PageSize, p.Offset())
+	rows, err := DB.Query(ctx,
+		fmt.Sprintf(`SELECT %s
+		   FROM supplier_invoices i
+		   LEFT JOIN suppliers s ON s.id = i.supplier_id
+		   LEFT JOIN users u ON u.id = i.user_id
+		  WHERE %s
+		  ORDER BY i.period_start DESC, s.name ASC, i.id DESC
+		  LIMIT $%d OFFSET $%d`, supplierInvoiceColumns, whereClause, len(args)+1, len(args)+2),
+		limitArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []SupplierInvoice
+	for rows.Next() {
+		var item SupplierInvoice
+		if err := scanSupplierInvoice(rows, &item); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, item)
+	}
+	return list, total, rows.Err()
+}
+
+// GetByID returns the invoice with its lines.
+func (r *SupplierInvoice) GetByID(ctx context.Context, id int64) (*SupplierInvoice, error) {
+	var item SupplierInvoice
+	row := DB.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s
+		   FROM supplier_invoices i
+		   LEFT JOIN suppliers s ON s.id = i.supplier_id
+		   LEFT JOIN users u ON u.id = i.user_id
+		  WHERE i.id = $1`, supplierInvoiceColumns), id)
+	if err := scanSupplierInvoice(row, &item); err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT id, kind, container_id, container_no, description, quantity, unit_price, amount
+		   FROM supplier_invoice_lines
+		  WHERE invoice_id = $1
+		  ORDER BY sort_order ASC, id ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line SupplierInvoiceLine
+		if err := rows.Scan(&line.ID, &line.Kind, &line.ContainerID, &line.ContainerNo, &line.Description, &line.Quantity, &line.UnitPrice, &line.Amount); err != nil {
+			return nil, err
+		}
+		item.Lines = append(item.Lines, line)
+	}
+	return &item, rows.Err()
+}
+
+// SaveDraft stores a generated invoice. An existing draft for the same
+// supplier, period and currency gets its lines replaced; an issued or paid one
+// is left alone and ErrInvoiceLocked is returned. created reports whether a
+// new invoice was made.
+func (r *SupplierInvoice) SaveDraft(ctx context.Context, userID int64) (created bool, err error) {
+	r.Total = 0
+	for _, line := range r.Lines {
+		r.Total += line.Amount
+	}
+	if userID > 0 {
+		r.UserID = &userID
+	}
+	now := time.Now()
+
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status InvoiceStatus
+	err = tx.QueryRow(ctx,
+		`SELECT id, invoice_no, status FROM supplier_invoices
+		  WHERE supplier_id = $1 AND period_start = $2 AND currency = $3
+		  FOR UPDATE`,
+		r.SupplierID, r.PeriodStart, r.Currency).Scan(&r.ID, &r.InvoiceNo, &status)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		created = true
+		if err := tx.QueryRow(ctx, `SELECT nextval(pg_get_serial_sequence('supplier_invoices', 'id'))`).Scan(&r.ID); err != nil {
+			return false, err
+		}
+		r.InvoiceNo = fmt.Sprintf("INV-%s-%05d", r.PeriodStart.Format("200601"), r.ID)
+		r.Status = InvoiceDraft
+		r.CreatedAt = now
+		r.UpdatedAt = now
+		_, err = tx.Exec(ctx,
+			`INSERT INTO supplier_invoices
+			 (id, invoice_no, supplier_id, period_start, period_end, currency, status, total, memo, user_id, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			r.ID, r.InvoiceNo, r.SupplierID, r.PeriodStart, r.PeriodEnd, r.Currency, r.Status, r.Total, r.Memo, r.UserID, r.CreatedAt, r.UpdatedAt)
+		if err != nil {
+			return false, err
+		}
+	case err != nil:
+		return false, err
+	case status != InvoiceDraft:
+		return false, ErrInvoiceLocked
+	default:
+		r.Status = InvoiceDraft
+		r.UpdatedAt = now
+		if _, err := tx.Exec(ctx,
+			`UPDATE supplier_invoices SET period_end = $2, total = $3, user_id = $4, updated_at = $5 WHERE id = $1`,
+			r.ID, r.PeriodEnd, r.Total, r.UserID, r.UpdatedAt); err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM supplier_invoice_lines WHERE invoice_id = $1`, r.ID); err != nil {
+			return false, err
+		}
+	}
+
+	for i, line := range r.Lines {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO supplier_invoice_lines
+			 (invoice_id, kind, container_id, container_no, description, quantity, unit_price, amount, sort_order)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			r.ID, line.Kind, line.ContainerID, line.ContainerNo, line.Description, line.Quantity, line.UnitPrice, line.Amount, i); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+// SetStatus moves an invoice along draft → issued → paid, or back one step.
+// issued_at and paid_at follow the status.
+func (r *SupplierInvoice) SetStatus(ctx context.Context, id int64, next InvoiceStatus) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var current InvoiceStatus
+	if err := tx.QueryRow(ctx, `SELECT status FROM supplier_invoices WHERE id = $1 FOR UPDATE`, id).Scan(&current); err != nil {
+		return err
+	}
+	if !current.CanMoveTo(next) {
+		return ErrInvoiceStatusMove
+	}
+
+	now := time.Now()
+	var sets string
+	switch next {
+	case InvoiceDraft:
+		sets = "issued_at = NULL, paid_at = NULL"
+	case InvoiceIssued:
+		sets = "issued_at = COALESCE(issued_at, $3), paid_at = NULL"
+	case InvoicePaid:
+		sets = "paid_at = $3"
+	}
+	args := []interface{}{id, next}
+	if strings.Contains(sets, "$3") {
+		args = append(args, now)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE supplier_invoices SET status = $2, updated_at = now(), `+sets+` WHERE id = $1`, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes a draft invoice.
+func (r *SupplierInvoice) Delete(ctx context.Context, id int64) error {
+	tag, err := DB.Exec(ctx, "DELETE FROM supplier_invoices WHERE id = $1 AND status = 'draft'", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvoiceLocked
+	}
+	return nil
+}
@@ -30,7 +30,7 @@ func (r *SupplierPortalItem) ListByHBLNo(ctx context.Context, hblNo string) ([]S
 
 	rows, err := DB.Query(ctx,
 		`SELECT b.hbl_no, b.marks, b.is_active, b.created_at,
-		        COALESCE(p.name, ''), COALESCE(u.name, ''),
+		        COALESCE(p.path, ''), COALESCE(u.name, ''),
 		        c.container_no, c.container_status,
 		        c.inbound_date, c.processing_date, c.outbound_date,
 		        COALESCE(ct.name, ''), COALESCE(s.name, '')
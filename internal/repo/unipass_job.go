@@ -0,0 +1,343 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	UnipassJobPending = "pending"
+	UnipassJobRunning = "running"
+	UnipassJobDone    = "done"
+	UnipassJobFailed  = "failed"
+)
+
+const (
+	UnipassBatchUpload = "upload"
+	UnipassBatchApply  = "apply"
+	UnipassBatchMBL    = "mbl"
+)
+
+// UnipassReleasedStatus is the progress status UNIPASS reports once cargo has
+// left the bonded area; released BLs are no longer refreshed.
+const UnipassReleasedStatus = "반출완료"
+
+type UnipassJob struct {
+	ID          int64
+	BatchID     *int64
+	BLMarkingID int64
+	HBLNo       string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	RunAfter    time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type UnipassJobBatch struct {
+	ID        int64
+	UserID    int64
+	Source    string
+	Total     int
+	Pending   int
+	Running   int
+	Done      int
+	Failed    int
+	CreatedAt time.Time
+}
+
+func (b UnipassJobBatch) Finished() int {
+	return b.Done + b.Failed
+}
+
+func (b UnipassJobBatch) IsComplete() bool {
+	return b.Pending+b.Running == 0
+}
+
+func (b UnipassJobBatch) Percent() int {
+	if b.Total <= 0 {
+		return 0
+	}
+	return b.Finished() * 100 / b.Total
+}
+
+func (r *UnipassJobBatch) Create(ctx context.Context) error {
+	r.CreatedAt = time.Now()
+	return DB.QueryRow(ctx,
+		`INSERT INTO unipass_job_batches (user_id, source, created_at)
+		 VALUES ($1, $2, $3)
+		 RETURNING id`,
+		r.UserID,
+		r.Source,
+		r.CreatedAt,
+	).Scan(&r.ID)
+}
+
+func (r *UnipassJobBatch) GetProgress(ctx context.Context, id int64) (*UnipassJobBatch, error) {
+	var item UnipassJobBatch
+	err := DB.QueryRow(ctx,
+		`SELECT b.id, b.user_id, b.source, b.created_at,
+		        count(j.id),
+		        count(j.id) FILTER (WHERE j.status = 'pending'),
+		        count(j.id) FILTER (WHERE j.status = 'running'),
+		        count(j.id) FILTER (WHERE j.status = 'done'),
+		        count(j.id) FILTER (WHERE j.status = 'failed')
+		   FROM unipass_job_batches b
+		   LEFT JOIN unipass_jobs j ON j.batch_id = b.id
+		  WHERE b.id = $1
+		  GROUP BY b.id`, id).
+		Scan(
+			&item.ID,
+			&item.UserID,
+			&item.Source,
+			&item.CreatedAt,
+			&item.Total,
+			&item.Pending,
+			&item.Running,
+			&item.Done,
+			&item.Failed,
+		)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *UnipassJob) ListFailedByBatch(ctx context.Context, batchID int64, limit int) ([]UnipassJob, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT id, bl_marking_id, hbl_no, attempts, COALESCE(last_error, ''), updated_at
+		   FROM unipass_jobs
+		  WHERE batch_id = $1 AND status = 'failed'
+		  ORDER BY id ASC
+		  LIMIT $2`, batchID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []UnipassJob
+	for rows.Next() {
+		var item UnipassJob
+		if err := rows.Scan(&item.ID, &item.BLMarkingID, &item.HBLNo, &item.Attempts, &item.LastError, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// Enqueue adds a lookup job unless the same marking already has one waiting,
+// so repeated uploads of a file do not pile up duplicate requests.
+func (r *UnipassJob) Enqueue(ctx context.Context, batchID *int64, blMarkingID int64, hblNo string) error {
+	return enqueueUnipassJob(ctx, DB, batchID, blMarkingID, hblNo)
+}
+
+// execer is the part of pgxpool.Pool and pgx.Tx that enqueueUnipassJob needs,
+// so an import commit can queue lookups inside its own transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func enqueueUnipassJob(ctx context.Context, db execer, batchID *int64, blMarkingID int64, hblNo string) error {
+	now := time.Now()
+	tag, err := db.Exec(ctx,
+		`UPDATE unipass_jobs SET
+		 batch_id = $1,
+		 hbl_no = $2,
+		 attempts = 0,
+		 last_error = NULL,
+		 run_after = $3,
+		 updated_at = $3
+		 WHERE bl_marking_id = $4 AND status = 'pending'`,
+		batchID, hblNo, now, blMarkingID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	_, err = db.Exec(ctx,
+		`INSERT INTO unipass_jobs
+		 (batch_id, bl_marking_id, hbl_no, status, attempts, max_attempts, run_after, created_at, updated_at)
+		 VALUES ($1, $2, $3, 'pending', 0, 5, $4, $4, $4)`,
+		batchID, blMarkingID, hblNo, now)
+	return err
+}
+
+// EnqueueRefresh queues a lookup for active, not yet released BLs whose
+// container is still in the yard and whose last fetch is older than
+// staleBefore. BLs that already have a pending or running job, or whose last
+// job failed after staleBefore, are left alone.
+func (r *UnipassJob) EnqueueRefresh(ctx context.Context, staleBefore time.Time, limit int) (int64, error) {
+	now := time.Now()
+	tag, err := DB.Exec(ctx,
+		`INSERT INTO unipass_jobs
+		 (batch_id, bl_marking_id, hbl_no, status, attempts, max_attempts, run_after, created_at, updated_at)
+		 SELECT NULL, b.id, b.hbl_no, 'pending', 0, 5, $1, $1, $1
+		   FROM bl_markings b
+		   LEFT JOIN containers c ON c.id = b.container_id
+		   LEFT JOIN bl_unipass_cargo uc ON uc.bl_marking_id = b.id
+		  WHERE b.is_active = true
+		    AND b.hbl_no <> ''
+		    AND c.outbound_date IS NULL
+		    AND COALESCE(uc.progress_status, '') <> $2
+		    AND COALESCE(uc.customs_status, '') <> $2
+		    AND (uc.fetched_at IS NULL OR uc.fetched_at < $3)
+		    AND NOT EXISTS (
+		        SELECT 1 FROM unipass_jobs j
+		         WHERE j.bl_marking_id = b.id
+		           AND (j.status IN ('pending', 'running') OR (j.status = 'failed' AND j.updated_at >= $3))
+		    )
+		  ORDER BY uc.fetched_at ASC NULLS FIRST, b.id ASC
+		  LIMIT $4`,
+		now, UnipassReleasedStatus, staleBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountQueued returns the number of pending and running jobs.
+func (r *UnipassJob) CountQueued(ctx context.Context) (int, error) {
+	var count int
+	err := DB.QueryRow(ctx,
+		`SELECT count(*) FROM unipass_jobs WHERE status IN ('pending', 'running')`).
+		Scan(&count)
+	return count, err
+}
+
+// ClaimNext marks the oldest due job as running and returns it. SKIP LOCKED
+// lets several workers (or several server processes) poll the same table.
+func (r *UnipassJob) ClaimNext(ctx context.Context) (*UnipassJob, error) {
+	var item UnipassJob
+	var batchID pgtype.Int8
+	err := DB.QueryRow(ctx,
+		`UPDATE unipass_jobs SET
+		 status = 'running',
+		 attempts = attempts + 1,
+		 started_at = NOW(),
+		 updated_at = NOW()
+		 WHERE id = (
+		     SELECT id FROM unipass_jobs
+		      WHERE status = 'pending' AND run_after <= NOW()
+		      ORDER BY run_after ASC, id ASC
+		      LIMIT 1
+		      FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id, batch_id, bl_marking_id, hbl_no, status, attempts, max_attempts`).
+		Scan(
+			&item.ID,
+			&batchID,
+			&item.BLMarkingID,
+			&item.HBLNo,
+			&item.Status,
+			&item.Attempts,
+			&item.MaxAttempts,
+		)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if batchID.Valid {
+		value := batchID.Int64
+		item.BatchID = &value
+	}
+	return &item, nil
+}
+
+func (r *UnipassJob) MarkDone(ctx context.Context, id int64) error {
+	_, err := DB.Exec(ctx,
+		`UPDATE unipass_jobs SET
+		 status = 'done',
+		 last_error = NULL,
+		 finished_at = NOW(),
+		 updated_at = NOW()
+		 WHERE id = $1`, id)
+	return err
+}
+
+// MarkRetry puts the job back in the queue after backoff, or marks it failed
+// once it has used up its attempts.
+func (r *UnipassJob) MarkRetry(ctx context.Context, id int64, lastError string, backoff time.Duration) error {
+	_, err := DB.Exec(ctx,
+		`UPDATE unipass_jobs SET
+		 status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+		 last_error = $1,
+		 run_after = NOW() + $2 * INTERVAL '1 second',
+		 finished_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+		 updated_at = NOW()
+		 WHERE id = $3`,
+		lastError, int64(backoff/time.Second), id)
+	return err
+}
+
+// Defer hands a claimed job back to the queue without spending an attempt,
+// used while the UNIPASS circuit breaker is open.
+func (r *UnipassJob) Defer(ctx context.Context, id int64, reason string, runAfter time.Time) error {
+	_, err := DB.Exec(ctx,
+		`UPDATE unipass_jobs SET
+		 status = 'pending',
+		 attempts = GREATEST(attempts - 1, 0),
+		 last_error = $1,
+		 run_after = $2,
+		 started_at = NULL,
+		 updated_at = NOW()
+		 WHERE id = $3`,
+		reason, runAfter, id)
+	return err
+}
+
+// RequeueStale returns jobs left in running by a crashed or restarted process.
+func (r *UnipassJob) RequeueStale(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tag, err := DB.Exec(ctx,
+		`UPDATE unipass_jobs SET
+		 status = 'pending',
+		 run_after = NOW(),
+		 updated_at = NOW()
+		 WHERE status = 'running' AND started_at < NOW() - $1 * INTERVAL '1 second'`,
+		int64(olderThan/time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PruneFinished deletes jobs that finished before finishedBefore. Scheduled
+// refresh jobs have no batch and are removed one by one; upload, apply and
+// MBL batches are removed whole once none of their jobs are still queued, so
+// a batch's progress page never shows a partial count.
+func (r *UnipassJob) PruneFinished(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	tag, err := DB.Exec(ctx,
+		`DELETE FROM unipass_jobs
+		  WHERE batch_id IS NULL
+		    AND status IN ('done', 'failed')
+		    AND finished_at < $1`, finishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	pruned := tag.RowsAffected()
+
+	tag, err = DB.Exec(ctx,
+		`DELETE FROM unipass_job_batches b
+		  WHERE b.created_at < $1
+		    AND NOT EXISTS (
+		        SELECT 1 FROM unipass_jobs j
+		         WHERE j.batch_id = b.id
+		           AND (j.status IN ('pending', 'running') OR j.finished_at >= $1)
+		    )`, finishedBefore)
+	if err != nil {
+		return pruned, err
+	}
+	return pruned + tag.RowsAffected(), nil
+}
@@ -0,0 +1,125 @@
+// Package scancode renders the HBL number as Code128 and QR symbols for the
+// printed cargo cards and labels, in a form both the HTML templates (SVG) and
+// the PDF generator can draw.
+package scancode
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// Matrix is a grid of modules. A linear code has a height of one.
+type Matrix struct {
+	Width  int
+	Height int
+	dark   []bool
+}
+
+// Dark reports whether the module at x, y is printed.
+func (m Matrix) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return false
+	}
+	return m.dark[y*m.Width+x]
+}
+
+// Run is a horizontal stretch of dark modules starting at X in row Y.
+type Run struct {
+	X, Y, Len int
+}
+
+// Runs merges adjacent dark modules per row so callers draw one rectangle
+// per bar instead of one per module.
+func (m Matrix) Runs() []Run {
+	var runs []Run
+	for y := 0; y < m.Height; y++ {
+		x := 0
+		for x < m.Width {
+			if !m.Dark(x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < m.Width && m.Dark(x, y) {
+				x++
+			}
+			runs = append(runs, Run{X: start, Y: y, Len: x - start})
+		}
+	}
+	return runs
+}
+
+// Code128 encodes value as a Code128 barcode.
+func Code128(value string) (Matrix, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Matrix{}, fmt.Errorf("바코드로 만들 값이 없습니다")
+	}
+	code, err := code128.Encode(value)
+	if err != nil {
+		return Matrix{}, err
+	}
+	bounds := code.Bounds()
+	m := Matrix{Width: bounds.Dx(), Height: 1, dark: make([]bool, bounds.Dx())}
+	for x := 0; x < m.Width; x++ {
+		m.dark[x] = isDark(code, bounds.Min.X+x, bounds.Min.Y)
+	}
+	return m, nil
+}
+
+// QR encodes value as a QR code with medium error correction.
+func QR(value string) (Matrix, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Matrix{}, fmt.Errorf("QR 코드로 만들 값이 없습니다")
+	}
+	code, err := qr.Encode(value, qr.M, qr.Auto)
+	if err != nil {
+		return Matrix{}, err
+	}
+	bounds := code.Bounds()
+	m := Matrix{Width: bounds.Dx(), Height: bounds.Dy(), dark: make([]bool, bounds.Dx()*bounds.Dy())}
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			m.dark[y*m.Width+x] = isDark(code, bounds.Min.X+x, bounds.Min.Y+y)
+		}
+	}
+	return m, nil
+}
+
+func isDark(code barcode.Barcode, x, y int) bool {
+	gray := color.GrayModel.Convert(code.At(x, y)).(color.Gray)
+	return gray.Y < 128
+}
+
+// SVG renders the matrix with quiet modules on each side. Linear codes are
+// stretched to the box the caller gives them, QR codes keep square modules.
+func (m Matrix) SVG(quiet int) string {
+	if m.Width == 0 {
+		return ""
+	}
+	width := m.Width + quiet*2
+	height := m.Height
+	aspect := "none"
+	if m.Height > 1 {
+		height += quiet * 2
+		aspect = "xMidYMid meet"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" preserveAspectRatio="%s" shape-rendering="crispEdges">`, width, height, aspect)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	offsetY := 0
+	if m.Height > 1 {
+		offsetY = quiet
+	}
+	for _, run := range m.Runs() {
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="1" fill="#000"/>`, run.X+quiet, run.Y+offsetY, run.Len)
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
@@ -0,0 +1,174 @@
+// Package tariff computes storage charges from free days and tiered daily
+// rates. Amounts are integers in the currency's minor unit (won for KRW,
+// cents for USD) so sums never drift.
+package tariff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is used when a tariff does not name one.
+const DefaultCurrency = "KRW"
+
+// Tier charges Rate per day from storage day FromDay (1 = the inbound day)
+// until the next tier starts.
+type Tier struct {
+	FromDay int
+	Rate    int64
+}
+
+// Tariff is the rule set applied to one container.
+type Tariff struct {
+	Currency string
+	FreeDays int
+	Tiers    []Tier
+}
+
+// Charge is a container's storage within a billing period.
+type Charge struct {
+	// StorageDay is the storage day number reached by the end of the period.
+	StorageDay     int
+	BilledDays     int
+	FreeDays       int
+	ChargeableDays int
+	Amount         int64
+}
+
+func (t Tariff) sortedTiers() []Tier {
+	tiers := append([]Tier(nil), t.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].FromDay < tiers[j].FromDay })
+	return tiers
+}
+
+// Calculate charges the days from inbound to outbound (both inclusive) that
+// fall inside [start, end]. A container still in the yard is counted to end.
+// Tier positions always count from the inbound day, so a long stay keeps its
+// higher rate in later periods.
+func (t Tariff) Calculate(inbound time.Time, outbound *time.Time, start, end time.Time) Charge {
+	inDay := day(inbound)
+	last := day(end)
+	if outbound != nil && day(*outbound).Before(last) {
+		last = day(*outbound)
+	}
+	first := day(start)
+	if inDay.After(first) {
+		first = inDay
+	}
+
+	var charge Charge
+	if last.Before(inDay) {
+		return charge
+	}
+	charge.StorageDay = daysBetween(inDay, last) + 1
+	if last.Before(first) {
+		return charge
+	}
+
+	tiers := t.sortedTiers()
+	for n := daysBetween(inDay, first) + 1; n <= charge.StorageDay; n++ {
+		charge.BilledDays++
+		if n <= t.FreeDays {
+			charge.FreeDays++
+			continue
+		}
+		var rate int64
+		for _, tier := range tiers {
+			if tier.FromDay > n {
+				break
+			}
+			rate = tier.Rate
+		}
+		charge.ChargeableDays++
+		charge.Amount += rate
+	}
+	return charge
+}
+
+func day(value time.Time) time.Time {
+	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// NormalizeCurrency uppercases a currency code and falls back to
+// DefaultCurrency.
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// MinorDigits is the number of decimal places of a currency.
+func MinorDigits(currency string) int {
+	switch NormalizeCurrency(currency) {
+	case "KRW", "JPY":
+		return 0
+	default:
+		return 2
+	}
+}
+
+// ParseAmount reads a decimal amount such as "12,500" or "35.50" into minor
+// units of currency.
+func ParseAmount(value string, currency string) (int64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	if value == "" {
+		return 0, nil
+	}
+	digits := MinorDigits(currency)
+	whole, frac, hasFrac := strings.Cut(value, ".")
+	if hasFrac && len(frac) > digits {
+		return 0, fmt.Errorf("금액 %q는 소수점 %d자리까지 입력할 수 있습니다", value, digits)
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("금액 %q를 읽을 수 없습니다", value)
+	}
+	return amount, nil
+}
+
+// FormatAmount writes minor units as a grouped decimal, e.g. 1,250,000 or
+// 1,234.50.
+func FormatAmount(amount int64, currency string) string {
+	digits := MinorDigits(currency)
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	text := strconv.FormatInt(amount, 10)
+	frac := ""
+	if digits > 0 {
+		if len(text) <= digits {
+			text = strings.Repeat("0", digits-len(text)+1) + text
+		}
+		frac = "." + text[len(text)-digits:]
+		text = text[:len(text)-digits]
+	}
+	var grouped strings.Builder
+	for i, r := range text {
+		if i > 0 && (len(text)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(r)
+	}
+	return sign + grouped.String() + frac
+}
+
+// AmountValue is amount in major units, for spreadsheets.
+func AmountValue(amount int64, currency string) float64 {
+	value := float64(amount)
+	for i := 0; i < MinorDigits(currency); i++ {
+		value /= 10
+	}
+	return value
+}
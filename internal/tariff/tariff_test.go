@@ -0,0 +1,221 @@
+package tariff
+
+import (
+	"testing"
+	"time"
+)
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalculate(t *testing.T) {
+	// Days 1-5 are free, 6-10 cost 1,000 and 11 onwards 2,000. The tiers are
+	// listed out of order on purpose.
+	standard := Tariff{
+		Currency: "KRW",
+		FreeDays: 5,
+		Tiers:    []Tier{{FromDay: 11, Rate: 2000}, {FromDay: 6, Rate: 1000}},
+	}
+	octStart, octEnd := date(time.October, 1), date(time.October, 31)
+	at := func(value time.Time) *time.Time { return &value }
+
+	tests := []struct {
+		name     string
+		tariff   Tariff
+		inbound  time.Time
+		outbound *time.Time
+		start    time.Time
+		end      time.Time
+		want     Charge
+	}{
+		{
+			name:     "free days ending inside the earlier period",
+			tariff:   standard,
+			inbound:  date(time.September, 28),
+			outbound: at(date(time.October, 3)),
+			start:    date(time.September, 1),
+			end:      date(time.September, 30),
+			want:     Charge{StorageDay: 3, BilledDays: 3, FreeDays: 3},
+		},
+		{
+			name:     "free days carried into the next period",
+			tariff:   standard,
+			inbound:  date(time.September, 28),
+			outbound: at(date(time.October, 3)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 6, BilledDays: 3, FreeDays: 2, ChargeableDays: 1, Amount: 1000},
+		},
+		{
+			name:    "long stay keeps its tier position across periods",
+			tariff:  standard,
+			inbound: date(time.September, 28),
+			start:   octStart,
+			end:     octEnd,
+			// Days 4-5 free, 6-10 at 1,000, 11-34 at 2,000.
+			want: Charge{StorageDay: 34, BilledDays: 31, FreeDays: 2, ChargeableDays: 29, Amount: 53000},
+		},
+		{
+			name:     "last free day",
+			tariff:   standard,
+			inbound:  date(time.October, 1),
+			outbound: at(date(time.October, 5)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 5, BilledDays: 5, FreeDays: 5},
+		},
+		{
+			name:     "first charged day",
+			tariff:   standard,
+			inbound:  date(time.October, 1),
+			outbound: at(date(time.October, 6)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 6, BilledDays: 6, FreeDays: 5, ChargeableDays: 1, Amount: 1000},
+		},
+		{
+			name:     "last day of the first tier",
+			tariff:   standard,
+			inbound:  date(time.October, 1),
+			outbound: at(date(time.October, 10)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 10, BilledDays: 10, FreeDays: 5, ChargeableDays: 5, Amount: 5000},
+		},
+		{
+			name:     "first day of the second tier",
+			tariff:   standard,
+			inbound:  date(time.October, 1),
+			outbound: at(date(time.October, 11)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 11, BilledDays: 11, FreeDays: 5, ChargeableDays: 6, Amount: 7000},
+		},
+		{
+			name:     "outbound before the period end",
+			tariff:   standard,
+			inbound:  date(time.October, 1),
+			outbound: at(date(time.October, 12)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 12, BilledDays: 12, FreeDays: 5, ChargeableDays: 7, Amount: 9000},
+		},
+		{
+			name:     "outbound after the period end",
+			tariff:   standard,
+			inbound:  date(time.October, 25),
+			outbound: at(date(time.November, 10)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 7, BilledDays: 7, FreeDays: 5, ChargeableDays: 2, Amount: 2000},
+		},
+		{
+			name:    "inbound after the period start",
+			tariff:  standard,
+			inbound: date(time.October, 20),
+			start:   octStart,
+			end:     octEnd,
+			want:    Charge{StorageDay: 12, BilledDays: 12, FreeDays: 5, ChargeableDays: 7, Amount: 9000},
+		},
+		{
+			name:    "time of day is ignored",
+			tariff:  standard,
+			inbound: date(time.October, 20).Add(23 * time.Hour),
+			start:   octStart.Add(9 * time.Hour),
+			end:     octEnd.Add(time.Hour),
+			want:    Charge{StorageDay: 12, BilledDays: 12, FreeDays: 5, ChargeableDays: 7, Amount: 9000},
+		},
+		{
+			name:     "same day in and out",
+			tariff:   standard,
+			inbound:  date(time.October, 15),
+			outbound: at(date(time.October, 15)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 1, BilledDays: 1, FreeDays: 1},
+		},
+		{
+			name:     "outbound before the period start",
+			tariff:   standard,
+			inbound:  date(time.September, 1),
+			outbound: at(date(time.September, 20)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 20},
+		},
+		{
+			name:    "inbound after the period end",
+			tariff:  standard,
+			inbound: date(time.November, 2),
+			start:   octStart,
+			end:     octEnd,
+			want:    Charge{},
+		},
+		{
+			name:     "days before the first tier cost nothing",
+			tariff:   Tariff{Tiers: []Tier{{FromDay: 3, Rate: 500}}},
+			inbound:  date(time.October, 1),
+			outbound: at(date(time.October, 4)),
+			start:    octStart,
+			end:      octEnd,
+			want:     Charge{StorageDay: 4, BilledDays: 4, ChargeableDays: 4, Amount: 1000},
+		},
+	}
+	for _, tt := range tests {
+		got := tt.tariff.Calculate(tt.inbound, tt.outbound, tt.start, tt.end)
+		if got != tt.want {
+			t.Errorf("%s: Calculate = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		valid    bool
+	}{
+		{"12,500", "KRW", 12500, true},
+		{"", "KRW", 0, true},
+		{"35.50", "USD", 3550, true},
+		{"35.5", "usd", 3550, true},
+		{"35", "USD", 3500, true},
+		{"35.505", "USD", 0, false},
+		{"1.5", "KRW", 0, false},
+		{"-100", "KRW", 0, false},
+		{"abc", "KRW", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.value, tt.currency)
+		if tt.valid != (err == nil) {
+			t.Errorf("ParseAmount(%q, %q) error = %v, want valid %v", tt.value, tt.currency, err, tt.valid)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q, %q) = %d, want %d", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{1250000, "KRW", "1,250,000"},
+		{500, "KRW", "500"},
+		{0, "KRW", "0"},
+		{-12500, "KRW", "-12,500"},
+		{123450, "USD", "1,234.50"},
+		{5, "USD", "0.05"},
+		{0, "USD", "0.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("FormatAmount(%d, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package unipass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL   = "https://unipass.customs.go.kr:38010/ext/rest"
+	cargoProgressAPI = "/cargCsclPrgsInfoQry/retrieveCargCsclPrgsInfo"
+	defaultTimeout   = 20 * time.Second
+	defaultRateLimit = 5
+
+	defaultMaxInFlight      = 4
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = time.Minute
+)
+
+// Client queries the UNIPASS open API. The HTTP implementation is returned by
+// NewClient; unipasstest provides a local fake server for offline use.
+type Client interface {
+	// CargoProgress calls 화물통관진행정보조회 and returns the raw XML body.
+	// Bodies that report an error or no cargo come back as *ResultError.
+	CargoProgress(ctx context.Context, q CargoQuery) (string, error)
+}
+
+// CargoQuery selects the cargo to look up by cargo management number, HBL or
+// MBL, in that order of precedence. BLYear defaults to the current year when
+// zero and is not sent with a cargo management number.
+type CargoQuery struct {
+	HBLNo    string
+	MBLNo    string
+	CargMtNo string
+	BLYear   int
+}
+
+func (q CargoQuery) logKey() string {
+	switch {
+	case strings.TrimSpace(q.CargMtNo) != "":
+		return "cargMtNo:" + strings.TrimSpace(q.CargMtNo)
+	case strings.TrimSpace(q.HBLNo) != "":
+		return strings.TrimSpace(q.HBLNo)
+	default:
+		return "mbl:" + strings.TrimSpace(q.MBLNo)
+	}
+}
+
+type Config struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+	// RateLimit is the maximum number of requests per second; zero means
+	// unlimited.
+	RateLimit float64
+	// MaxInFlight caps concurrent requests; zero means unlimited.
+	MaxInFlight int
+	// BreakerThreshold is the number of consecutive failures that opens the
+	// circuit breaker for BreakerCooldown; zero disables the breaker.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// StatusReporter is implemented by clients that track the breaker state.
+type StatusReporter interface {
+	BreakerStatus() BreakerStatus
+}
+
+// ConfigFromEnv reads UNIPASS_BASE_URL, UNIPASS_API_KEY, UNIPASS_TIMEOUT,
+// UNIPASS_RATE_LIMIT, UNIPASS_MAX_IN_FLIGHT, UNIPASS_BREAKER_THRESHOLD and
+// UNIPASS_BREAKER_COOLDOWN. The legacy crkyCn variable is still accepted as
+// the key.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		BaseURL:          DefaultBaseURL,
+		Timeout:          defaultTimeout,
+		RateLimit:        defaultRateLimit,
+		MaxInFlight:      defaultMaxInFlight,
+		BreakerThreshold: defaultBreakerThreshold,
+		BreakerCooldown:  defaultBreakerCooldown,
+	}
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_BASE_URL")); value != "" {
+		cfg.BaseURL = value
+	}
+	cfg.APIKey = strings.TrimSpace(os.Getenv("UNIPASS_API_KEY"))
+	if cfg.APIKey == "" {
+		cfg.APIKey = strings.TrimSpace(os.Getenv("crkyCn"))
+	}
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_TIMEOUT")); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cfg.Timeout = parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_RATE_LIMIT")); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			cfg.RateLimit = parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_MAX_IN_FLIGHT")); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			cfg.MaxInFlight = parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_BREAKER_THRESHOLD")); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			cfg.BreakerThreshold = parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_BREAKER_COOLDOWN")); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cfg.BreakerCooldown = parsed
+		}
+	}
+	return cfg
+}
+
+type httpClient struct {
+	cfg      Config
+	http     *http.Client
+	limiter  *limiter
+	inFlight chan struct{}
+	breaker  *breaker
+}
+
+func NewClient(cfg Config) Client {
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaultBreakerCooldown
+	}
+	c := &httpClient{
+		cfg:     cfg,
+		http:    &http.Client{Timeout: cfg.Timeout},
+		limiter: newLimiter(cfg.RateLimit),
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+	if cfg.MaxInFlight > 0 {
+		c.inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return c
+}
+
+// CurrentStatus reports the breaker state of the default client. ok is false
+// when the client does not track one.
+func CurrentStatus() (status BreakerStatus, ok bool) {
+	reporter, ok := DefaultClient().(StatusReporter)
+	if !ok {
+		return BreakerStatus{}, false
+	}
+	return reporter.BreakerStatus(), true
+}
+
+func (c *httpClient) BreakerStatus() BreakerStatus {
+	return c.breaker.status()
+}
+
+var (
+	defaultClientMu sync.Mutex
+	defaultClient   Client
+)
+
+// DefaultClient returns the client used by the background workers, built
+// from the environment on first use.
+func DefaultClient() Client {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	if defaultClient == nil {
+		defaultClient = NewClient(ConfigFromEnv())
+	}
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by the background workers, e.g.
+// with one pointed at unipasstest.Server.
+func SetDefaultClient(c Client) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient = c
+}
+
+func (c *httpClient) CargoProgress(ctx context.Context, q CargoQuery) (string, error) {
+	cargMtNo := strings.TrimSpace(q.CargMtNo)
+	hblNo := strings.TrimSpace(q.HBLNo)
+	mblNo := strings.TrimSpace(q.MBLNo)
+	if cargMtNo == "" && hblNo == "" && mblNo == "" {
+		return "", ErrEmptyQuery
+	}
+	if c.cfg.APIKey == "" {
+		return "", ErrNoAPIKey
+	}
+
+	query := url.Values{}
+	query.Set("crkyCn", c.cfg.APIKey)
+	if cargMtNo != "" {
+		query.Set("cargMtNo", cargMtNo)
+		return c.get(ctx, cargoProgressAPI, query, q.logKey())
+	}
+	year := q.BLYear
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	if hblNo != "" {
+		query.Set("hblNo", hblNo)
+	}
+	if mblNo != "" {
+		query.Set("mblNo", mblNo)
+	}
+	query.Set("blYy", strconv.Itoa(year))
+	return c.get(ctx, cargoProgressAPI, query, q.logKey())
+}
+
+func (c *httpClient) get(ctx context.Context, path string, query url.Values, logKey string) (string, error) {
+	if err := c.breaker.allow(); err != nil {
+		return "", err
+	}
+	if c.inFlight != nil {
+		select {
+		case c.inFlight <- struct{}{}:
+			defer func() { <-c.inFlight }()
+		case <-ctx.Done():
+			c.breaker.release()
+			return "", ctx.Err()
+		}
+	}
+	if err := c.limiter.wait(ctx); err != nil {
+		c.breaker.release()
+		return "", err
+	}
+
+	body, err := c.do(ctx, path, query, logKey)
+	switch {
+	case err == nil:
+		c.breaker.success()
+	case errors.Is(err, context.Canceled):
+		c.breaker.release()
+	case countsAsFailure(err):
+		c.breaker.failure(err)
+	default:
+		c.breaker.success()
+	}
+	return body, err
+}
+
+// countsAsFailure separates UNIPASS being unavailable or rejecting our key
+// from ordinary answers such as "no cargo found" (tCnt=0).
+func countsAsFailure(err error) bool {
+	var resultErr *ResultError
+	if errors.As(err, &resultErr) {
+		return resultErr.TCnt == "-1"
+	}
+	return true
+}
+
+func (c *httpClient) do(ctx context.Context, path string, query url.Values, logKey string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		log.Printf("unipass request failed key=%s err=%v", logKey, err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("unipass non-200 key=%s status=%d", logKey, resp.StatusCode)
+		return "", fmt.Errorf("unipass status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("unipass read failed key=%s err=%v", logKey, err)
+		return "", err
+	}
+	xmlBody := strings.TrimSpace(string(body))
+	if xmlBody == "" {
+		log.Printf("unipass empty body key=%s", logKey)
+		return "", errors.New("unipass empty body")
+	}
+	if !ResultOK(xmlBody) {
+		resultErr := &ResultError{
+			ResultCode: strings.TrimSpace(ExtractTagValue(xmlBody, "resultCode")),
+			TCnt:       strings.TrimSpace(ExtractTagValue(xmlBody, "tCnt")),
+			Notice:     strings.TrimSpace(ExtractTagValue(xmlBody, "ntceInfo")),
+		}
+		log.Printf("unipass error body key=%s resultCode=%s tCnt=%s ntceInfo=%s",
+			logKey, resultErr.ResultCode, resultErr.TCnt, resultErr.Notice)
+		return "", resultErr
+	}
+	return xmlBody, nil
+}
+
+// limiter spaces requests evenly so a large upload does not burst past the
+// UNIPASS per-key quota.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(perSecond float64) *limiter {
+	if perSecond <= 0 {
+		return &limiter{}
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
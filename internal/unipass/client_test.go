@@ -0,0 +1,137 @@
+package unipass_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"skycontainers/internal/unipass"
+	"skycontainers/internal/unipass/unipasstest"
+)
+
+func TestCargoProgressFixtures(t *testing.T) {
+	srv := unipasstest.NewServer()
+	defer srv.Close()
+	srv.Set("SKYHBL0001", unipasstest.FixtureSuccess)
+	srv.Set("SKYHBL0002", unipasstest.FixtureMultiple)
+	srv.SetMBL("SKYMBL0001", unipasstest.FixtureMBLConsol)
+
+	tests := []struct {
+		name      string
+		query     unipass.CargoQuery
+		wantCargo []string
+	}{
+		{"single", unipass.CargoQuery{HBLNo: "SKYHBL0001"}, []string{"24SKY00010002"}},
+		{"multiple", unipass.CargoQuery{HBLNo: "skyhbl0002"}, []string{"24SKY00010002", "23SKY00990001"}},
+		{"mbl consol", unipass.CargoQuery{MBLNo: "SKYMBL0001"}, []string{"24SKY00010002", "24SKY00010003", "24SKY00010004"}},
+	}
+	client := srv.Client()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := client.CargoProgress(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("CargoProgress: %v", err)
+			}
+			resp, err := unipass.ParseCargoProgress(body)
+			if err != nil {
+				t.Fatalf("ParseCargoProgress: %v", err)
+			}
+			if len(resp.Cargos) != len(tt.wantCargo) {
+				t.Fatalf("got %d cargos, want %d", len(resp.Cargos), len(tt.wantCargo))
+			}
+			for i, want := range tt.wantCargo {
+				if got := resp.Cargos[i].CargMtNo; got != want {
+					t.Errorf("cargo %d cargMtNo = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCargoProgressSendsQuery(t *testing.T) {
+	srv := unipasstest.NewServer()
+	defer srv.Close()
+
+	_, _ = srv.Client().CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "SKYHBL0001", BLYear: 2023})
+	_, _ = srv.Client().CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "SKYHBL0001", CargMtNo: "24SKY00010002", BLYear: 2023})
+
+	requests := srv.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if got := requests[0].Get("blYy"); got != "2023" {
+		t.Errorf("hbl query blYy = %q, want 2023", got)
+	}
+	if requests[1].Has("hblNo") || requests[1].Has("blYy") {
+		t.Errorf("cargMtNo query sent hblNo/blYy: %v", requests[1])
+	}
+}
+
+func TestCargoProgressEmpty(t *testing.T) {
+	srv := unipasstest.NewServer()
+	defer srv.Close()
+
+	_, err := srv.Client().CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "UNKNOWN"})
+	var resultErr *unipass.ResultError
+	if !errors.As(err, &resultErr) {
+		t.Fatalf("err = %v, want *ResultError", err)
+	}
+	if resultErr.TCnt != "0" {
+		t.Errorf("TCnt = %q, want 0", resultErr.TCnt)
+	}
+}
+
+func TestCargoProgressError(t *testing.T) {
+	srv := unipasstest.NewServer()
+	defer srv.Close()
+	srv.Set("SKYHBL0001", unipasstest.FixtureSuccess)
+
+	cfg := srv.Config()
+	cfg.APIKey = "wrong-key"
+	_, err := unipass.NewClient(cfg).CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "SKYHBL0001"})
+	var resultErr *unipass.ResultError
+	if !errors.As(err, &resultErr) {
+		t.Fatalf("err = %v, want *ResultError", err)
+	}
+	if resultErr.TCnt != "-1" || resultErr.Notice == "" {
+		t.Errorf("got TCnt=%q Notice=%q, want tCnt -1 with a notice", resultErr.TCnt, resultErr.Notice)
+	}
+}
+
+func TestCargoProgressTimeout(t *testing.T) {
+	srv := unipasstest.NewServer()
+	defer srv.Close()
+	srv.Set("SKYHBL0001", unipasstest.FixtureSuccess)
+	srv.SetDelay(time.Second)
+
+	cfg := srv.Config()
+	cfg.Timeout = 50 * time.Millisecond
+	_, err := unipass.NewClient(cfg).CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "SKYHBL0001"})
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+}
+
+func TestCargoProgressBreakerOpens(t *testing.T) {
+	srv := unipasstest.NewServer()
+	defer srv.Close()
+
+	cfg := srv.Config()
+	cfg.APIKey = "wrong-key"
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = time.Minute
+	client := unipass.NewClient(cfg)
+	for i := 0; i < 2; i++ {
+		_, _ = client.CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "SKYHBL0001"})
+	}
+	_, err := client.CargoProgress(context.Background(), unipass.CargoQuery{HBLNo: "SKYHBL0001"})
+	if !errors.Is(err, unipass.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("server saw %d requests, want 2", got)
+	}
+}
@@ -0,0 +1,223 @@
+package unipass
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CargoProgressResponse is the body of retrieveCargCsclPrgsInfo
+// (화물통관진행정보조회).
+type CargoProgressResponse struct {
+	XMLName    xml.Name              `xml:"cargCsclPrgsInfoQryRtnVo"`
+	NoticeInfo string                `xml:"ntceInfo"`
+	TCnt       string                `xml:"tCnt"`
+	Cargos     []CargoProgress       `xml:"cargCsclPrgsInfoQryVo"`
+	Details    []CargoProgressDetail `xml:"cargCsclPrgsInfoDtlQryVo"`
+}
+
+// CargoProgress is one cargo summary (cargCsclPrgsInfoQryVo).
+type CargoProgress struct {
+	CargMtNo            string `xml:"cargMtNo"`
+	MblNo               string `xml:"mblNo"`
+	HblNo               string `xml:"hblNo"`
+	CsclPrgsStts        string `xml:"csclPrgsStts"`
+	PrgsStts            string `xml:"prgsStts"`
+	PrgsStCd            string `xml:"prgsStCd"`
+	PrcsDttm            string `xml:"prcsDttm"`
+	EtprDt              string `xml:"etprDt"`
+	EtprCstm            string `xml:"etprCstm"`
+	ShipNm              string `xml:"shipNm"`
+	ShipNat             string `xml:"shipNat"`
+	ShipNatNm           string `xml:"shipNatNm"`
+	Vydf                string `xml:"vydf"`
+	ShcoFlco            string `xml:"shcoFlco"`
+	ShcoFlcoSgn         string `xml:"shcoFlcoSgn"`
+	FrwrSgn             string `xml:"frwrSgn"`
+	FrwrEntsConm        string `xml:"frwrEntsConm"`
+	EntsKoreNm          string `xml:"entsKoreNm"`
+	Agnc                string `xml:"agnc"`
+	Prnm                string `xml:"prnm"`
+	CargTp              string `xml:"cargTp"`
+	BlPt                string `xml:"blPt"`
+	BlPtNm              string `xml:"blPtNm"`
+	LdprCd              string `xml:"ldprCd"`
+	LdprNm              string `xml:"ldprNm"`
+	LodCntyCd           string `xml:"lodCntyCd"`
+	DsprCd              string `xml:"dsprCd"`
+	DsprNm              string `xml:"dsprNm"`
+	PckGcnt             string `xml:"pckGcnt"`
+	PckUt               string `xml:"pckUt"`
+	Ttwg                string `xml:"ttwg"`
+	WghtUt              string `xml:"wghtUt"`
+	Msrm                string `xml:"msrm"`
+	CntrGcnt            string `xml:"cntrGcnt"`
+	CntrNo              string `xml:"cntrNo"`
+	SpcnCargCd          string `xml:"spcnCargCd"`
+	MtTrgtCargYnNm      string `xml:"mtTrgtCargYnNm"`
+	RlseDtyPridPassTpcd string `xml:"rlseDtyPridPassTpcd"`
+	DclrDelyAdtxYn      string `xml:"dclrDelyAdtxYn"`
+}
+
+// CargoProgressDetail is one processing step (cargCsclPrgsInfoDtlQryVo).
+type CargoProgressDetail struct {
+	CargTrcnRelaBsopTpcd string `xml:"cargTrcnRelaBsopTpcd"`
+	PrcsDttm             string `xml:"prcsDttm"`
+	RlbrDttm             string `xml:"rlbrDttm"`
+	RlbrCn               string `xml:"rlbrCn"`
+	RlbrBssNo            string `xml:"rlbrBssNo"`
+	DclrNo               string `xml:"dclrNo"`
+	ShedNm               string `xml:"shedNm"`
+	ShedSgn              string `xml:"shedSgn"`
+	PckGcnt              string `xml:"pckGcnt"`
+	PckUt                string `xml:"pckUt"`
+	Wght                 string `xml:"wght"`
+	WghtUt               string `xml:"wghtUt"`
+	BfhnGdncCn           string `xml:"bfhnGdncCn"`
+}
+
+func ParseCargoProgress(body string) (*CargoProgressResponse, error) {
+	var resp CargoProgressResponse
+	if err := xml.Unmarshal([]byte(strings.TrimSpace(body)), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// First returns the first cargo summary, or nil when the response has none.
+func (r *CargoProgressResponse) First() *CargoProgress {
+	if r == nil || len(r.Cargos) == 0 {
+		return nil
+	}
+	return &r.Cargos[0]
+}
+
+// Pick chooses the record meant for a BL when UNIPASS returns more than one:
+// the record chosen before (cargMtNo), else the only one loaded on the BL's
+// container, else the only one under the expected MBL. ok is false when the
+// response stays ambiguous and a user has to choose.
+func (r *CargoProgressResponse) Pick(cargMtNo string, containerNo string, mblNo string) (cargo *CargoProgress, ok bool) {
+	if r == nil || len(r.Cargos) == 0 {
+		return nil, true
+	}
+	if len(r.Cargos) == 1 {
+		return &r.Cargos[0], true
+	}
+
+	cargMtNo = strings.TrimSpace(cargMtNo)
+	if cargMtNo != "" {
+		for i := range r.Cargos {
+			if strings.TrimSpace(r.Cargos[i].CargMtNo) == cargMtNo {
+				return &r.Cargos[i], true
+			}
+		}
+	}
+
+	candidates := make([]*CargoProgress, 0, len(r.Cargos))
+	for i := range r.Cargos {
+		candidates = append(candidates, &r.Cargos[i])
+	}
+	if key := normalizeNo(containerNo); key != "" {
+		matched := filterCargos(candidates, func(c *CargoProgress) bool {
+			return strings.Contains(normalizeNo(c.CntrNo), key)
+		})
+		if len(matched) == 1 {
+			return matched[0], true
+		}
+		if len(matched) > 1 {
+			candidates = matched
+		}
+	}
+	if key := normalizeNo(mblNo); key != "" {
+		matched := filterCargos(candidates, func(c *CargoProgress) bool {
+			return normalizeNo(c.MblNo) == key
+		})
+		if len(matched) == 1 {
+			return matched[0], true
+		}
+	}
+	return nil, false
+}
+
+func filterCargos(list []*CargoProgress, keep func(*CargoProgress) bool) []*CargoProgress {
+	var matched []*CargoProgress
+	for _, item := range list {
+		if keep(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+func normalizeNo(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	return strings.NewReplacer(" ", "", "-", "").Replace(value)
+}
+
+func (c *CargoProgress) ArrivalDate() *time.Time {
+	return parseDate(c.EtprDt)
+}
+
+func (c *CargoProgress) ProcessedAt() *time.Time {
+	return parseDateTime(c.PrcsDttm)
+}
+
+func (c *CargoProgress) Weight() *float64 {
+	return parseFloat(c.Ttwg)
+}
+
+func (c *CargoProgress) PackageCount() *int {
+	value := strings.TrimSpace(c.PckGcnt)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// Forwarder prefers the forwarder company name and falls back to the
+// shipping company when UNIPASS leaves it out.
+func (c *CargoProgress) Forwarder() string {
+	if value := strings.TrimSpace(c.FrwrEntsConm); value != "" {
+		return value
+	}
+	return strings.TrimSpace(c.ShcoFlco)
+}
+
+func parseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if len(value) < 8 {
+		return nil
+	}
+	parsed, err := time.ParseInLocation("20060102", value[:8], time.Local)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+func parseDateTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if len(value) >= 14 {
+		if parsed, err := time.ParseInLocation("20060102150405", value[:14], time.Local); err == nil {
+			return &parsed
+		}
+	}
+	return parseDate(value)
+}
+
+func parseFloat(value string) *float64 {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
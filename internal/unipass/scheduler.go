@@ -0,0 +1,61 @@
+package unipass
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"skycontainers/internal/repo"
+)
+
+const (
+	defaultRefreshInterval = 6 * time.Hour
+	refreshBatchLimit      = 500
+)
+
+// refreshInterval reads UNIPASS_REFRESH_INTERVAL (e.g. "6h", "90m"). Zero
+// disables the scheduled refresh.
+func refreshInterval() time.Duration {
+	value := strings.TrimSpace(os.Getenv("UNIPASS_REFRESH_INTERVAL"))
+	if value == "" {
+		return defaultRefreshInterval
+	}
+	if value == "0" {
+		return 0
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		log.Printf("unipass scheduler invalid UNIPASS_REFRESH_INTERVAL=%q, using %s", value, defaultRefreshInterval)
+		return defaultRefreshInterval
+	}
+	return parsed
+}
+
+// runScheduler periodically queues refresh jobs for BLs still waiting on
+// customs, so status changes show up without anyone pressing 유니패스적용.
+func runScheduler(ctx context.Context, interval time.Duration) {
+	jobRepo := repo.UnipassJob{}
+	for {
+		queued, err := jobRepo.EnqueueRefresh(ctx, time.Now().Add(-interval), refreshBatchLimit)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("unipass scheduler enqueue failed err=%v", err)
+			}
+		} else if queued > 0 {
+			log.Printf("unipass scheduler queued %d refresh jobs", queued)
+		}
+
+		// Re-check more often than the interval so a backlog larger than
+		// refreshBatchLimit drains without waiting a full cycle.
+		wait := interval
+		if wait > 10*time.Minute {
+			wait = 10 * time.Minute
+		}
+		sleep(ctx, wait)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
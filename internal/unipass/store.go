@@ -0,0 +1,109 @@
+package unipass
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"skycontainers/internal/repo"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Lookup runs q for the current BL year and, when UNIPASS has no cargo for
+// it, the previous year: BLs issued in late December are usually looked up
+// in January. Queries by cargo management number or with an explicit year
+// are sent once.
+func Lookup(ctx context.Context, c Client, q CargoQuery) (string, error) {
+	if strings.TrimSpace(q.CargMtNo) != "" || q.BLYear != 0 {
+		return c.CargoProgress(ctx, q)
+	}
+	q.BLYear = time.Now().Year()
+	body, err := c.CargoProgress(ctx, q)
+	var resultErr *ResultError
+	if err == nil || !errors.As(err, &resultErr) || resultErr.TCnt != "0" {
+		return body, err
+	}
+	q.BLYear--
+	return c.CargoProgress(ctx, q)
+}
+
+func LookupHBL(ctx context.Context, c Client, hblNo string) (string, error) {
+	return Lookup(ctx, c, CargoQuery{HBLNo: hblNo})
+}
+
+// StoreResult saves a cargo progress body on a BL. When the body holds
+// several records it picks the one matching the BL, or stores them as
+// candidates for a user to choose on the edit screen.
+func StoreResult(ctx context.Context, blMarkingID int64, xmlBody string) error {
+	resp, err := ParseCargoProgress(xmlBody)
+	if err != nil {
+		return err
+	}
+	markingRepo := repo.BLMarking{}
+	if len(resp.Cargos) <= 1 {
+		return markingRepo.UpdateUnipassResult(ctx, blMarkingID, xmlBody, CargoRow(resp.First()))
+	}
+
+	marking, err := markingRepo.GetByID(ctx, blMarkingID)
+	if err != nil {
+		return err
+	}
+	cargoRepo := repo.BLUnipassCargo{}
+	var chosen string
+	current, err := cargoRepo.GetByBLMarkingID(ctx, blMarkingID)
+	if err == nil {
+		chosen = current.CargMtNo
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	mblNo, err := cargoRepo.ContainerMBLNo(ctx, blMarkingID)
+	if err != nil {
+		return err
+	}
+
+	if cargo, ok := resp.Pick(chosen, marking.ContainerNo, mblNo); ok {
+		return markingRepo.UpdateUnipassResult(ctx, blMarkingID, xmlBody, CargoRow(cargo))
+	}
+
+	candidates := make([]repo.BLUnipassCandidate, 0, len(resp.Cargos))
+	for i := range resp.Cargos {
+		candidates = append(candidates, CandidateRow(&resp.Cargos[i]))
+	}
+	log.Printf("unipass bl=%d hbl=%s returned %d records, waiting for selection", blMarkingID, marking.HBLNo, len(candidates))
+	return markingRepo.UpdateUnipassCandidates(ctx, blMarkingID, xmlBody, candidates)
+}
+
+// CargoRow maps a cargo record to its normalized bl_unipass_cargo row.
+func CargoRow(cargo *CargoProgress) *repo.BLUnipassCargo {
+	if cargo == nil {
+		return nil
+	}
+	return &repo.BLUnipassCargo{
+		CargMtNo:       strings.TrimSpace(cargo.CargMtNo),
+		MblNo:          strings.TrimSpace(cargo.MblNo),
+		ArrivalDate:    cargo.ArrivalDate(),
+		VesselName:     strings.TrimSpace(cargo.ShipNm),
+		Weight:         cargo.Weight(),
+		WeightUnit:     strings.TrimSpace(cargo.WghtUt),
+		PackageCount:   cargo.PackageCount(),
+		PackageUnit:    strings.TrimSpace(cargo.PckUt),
+		CustomsStatus:  strings.TrimSpace(cargo.CsclPrgsStts),
+		ProgressStatus: strings.TrimSpace(cargo.PrgsStts),
+		ProcessedAt:    cargo.ProcessedAt(),
+	}
+}
+
+func CandidateRow(cargo *CargoProgress) repo.BLUnipassCandidate {
+	return repo.BLUnipassCandidate{
+		CargMtNo:       strings.TrimSpace(cargo.CargMtNo),
+		MblNo:          strings.TrimSpace(cargo.MblNo),
+		CntrNo:         strings.TrimSpace(cargo.CntrNo),
+		ArrivalDate:    cargo.ArrivalDate(),
+		VesselName:     strings.TrimSpace(cargo.ShipNm),
+		CustomsStatus:  strings.TrimSpace(cargo.CsclPrgsStts),
+		ProgressStatus: strings.TrimSpace(cargo.PrgsStts),
+	}
+}
@@ -0,0 +1,62 @@
+package unipass
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNoAPIKey = errors.New("unipass api key is not configured")
+
+var ErrEmptyQuery = errors.New("hbl no, mbl no or cargo management no is required")
+
+// ResultError is returned when UNIPASS answered but the body reports an error
+// or no matching cargo.
+type ResultError struct {
+	ResultCode string
+	TCnt       string
+	Notice     string
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("unipass result error resultCode=%s tCnt=%s ntceInfo=%s", e.ResultCode, e.TCnt, e.Notice)
+}
+
+func ResultOK(xmlBody string) bool {
+	code := ExtractTagValue(xmlBody, "resultCode")
+	if code == "" {
+		return !isErrorBody(xmlBody)
+	}
+	code = strings.TrimSpace(code)
+	if code != "00" {
+		return false
+	}
+	return !isErrorBody(xmlBody)
+}
+
+func ExtractTagValue(xmlBody string, tag string) string {
+	startTag := "<" + tag + ">"
+	endTag := "</" + tag + ">"
+	start := strings.Index(xmlBody, startTag)
+	if start == -1 {
+		return ""
+	}
+	start += len(startTag)
+	end := strings.Index(xmlBody[start:], endTag)
+	if end == -1 {
+		return ""
+	}
+	return xmlBody[start : start+end]
+}
+
+func isErrorBody(xmlBody string) bool {
+	notice := strings.TrimSpace(ExtractTagValue(xmlBody, "ntceInfo"))
+	if notice != "" {
+		return true
+	}
+	tcnt := strings.TrimSpace(ExtractTagValue(xmlBody, "tCnt"))
+	if tcnt == "-1" || tcnt == "0" {
+		return true
+	}
+	return false
+}
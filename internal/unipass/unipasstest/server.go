@@ -0,0 +1,162 @@
+// Package unipasstest runs a local stand-in for the UNIPASS open API that
+// replays recorded XML fixtures, so code built on unipass.Client can be
+// exercised offline.
+package unipasstest
+
+import (
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"skycontainers/internal/unipass"
+)
+
+// Recorded responses of 화물통관진행정보조회.
+const (
+	FixtureSuccess  = "success"
+	FixtureNotice   = "ntce_info"
+	FixtureNoResult = "no_result"
+	FixtureMultiple = "multiple"
+	// FixtureMBLConsol lists three HBLs consolidated under SKYMBL0001.
+	FixtureMBLConsol = "mbl_consol"
+)
+
+// APIKey is the key the fake server accepts. Any other key gets the
+// ntceInfo fixture, like UNIPASS does for an invalid crkyCn.
+const APIKey = "unipasstest-key"
+
+//go:embed fixtures/*.xml
+var fixtures embed.FS
+
+// Fixture returns the raw XML of a recorded response.
+func Fixture(name string) string {
+	body, err := fixtures.ReadFile("fixtures/" + name + ".xml")
+	if err != nil {
+		panic("unipasstest: unknown fixture " + name)
+	}
+	return string(body)
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	byKey    map[string]string
+	fallback string
+	delay    time.Duration
+	requests []url.Values
+}
+
+// NewServer starts a fake server. Queries without a registered fixture get
+// FixtureNoResult. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		byKey:    map[string]string{},
+		fallback: FixtureNoResult,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Set registers the fixture returned for an HBL number.
+func (s *Server) Set(hblNo string, fixture string) {
+	s.set("hblNo", hblNo, fixture)
+}
+
+// SetMBL registers the fixture returned for an MBL number.
+func (s *Server) SetMBL(mblNo string, fixture string) {
+	s.set("mblNo", mblNo, fixture)
+}
+
+// SetCargMtNo registers the fixture returned for a cargo management number.
+func (s *Server) SetCargMtNo(cargMtNo string, fixture string) {
+	s.set("cargMtNo", cargMtNo, fixture)
+}
+
+func (s *Server) set(param string, value string, fixture string) {
+	Fixture(fixture)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byKey[lookupKey(param, value)] = fixture
+}
+
+func lookupKey(param string, value string) string {
+	return param + ":" + strings.ToUpper(strings.TrimSpace(value))
+}
+
+// SetFallback changes the fixture returned for unregistered HBLs.
+func (s *Server) SetFallback(fixture string) {
+	Fixture(fixture)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = fixture
+}
+
+// SetDelay holds every response for d, or until the client gives up, so
+// timeouts can be exercised.
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Requests returns the query strings received so far.
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]url.Values, len(s.requests))
+	copy(list, s.requests)
+	return list
+}
+
+// Config points a unipass client at this server.
+func (s *Server) Config() unipass.Config {
+	return unipass.Config{
+		BaseURL: s.URL,
+		APIKey:  APIKey,
+		Timeout: 5 * time.Second,
+	}
+}
+
+func (s *Server) Client() unipass.Client {
+	return unipass.NewClient(s.Config())
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	s.requests = append(s.requests, query)
+	// Match the way UNIPASS reads the query: cargMtNo first, then hblNo,
+	// then mblNo.
+	fixture := s.fallback
+	for _, param := range []string{"cargMtNo", "hblNo", "mblNo"} {
+		if value := query.Get(param); value != "" {
+			if registered, ok := s.byKey[lookupKey(param, value)]; ok {
+				fixture = registered
+			}
+			break
+		}
+	}
+	delay := s.delay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	if query.Get("crkyCn") != APIKey {
+		fixture = FixtureNotice
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = w.Write([]byte(Fixture(fixture)))
+}
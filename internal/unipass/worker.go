@@ -0,0 +1,179 @@
+package unipass
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"skycontainers/internal/repo"
+)
+
+const (
+	defaultWorkers  = 2
+	pollInterval    = 2 * time.Second
+	staleJobTimeout = 5 * time.Minute
+	baseBackoff     = 30 * time.Second
+	maxBackoff      = 30 * time.Minute
+	jobRetention    = 30 * 24 * time.Hour
+	pruneInterval   = time.Hour
+)
+
+// StartWorkers launches the UNIPASS job workers, plus the refresh scheduler,
+// and returns a function that blocks until they have stopped after ctx is
+// cancelled.
+func StartWorkers(ctx context.Context) func() {
+	count := defaultWorkers
+	if value := strings.TrimSpace(os.Getenv("UNIPASS_WORKERS")); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			count = parsed
+		}
+	}
+
+	jobRepo := repo.UnipassJob{}
+	if requeued, err := jobRepo.RequeueStale(ctx, staleJobTimeout); err != nil {
+		log.Printf("unipass worker requeue failed err=%v", err)
+	} else if requeued > 0 {
+		log.Printf("unipass worker requeued %d stale jobs", requeued)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			runWorker(ctx, id)
+		}(i + 1)
+	}
+	log.Printf("unipass workers started: %d", count)
+
+	if count > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runPruner(ctx)
+		}()
+	}
+
+	if interval := refreshInterval(); interval > 0 && count > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runScheduler(ctx, interval)
+		}()
+		log.Printf("unipass refresh scheduled every %s", interval)
+	}
+	return wg.Wait
+}
+
+func runWorker(ctx context.Context, id int) {
+	jobRepo := repo.UnipassJob{}
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		// Leave jobs queued while the breaker is open instead of claiming
+		// them only to fail fast.
+		if status, ok := CurrentStatus(); ok && status.State == BreakerOpen && time.Now().Before(status.RetryAt) {
+			sleep(ctx, pollInterval)
+			continue
+		}
+		job, err := jobRepo.ClaimNext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("unipass worker=%d claim failed err=%v", id, err)
+			}
+			sleep(ctx, pollInterval)
+			continue
+		}
+		if job == nil {
+			sleep(ctx, pollInterval)
+			continue
+		}
+		processJob(ctx, id, job)
+	}
+}
+
+func processJob(ctx context.Context, workerID int, job *repo.UnipassJob) {
+	jobRepo := repo.UnipassJob{}
+
+	xmlBody, err := LookupHBL(ctx, DefaultClient(), job.HBLNo)
+	if err == nil {
+		err = StoreResult(ctx, job.BLMarkingID, xmlBody)
+	}
+	if err == nil {
+		if markErr := jobRepo.MarkDone(context.Background(), job.ID); markErr != nil {
+			log.Printf("unipass worker=%d job=%d mark done failed err=%v", workerID, job.ID, markErr)
+		}
+		return
+	}
+
+	// A lookup cut short by shutdown is not the job's fault; hand it back
+	// without spending an attempt.
+	if ctx.Err() != nil {
+		if markErr := jobRepo.Defer(context.Background(), job.ID, err.Error(), time.Now()); markErr != nil {
+			log.Printf("unipass worker=%d job=%d defer failed err=%v", workerID, job.ID, markErr)
+		}
+		return
+	}
+
+	if errors.Is(err, ErrCircuitOpen) {
+		runAfter := time.Now().Add(pollInterval)
+		if status, ok := CurrentStatus(); ok && status.RetryAt.After(runAfter) {
+			runAfter = status.RetryAt
+		}
+		if markErr := jobRepo.Defer(context.Background(), job.ID, err.Error(), runAfter); markErr != nil {
+			log.Printf("unipass worker=%d job=%d defer failed err=%v", workerID, job.ID, markErr)
+		}
+		return
+	}
+
+	backoff := retryBackoff(job.Attempts)
+	if markErr := jobRepo.MarkRetry(context.Background(), job.ID, err.Error(), backoff); markErr != nil {
+		log.Printf("unipass worker=%d job=%d mark retry failed err=%v", workerID, job.ID, markErr)
+	}
+}
+
+// runPruner deletes finished jobs past jobRetention so the table does not
+// grow with every scheduled refresh.
+func runPruner(ctx context.Context) {
+	jobRepo := repo.UnipassJob{}
+	for {
+		pruned, err := jobRepo.PruneFinished(ctx, time.Now().Add(-jobRetention))
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("unipass pruner failed err=%v", err)
+			}
+		} else if pruned > 0 {
+			log.Printf("unipass pruner removed %d finished jobs/batches", pruned)
+		}
+		sleep(ctx, pruneInterval)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func retryBackoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return backoff
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
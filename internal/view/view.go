@@ -7,6 +7,7 @@ import (
 	"skycontainers/internal/auth"
 	"skycontainers/internal/http/middleware"
 	"skycontainers/internal/policy"
+	"skycontainers/internal/tariff"
 	"strconv"
 	"strings"
 	"time"
@@ -143,6 +144,7 @@ func InitTemplates() {
 			}
 			return *value == target
 		},
+		"formatAmount": tariff.FormatAmount,
 	}
 
 	for _, page := range pages {
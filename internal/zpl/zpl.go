@@ -0,0 +1,155 @@
+// Package zpl renders BL marking labels for Zebra-style thermal printers and
+// sends them to a printer over a raw TCP connection.
+package zpl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultPort is the raw printing port of network label printers.
+const DefaultPort = "9100"
+
+// DefaultTemplate prints a 100x50mm label at 203dpi: supplier and position on
+// top, the HBL as Code128, then consignee and marks. ^CI28 switches the
+// printer to UTF-8; Korean text also needs a Korean font on the printer.
+const DefaultTemplate = `^XA
+^CI28
+^PW800
+^LL400
+^FO30,25^A0N,34,34^FD{{.SupplierShort}}^FS
+^FO430,25^A0N,34,34^FB340,1,0,R^FD{{.Position}}^FS
+^FO30,75^BY2,3,90^BCN,90,Y,N,N^FD{{.HBL}}^FS
+^FO30,215^A0N,30,30^FB740,1,0,L^FD{{.Consignee}}^FS
+^FO30,255^A0N,44,44^FB740,3,0,L^FD{{.Marks}}^FS
+^XZ
+`
+
+// Label holds the values a template can use. Every value is already cleaned
+// of ZPL control characters, and Marks uses \& for line breaks inside ^FB.
+type Label struct {
+	HBL           string
+	Marks         string
+	Consignee     string
+	ContainerNo   string
+	Position      string
+	SupplierName  string
+	SupplierShort string
+}
+
+// NewLabel cleans raw values for use inside ^FD fields.
+func NewLabel(hbl, marks, consignee, containerNo, position, supplierName, supplierShort string) Label {
+	return Label{
+		HBL:           field(hbl),
+		Marks:         strings.Join(fieldLines(marks), `\&`),
+		Consignee:     field(consignee),
+		ContainerNo:   field(containerNo),
+		Position:      field(position),
+		SupplierName:  field(supplierName),
+		SupplierShort: field(chooseShort(supplierShort, supplierName)),
+	}
+}
+
+func chooseShort(short, name string) string {
+	if strings.TrimSpace(short) != "" {
+		return short
+	}
+	return name
+}
+
+// field removes the ZPL command prefixes and line breaks so data cannot end
+// the field or inject commands.
+func field(value string) string {
+	value = strings.NewReplacer("^", " ", "~", " ", "\r", " ", "\n", " ").Replace(value)
+	return strings.TrimSpace(value)
+}
+
+func fieldLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		if line = field(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Parse compiles a label template; an empty template means DefaultTemplate.
+func Parse(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("label").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("라벨 템플릿 형식이 올바르지 않습니다: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Validate checks that a template parses, renders with sample values and
+// contains a complete ^XA ... ^XZ label.
+func Validate(text string) error {
+	tmpl, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	out, err := Render(tmpl, NewLabel("HBL0001", "MARKS", "CNEE", "ABCU1234560", "A-1", "SUPPLIER", "SUP"))
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, "^XA") || !strings.Contains(out, "^XZ") {
+		return fmt.Errorf("라벨 템플릿에는 ^XA와 ^XZ가 있어야 합니다")
+	}
+	return nil
+}
+
+func Render(tmpl *template.Template, label Label) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, label); err != nil {
+		return "", fmt.Errorf("라벨을 만들 수 없습니다: %w", err)
+	}
+	out := strings.TrimSpace(b.String())
+	return out + "\n", nil
+}
+
+// PrinterAddress adds the raw printing port when the address has none.
+func PrinterAddress(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		// No port: a bare host, an IPv6 address or a bracketed one.
+		host, port = value, DefaultPort
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		}
+	}
+	if port == "" {
+		port = DefaultPort
+	}
+	if strings.TrimSpace(host) == "" {
+		return "", fmt.Errorf("프린터 주소가 올바르지 않습니다: %s", value)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Send writes the job to the printer and closes the connection.
+func Send(ctx context.Context, address string, data []byte) error {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("프린터(%s)에 연결할 수 없습니다: %w", address, err)
+	}
+	defer conn.Close()
+	_ = conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("프린터(%s)로 전송하지 못했습니다: %w", address, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package zpl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"HBL0001", "HBL0001"},
+		{"  HBL0001  ", "HBL0001"},
+		{"ABC^XZ^XA^FDX", "ABC XZ XA FDX"},
+		{"ABC~JA", "ABC JA"},
+		{"ABC\r\n^XZ", "ABC   XZ"},
+		{"^", ""},
+		{"한글 화주", "한글 화주"},
+	}
+	for _, tt := range tests {
+		if got := field(tt.value); got != tt.want {
+			t.Errorf("field(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNewLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		label Label
+		want  Label
+	}{
+		{
+			name:  "marks lines joined with \\&",
+			label: NewLabel("HBL1", "LINE 1\r\nLINE 2\n\n  \nLINE 3", "", "", "", "", ""),
+			want:  Label{HBL: "HBL1", Marks: `LINE 1\&LINE 2\&LINE 3`},
+		},
+		{
+			name:  "control characters stripped from every field",
+			label: NewLabel("H^XZ", "M~JA", "C^FS", "ABCU1234560\n^XA", "A-1~", "NAME^", "SHORT^"),
+			want: Label{
+				HBL:           "H XZ",
+				Marks:         "M JA",
+				Consignee:     "C FS",
+				ContainerNo:   "ABCU1234560  XA",
+				Position:      "A-1",
+				SupplierName:  "NAME",
+				SupplierShort: "SHORT",
+			},
+		},
+		{
+			name:  "short name falls back to the supplier name",
+			label: NewLabel("HBL1", "", "", "", "", "SUPPLIER", "  "),
+			want:  Label{HBL: "HBL1", SupplierName: "SUPPLIER", SupplierShort: "SUPPLIER"},
+		},
+	}
+	for _, tt := range tests {
+		if tt.label != tt.want {
+			t.Errorf("%s: NewLabel = %+v, want %+v", tt.name, tt.label, tt.want)
+		}
+	}
+}
+
+func TestRenderKeepsOneLabel(t *testing.T) {
+	tmpl, err := Parse("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Render(tmpl, NewLabel("HBL1", "^XZ^XA^FDINJECTED", "~JA", "", "", "SUPPLIER", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out, "^XA"); got != 1 {
+		t.Errorf("rendered label has %d ^XA, want 1:\n%s", got, out)
+	}
+	if got := strings.Count(out, "^XZ"); got != 1 {
+		t.Errorf("rendered label has %d ^XZ, want 1:\n%s", got, out)
+	}
+	if strings.Contains(out, "~") {
+		t.Errorf("rendered label contains ~:\n%s", out)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		valid    bool
+	}{
+		{"empty means the default template", "", true},
+		{"default template", DefaultTemplate, true},
+		{"minimal label", "^XA^FO10,10^FD{{.HBL}}^FS^XZ", true},
+		{"missing ^XZ", "^XA^FO10,10^FD{{.HBL}}^FS", false},
+		{"missing ^XA", "^FO10,10^FD{{.HBL}}^FS^XZ", false},
+		{"unknown field", "^XA^FD{{.Unknown}}^FS^XZ", false},
+		{"broken template", "^XA^FD{{.HBL}^FS^XZ", false},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.template)
+		if tt.valid && err != nil {
+			t.Errorf("%s: Validate = %v, want nil", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: Validate = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestPrinterAddress(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		valid bool
+	}{
+		{"", "", true},
+		{"192.168.0.50", "192.168.0.50:9100", true},
+		{" 192.168.0.50:6101 ", "192.168.0.50:6101", true},
+		{"printer.local", "printer.local:9100", true},
+		{"printer.local:", "printer.local:9100", true},
+		{"fe80::1", "[fe80::1]:9100", true},
+		{"[fe80::1]", "[fe80::1]:9100", true},
+		{"[fe80::1]:6101", "[fe80::1]:6101", true},
+		{":9100", "", false},
+	}
+	for _, tt := range tests {
+		got, err := PrinterAddress(tt.value)
+		if tt.valid != (err == nil) {
+			t.Errorf("PrinterAddress(%q) error = %v, want valid %v", tt.value, err, tt.valid)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("PrinterAddress(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS "unipass_job_batches"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "user_id" BIGINT NOT NULL,
+    "source" VARCHAR(20) CHECK
+        ("source" IN('upload', 'apply')) NOT NULL,
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+ALTER TABLE
+    "unipass_job_batches" ADD CONSTRAINT "unipass_job_batches_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "users"("id");
+
+CREATE TABLE IF NOT EXISTS "unipass_jobs"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "batch_id" BIGINT,
+    "bl_marking_id" BIGINT NOT NULL,
+    "hbl_no" VARCHAR(255) NOT NULL,
+    "status" VARCHAR(20) CHECK
+        (
+            "status" IN('pending', 'running', 'done', 'failed')
+        ) NOT NULL DEFAULT 'pending',
+        "attempts" INTEGER NOT NULL DEFAULT 0,
+        "max_attempts" INTEGER NOT NULL DEFAULT 5,
+        "last_error" TEXT,
+        "run_after" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL,
+        "started_at" TIMESTAMP(0)
+    WITH
+        TIME zone,
+        "finished_at" TIMESTAMP(0)
+    WITH
+        TIME zone,
+        "created_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL,
+        "updated_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "unipass_jobs_status_run_after_index" ON
+    "unipass_jobs"("status", "run_after");
+CREATE INDEX IF NOT EXISTS "unipass_jobs_batch_id_index" ON
+    "unipass_jobs"("batch_id");
+ALTER TABLE
+    "unipass_jobs" ADD CONSTRAINT "unipass_jobs_batch_id_foreign" FOREIGN KEY("batch_id") REFERENCES "unipass_job_batches"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "unipass_jobs" ADD CONSTRAINT "unipass_jobs_bl_marking_id_foreign" FOREIGN KEY("bl_marking_id") REFERENCES "bl_markings"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "unipass_jobs"."status" IS '대기,처리중,완료,실패';
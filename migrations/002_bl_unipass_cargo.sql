@@ -0,0 +1,63 @@
+CREATE TABLE IF NOT EXISTS "bl_unipass_cargo"(
+    "bl_marking_id" BIGINT NOT NULL PRIMARY KEY,
+    "carg_mt_no" VARCHAR(50),
+    "mbl_no" VARCHAR(50),
+    "arrival_date" DATE,
+    "vessel_name" VARCHAR(255),
+    "weight" NUMERIC(14, 3),
+    "weight_unit" VARCHAR(10),
+    "package_count" INTEGER,
+    "package_unit" VARCHAR(10),
+    "customs_status" VARCHAR(100),
+    "progress_status" VARCHAR(100),
+    "processed_at" TIMESTAMP(0) WITH
+        TIME zone,
+        "fetched_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "bl_unipass_cargo_customs_status_index" ON
+    "bl_unipass_cargo"("customs_status");
+CREATE INDEX IF NOT EXISTS "bl_unipass_cargo_mbl_no_index" ON
+    "bl_unipass_cargo"("mbl_no");
+ALTER TABLE
+    "bl_unipass_cargo" ADD CONSTRAINT "bl_unipass_cargo_bl_marking_id_foreign" FOREIGN KEY("bl_marking_id") REFERENCES "bl_markings"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "bl_unipass_cargo"."customs_status" IS '통관진행상태 (csclPrgsStts)';
+COMMENT
+ON COLUMN
+    "bl_unipass_cargo"."progress_status" IS '진행상태 (prgsStts)';
+
+-- Backfill from the XML already stored on bl_markings.
+INSERT INTO "bl_unipass_cargo"
+    (bl_marking_id, carg_mt_no, mbl_no, arrival_date, vessel_name, weight, weight_unit,
+     package_count, package_unit, customs_status, progress_status, fetched_at)
+SELECT src.id,
+       NULLIF(src.carg_mt_no, ''),
+       NULLIF(src.mbl_no, ''),
+       CASE WHEN src.etpr_dt ~ '^[0-9]{8}' THEN to_date(left(src.etpr_dt, 8), 'YYYYMMDD') END,
+       NULLIF(src.ship_nm, ''),
+       CASE WHEN src.ttwg ~ '^[0-9]+(\.[0-9]+)?$' THEN src.ttwg::NUMERIC END,
+       NULLIF(src.wght_ut, ''),
+       CASE WHEN src.pck_gcnt ~ '^[0-9]+$' THEN src.pck_gcnt::INTEGER END,
+       NULLIF(src.pck_ut, ''),
+       NULLIF(src.cscl_prgs_stts, ''),
+       NULLIF(src.prgs_stts, ''),
+       src.updated_at
+  FROM (
+    SELECT b.id, b.updated_at,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/cargMtNo/text()', b.frm_unipass))[1]::text) AS carg_mt_no,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/mblNo/text()', b.frm_unipass))[1]::text) AS mbl_no,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/etprDt/text()', b.frm_unipass))[1]::text) AS etpr_dt,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/shipNm/text()', b.frm_unipass))[1]::text) AS ship_nm,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/ttwg/text()', b.frm_unipass))[1]::text) AS ttwg,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/wghtUt/text()', b.frm_unipass))[1]::text) AS wght_ut,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/pckGcnt/text()', b.frm_unipass))[1]::text) AS pck_gcnt,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/pckUt/text()', b.frm_unipass))[1]::text) AS pck_ut,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/csclPrgsStts/text()', b.frm_unipass))[1]::text) AS cscl_prgs_stts,
+           trim((xpath('//cargCsclPrgsInfoQryVo[1]/prgsStts/text()', b.frm_unipass))[1]::text) AS prgs_stts
+      FROM bl_markings b
+     WHERE b.frm_unipass IS NOT NULL
+  ) src
+ON CONFLICT (bl_marking_id) DO NOTHING;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS "bl_unipass_events"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "bl_marking_id" BIGINT NOT NULL,
+    "customs_status" VARCHAR(100),
+    "progress_status" VARCHAR(100),
+    "processed_at" TIMESTAMP(0) WITH
+        TIME zone,
+        "created_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "bl_unipass_events_bl_marking_id_index" ON
+    "bl_unipass_events"("bl_marking_id", "created_at");
+ALTER TABLE
+    "bl_unipass_events" ADD CONSTRAINT "bl_unipass_events_bl_marking_id_foreign" FOREIGN KEY("bl_marking_id") REFERENCES "bl_markings"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "bl_unipass_events"."customs_status" IS '변경 후 통관진행상태';
+COMMENT
+ON COLUMN
+    "bl_unipass_events"."progress_status" IS '변경 후 진행상태';
+COMMENT
+ON COLUMN
+    "bl_unipass_events"."processed_at" IS '유니패스 처리일시 (prcsDttm)';
+
+-- Seed the history with the status already stored for each BL.
+INSERT INTO "bl_unipass_events"
+    (bl_marking_id, customs_status, progress_status, processed_at, created_at)
+SELECT bl_marking_id, customs_status, progress_status, processed_at, fetched_at
+  FROM bl_unipass_cargo
+ WHERE customs_status IS NOT NULL OR progress_status IS NOT NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "bl_unipass_candidates"(
+    "bl_marking_id" BIGINT NOT NULL,
+    "carg_mt_no" VARCHAR(50) NOT NULL,
+    "mbl_no" VARCHAR(50),
+    "cntr_no" VARCHAR(255),
+    "arrival_date" DATE,
+    "vessel_name" VARCHAR(255),
+    "customs_status" VARCHAR(100),
+    "progress_status" VARCHAR(100),
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+        PRIMARY KEY("bl_marking_id", "carg_mt_no")
+);
+ALTER TABLE
+    "bl_unipass_candidates" ADD CONSTRAINT "bl_unipass_candidates_bl_marking_id_foreign" FOREIGN KEY("bl_marking_id") REFERENCES "bl_markings"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "bl_unipass_candidates"."carg_mt_no" IS '화물관리번호 (여러 건 조회 시 사용자 선택 대기)';
@@ -0,0 +1,4 @@
+ALTER TABLE
+    "unipass_job_batches" DROP CONSTRAINT IF EXISTS "unipass_job_batches_source_check";
+ALTER TABLE
+    "unipass_job_batches" ADD CONSTRAINT "unipass_job_batches_source_check" CHECK("source" IN('upload', 'apply', 'mbl'));
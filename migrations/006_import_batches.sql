@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS "import_batches"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "user_id" BIGINT NOT NULL,
+    "container_id" BIGINT,
+    "bl_position_id" BIGINT,
+    "file_name" VARCHAR(255) NOT NULL,
+    "status" VARCHAR(20) CHECK
+        (
+            "status" IN('preview', 'committed', 'cancelled')
+        ) NOT NULL DEFAULT 'preview',
+        "unipass_job_batch_id" BIGINT,
+        "created_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL,
+        "committed_at" TIMESTAMP(0)
+    WITH
+        TIME zone
+);
+CREATE INDEX IF NOT EXISTS "import_batches_status_index" ON
+    "import_batches"("status", "created_at");
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "users"("id");
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_container_id_foreign" FOREIGN KEY("container_id") REFERENCES "containers"("id");
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_bl_position_id_foreign" FOREIGN KEY("bl_position_id") REFERENCES "bl_positions"("id");
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_unipass_job_batch_id_foreign" FOREIGN KEY("unipass_job_batch_id") REFERENCES "unipass_job_batches"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "import_batches"."status" IS '미리보기,확정,취소';
+
+CREATE TABLE IF NOT EXISTS "import_batch_rows"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "batch_id" BIGINT NOT NULL,
+    "row_no" INTEGER NOT NULL,
+    "hbl_no" VARCHAR(255) NOT NULL,
+    "cnee" VARCHAR(255) NOT NULL,
+    "marks" VARCHAR(255) NOT NULL,
+    "container_id" BIGINT,
+    "bl_position_id" BIGINT,
+    "action" VARCHAR(20) CHECK
+        (
+            "action" IN('new', 'update', 'skip', 'error')
+        ) NOT NULL,
+        "message" TEXT NOT NULL,
+        "bl_marking_id" BIGINT,
+        "old_container_id" BIGINT,
+        "old_bl_position_id" BIGINT,
+        "old_marks" VARCHAR(255),
+        "old_cnee" VARCHAR(255)
+);
+CREATE INDEX IF NOT EXISTS "import_batch_rows_batch_id_index" ON
+    "import_batch_rows"("batch_id", "row_no");
+ALTER TABLE
+    "import_batch_rows" ADD CONSTRAINT "import_batch_rows_batch_id_foreign" FOREIGN KEY("batch_id") REFERENCES "import_batches"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "import_batch_rows" ADD CONSTRAINT "import_batch_rows_bl_marking_id_foreign" FOREIGN KEY("bl_marking_id") REFERENCES "bl_markings"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "import_batch_rows"."row_no" IS '엑셀 행 번호';
+COMMENT
+ON COLUMN
+    "import_batch_rows"."action" IS '신규,갱신,제외,오류';
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS "bl_import_profiles"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "supplier_id" BIGINT NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    "sheet_name" VARCHAR(255) NOT NULL DEFAULT '',
+    "header_row" INTEGER NOT NULL DEFAULT 1,
+    "hbl_column" VARCHAR(255) NOT NULL,
+    "cnee_column" VARCHAR(255) NOT NULL DEFAULT '',
+    "marks_column" VARCHAR(255) NOT NULL,
+    "hbl_transforms" VARCHAR(255) NOT NULL DEFAULT '',
+    "cnee_transforms" VARCHAR(255) NOT NULL DEFAULT '',
+    "marks_transforms" VARCHAR(255) NOT NULL DEFAULT '',
+    "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+        "updated_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "bl_import_profiles_supplier_id_index" ON
+    "bl_import_profiles"("supplier_id");
+ALTER TABLE
+    "bl_import_profiles" ADD CONSTRAINT "bl_import_profiles_supplier_id_foreign" FOREIGN KEY("supplier_id") REFERENCES "suppliers"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "bl_import_profiles"."sheet_name" IS '시트명 (비어 있으면 첫 시트)';
+COMMENT
+ON COLUMN
+    "bl_import_profiles"."header_row" IS '헤더 행 번호 (0이면 헤더 없음)';
+COMMENT
+ON COLUMN
+    "bl_import_profiles"."hbl_column" IS '헤더명 또는 열 문자';
+COMMENT
+ON COLUMN
+    "bl_import_profiles"."hbl_transforms" IS '쉼표로 구분한 변환 목록';
+
+ALTER TABLE
+    "import_batches" ADD COLUMN IF NOT EXISTS "import_profile_id" BIGINT;
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_import_profile_id_foreign" FOREIGN KEY("import_profile_id") REFERENCES "bl_import_profiles"("id") ON DELETE SET NULL;
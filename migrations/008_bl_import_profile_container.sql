@@ -0,0 +1,11 @@
+ALTER TABLE
+    "bl_import_profiles" ADD COLUMN IF NOT EXISTS "container_column" VARCHAR(255) NOT NULL DEFAULT '';
+COMMENT
+ON COLUMN
+    "bl_import_profiles"."container_column" IS '컨테이너 번호 열 (비어 있으면 업로드 화면에서 선택한 컨테이너)';
+
+ALTER TABLE
+    "import_batch_rows" ADD COLUMN IF NOT EXISTS "source_container_no" VARCHAR(255) NOT NULL DEFAULT '';
+COMMENT
+ON COLUMN
+    "import_batch_rows"."source_container_no" IS '파일에 적힌 컨테이너 번호';
@@ -0,0 +1,31 @@
+ALTER TABLE
+    "bl_markings" ADD COLUMN IF NOT EXISTS "import_batch_id" BIGINT;
+ALTER TABLE
+    "bl_markings" ADD CONSTRAINT "bl_markings_import_batch_id_foreign" FOREIGN KEY("import_batch_id") REFERENCES "import_batches"("id") ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS "bl_markings_import_batch_id_index" ON
+    "bl_markings"("import_batch_id");
+COMMENT
+ON COLUMN
+    "bl_markings"."import_batch_id" IS '마지막으로 반영한 업로드';
+
+ALTER TABLE
+    "import_batch_rows" ADD COLUMN IF NOT EXISTS "old_user_id" BIGINT;
+ALTER TABLE
+    "import_batch_rows" ADD COLUMN IF NOT EXISTS "old_import_batch_id" BIGINT;
+
+ALTER TABLE
+    "import_batches" DROP CONSTRAINT IF EXISTS "import_batches_status_check";
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_status_check" CHECK
+    (
+        "status" IN('preview', 'committed', 'cancelled', 'rolled_back')
+    );
+ALTER TABLE
+    "import_batches" ADD COLUMN IF NOT EXISTS "rolled_back_at" TIMESTAMP(0) WITH TIME zone;
+ALTER TABLE
+    "import_batches" ADD COLUMN IF NOT EXISTS "rolled_back_by" BIGINT;
+ALTER TABLE
+    "import_batches" ADD CONSTRAINT "import_batches_rolled_back_by_foreign" FOREIGN KEY("rolled_back_by") REFERENCES "users"("id");
+COMMENT
+ON COLUMN
+    "import_batches"."status" IS '미리보기,확정,취소,되돌림';
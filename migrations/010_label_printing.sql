@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "app_settings"(
+    "key" VARCHAR(100) NOT NULL PRIMARY KEY,
+    "value" TEXT NOT NULL DEFAULT '',
+    "updated_by" BIGINT,
+    "updated_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+ALTER TABLE
+    "app_settings" ADD CONSTRAINT "app_settings_updated_by_foreign" FOREIGN KEY("updated_by") REFERENCES "users"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "app_settings"."key" IS '설정 키 (예: label_printer_addr)';
+
+ALTER TABLE
+    "suppliers" ADD COLUMN IF NOT EXISTS "zpl_template" TEXT;
+COMMENT
+ON COLUMN
+    "suppliers"."zpl_template" IS 'ZPL 라벨 템플릿 (비어 있으면 기본 템플릿)';
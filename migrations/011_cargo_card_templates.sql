@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "cargo_card_templates"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "supplier_id" BIGINT NOT NULL,
+    "title" VARCHAR(255) NOT NULL DEFAULT '',
+    "fields" VARCHAR(500) NOT NULL DEFAULT '',
+    "extra_fields" TEXT NOT NULL DEFAULT '',
+    "show_overlay" BOOLEAN NOT NULL DEFAULT TRUE,
+    "logo" BYTEA,
+    "logo_content_type" VARCHAR(50) NOT NULL DEFAULT '',
+    "updated_by" BIGINT,
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+        "updated_at" TIMESTAMP(0)
+    WITH
+        TIME zone NOT NULL
+);
+ALTER TABLE
+    "cargo_card_templates" ADD CONSTRAINT "cargo_card_templates_supplier_id_unique" UNIQUE("supplier_id");
+ALTER TABLE
+    "cargo_card_templates" ADD CONSTRAINT "cargo_card_templates_supplier_id_foreign" FOREIGN KEY("supplier_id") REFERENCES "suppliers"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "cargo_card_templates" ADD CONSTRAINT "cargo_card_templates_updated_by_foreign" FOREIGN KEY("updated_by") REFERENCES "users"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "cargo_card_templates"."title" IS '카드 제목 (비어 있으면 수입화물품목카드)';
+COMMENT
+ON COLUMN
+    "cargo_card_templates"."fields" IS '출력 항목 키를 순서대로 쉼표로 구분';
+COMMENT
+ON COLUMN
+    "cargo_card_templates"."extra_fields" IS '추가 항목 (한 줄에 "항목: 값")';
+COMMENT
+ON COLUMN
+    "cargo_card_templates"."show_overlay" IS '수량 워터마크 표시 여부';
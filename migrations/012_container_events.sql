@@ -0,0 +1,53 @@
+ALTER TABLE
+    "containers" ALTER COLUMN "inbound_date" DROP NOT NULL;
+ALTER TABLE
+    "containers" ALTER COLUMN "processing_date" DROP NOT NULL;
+ALTER TABLE
+    "containers" ALTER COLUMN "outbound_date" DROP NOT NULL;
+ALTER TABLE
+    "containers" ADD COLUMN IF NOT EXISTS "state" VARCHAR(20) CHECK
+    (
+        "state" IN('announced', 'gated_in', 'devanning', 'devanned', 'gated_out')
+    ) NOT NULL DEFAULT 'announced';
+CREATE INDEX IF NOT EXISTS "containers_state_index" ON
+    "containers"("state");
+COMMENT
+ON COLUMN
+    "containers"."state" IS '입고예정,입고,작업중,작업완료,출고';
+
+-- Existing rows get the state their dates imply.
+UPDATE "containers"
+   SET "state" = CASE
+       WHEN outbound_date IS NOT NULL THEN 'gated_out'
+       WHEN processing_date IS NOT NULL THEN 'devanned'
+       WHEN inbound_date IS NOT NULL THEN 'gated_in'
+       ELSE 'announced'
+   END;
+
+CREATE TABLE IF NOT EXISTS "container_events"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "container_id" BIGINT NOT NULL,
+    "event" VARCHAR(30) NOT NULL,
+    "from_state" VARCHAR(20) NOT NULL DEFAULT '',
+    "to_state" VARCHAR(20) NOT NULL,
+    "event_date" DATE,
+    "reason" TEXT NOT NULL DEFAULT '',
+    "user_id" BIGINT,
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "container_events_container_id_index" ON
+    "container_events"("container_id", "created_at");
+ALTER TABLE
+    "container_events" ADD CONSTRAINT "container_events_container_id_foreign" FOREIGN KEY("container_id") REFERENCES "containers"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "container_events" ADD CONSTRAINT "container_events_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "container_events"."event" IS '전이 종류 (gate_in, devan, gate_out, undo_gate_in 등)';
+COMMENT
+ON COLUMN
+    "container_events"."event_date" IS '전이로 기록한 입고일/작업일/출고일';
+COMMENT
+ON COLUMN
+    "container_events"."reason" IS '취소 사유';
@@ -0,0 +1,79 @@
+CREATE TABLE IF NOT EXISTS "gate_transactions"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "direction" VARCHAR(10) CHECK
+    ("direction" IN('in', 'out', 'unknown')) NOT NULL,
+    "truck_plate" VARCHAR(255) NOT NULL,
+    "driver_name" VARCHAR(255) NOT NULL DEFAULT '',
+    "driver_phone" VARCHAR(50) NOT NULL DEFAULT '',
+    "gate_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+    "memo" TEXT NOT NULL DEFAULT '',
+    "user_id" BIGINT,
+    "carnumber_id" BIGINT,
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "gate_transactions_gate_at_index" ON
+    "gate_transactions"("gate_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "gate_transactions_carnumber_id_unique" ON
+    "gate_transactions"("carnumber_id");
+ALTER TABLE
+    "gate_transactions" ADD CONSTRAINT "gate_transactions_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL;
+ALTER TABLE
+    "gate_transactions" ADD CONSTRAINT "gate_transactions_carnumber_id_foreign" FOREIGN KEY("carnumber_id") REFERENCES "carnumbers"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "gate_transactions"."direction" IS '반입,반출,구분없음(이전 차량번호 목록)';
+COMMENT
+ON COLUMN
+    "gate_transactions"."carnumber_id" IS '이전한 차량번호 목록 행';
+COMMENT
+ON COLUMN
+    "gate_transactions"."gate_at" IS '게이트 통과 일시';
+
+CREATE TABLE IF NOT EXISTS "gate_transaction_containers"(
+    "gate_transaction_id" BIGINT NOT NULL,
+    "container_id" BIGINT NOT NULL,
+    "seal_no" VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY("gate_transaction_id", "container_id")
+);
+CREATE INDEX IF NOT EXISTS "gate_transaction_containers_container_id_index" ON
+    "gate_transaction_containers"("container_id");
+ALTER TABLE
+    "gate_transaction_containers" ADD CONSTRAINT "gate_transaction_containers_gate_transaction_id_foreign" FOREIGN KEY("gate_transaction_id") REFERENCES "gate_transactions"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "gate_transaction_containers" ADD CONSTRAINT "gate_transaction_containers_container_id_foreign" FOREIGN KEY("container_id") REFERENCES "containers"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "gate_transaction_containers"."seal_no" IS '씰 번호';
+
+-- The old car number log becomes plate-only gate entries. It never recorded
+-- whether the truck came in or left, so they are imported as 'unknown'.
+-- log_date is free text; a value that is not a real date (e.g. 20240230)
+-- yields NULL instead of aborting the migration, and that row stays in
+-- carnumbers, where the gate report offers it as a download.
+CREATE FUNCTION pg_temp.carnumber_log_date(value TEXT) RETURNS DATE AS $$
+DECLARE
+    digits TEXT := regexp_replace(value, '[^0-9]', '', 'g');
+BEGIN
+    IF digits !~ '^[0-9]{8}$' THEN
+        RETURN NULL;
+    END IF;
+    RETURN digits::date;
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO "gate_transactions" (direction, truck_plate, gate_at, memo, carnumber_id, created_at)
+SELECT 'unknown',
+       n.car_no,
+       n.log_day,
+       '차량번호 목록에서 이전 (반입/반출 구분 없음)',
+       n.id,
+       n.created_at
+  FROM (
+      SELECT id, car_no, created_at, pg_temp.carnumber_log_date(log_date) AS log_day
+        FROM "carnumbers"
+  ) n
+ WHERE n.log_day IS NOT NULL;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS "storage_tariffs"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "supplier_id" BIGINT,
+    "containers_type_id" BIGINT,
+    "currency" VARCHAR(3) NOT NULL DEFAULT 'KRW',
+    "free_days" INTEGER NOT NULL DEFAULT 0 CHECK("free_days" >= 0),
+    "memo" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+    "updated_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+-- One tariff per supplier/type pair; NULL means "any" and is matched last.
+CREATE UNIQUE INDEX IF NOT EXISTS "storage_tariffs_scope_unique" ON
+    "storage_tariffs"(COALESCE("supplier_id", 0), COALESCE("containers_type_id", 0));
+ALTER TABLE
+    "storage_tariffs" ADD CONSTRAINT "storage_tariffs_supplier_id_foreign" FOREIGN KEY("supplier_id") REFERENCES "suppliers"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "storage_tariffs" ADD CONSTRAINT "storage_tariffs_containers_type_id_foreign" FOREIGN KEY("containers_type_id") REFERENCES "container_types"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "storage_tariffs"."supplier_id" IS '비어 있으면 전체 업체';
+COMMENT
+ON COLUMN
+    "storage_tariffs"."containers_type_id" IS '비어 있으면 전체 규격';
+COMMENT
+ON COLUMN
+    "storage_tariffs"."free_days" IS '입고일부터 무료 보관 일수';
+
+CREATE TABLE IF NOT EXISTS "storage_tariff_tiers"(
+    "storage_tariff_id" BIGINT NOT NULL,
+    "from_day" INTEGER NOT NULL CHECK("from_day" >= 1),
+    "rate" BIGINT NOT NULL CHECK("rate" >= 0),
+    PRIMARY KEY("storage_tariff_id", "from_day")
+);
+ALTER TABLE
+    "storage_tariff_tiers" ADD CONSTRAINT "storage_tariff_tiers_storage_tariff_id_foreign" FOREIGN KEY("storage_tariff_id") REFERENCES "storage_tariffs"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "storage_tariff_tiers"."from_day" IS '이 요율이 시작되는 보관 일차 (입고일 = 1일차)';
+COMMENT
+ON COLUMN
+    "storage_tariff_tiers"."rate" IS '일 요금 (통화 최소 단위, KRW는 원)';
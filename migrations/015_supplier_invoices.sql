@@ -0,0 +1,93 @@
+CREATE TABLE IF NOT EXISTS "invoice_rates"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "supplier_id" BIGINT,
+    "currency" VARCHAR(3) NOT NULL DEFAULT 'KRW',
+    "bl_fee" BIGINT NOT NULL DEFAULT 0 CHECK("bl_fee" >= 0),
+    "memo" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+    "updated_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+-- One rate card per supplier; the NULL row is the default for everyone else.
+CREATE UNIQUE INDEX IF NOT EXISTS "invoice_rates_supplier_unique" ON
+    "invoice_rates"(COALESCE("supplier_id", 0));
+ALTER TABLE
+    "invoice_rates" ADD CONSTRAINT "invoice_rates_supplier_id_foreign" FOREIGN KEY("supplier_id") REFERENCES "suppliers"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "invoice_rates"."bl_fee" IS 'BL 1건당 요금 (통화 최소 단위)';
+
+CREATE TABLE IF NOT EXISTS "invoice_rate_handling_fees"(
+    "invoice_rate_id" BIGINT NOT NULL,
+    "length_ft" SMALLINT NOT NULL,
+    "amount" BIGINT NOT NULL CHECK("amount" >= 0),
+    PRIMARY KEY("invoice_rate_id", "length_ft")
+);
+ALTER TABLE
+    "invoice_rate_handling_fees" ADD CONSTRAINT "invoice_rate_handling_fees_invoice_rate_id_foreign" FOREIGN KEY("invoice_rate_id") REFERENCES "invoice_rates"("id") ON DELETE CASCADE;
+COMMENT
+ON COLUMN
+    "invoice_rate_handling_fees"."amount" IS '컨테이너 1개당 작업료 (container_types.length_ft 기준)';
+
+CREATE TABLE IF NOT EXISTS "supplier_invoices"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "invoice_no" VARCHAR(50) NOT NULL,
+    "supplier_id" BIGINT NOT NULL,
+    "period_start" DATE NOT NULL,
+    "period_end" DATE NOT NULL,
+    "currency" VARCHAR(3) NOT NULL,
+    "status" VARCHAR(20) CHECK
+    ("status" IN('draft', 'issued', 'paid')) NOT NULL DEFAULT 'draft',
+    "total" BIGINT NOT NULL DEFAULT 0,
+    "memo" TEXT NOT NULL DEFAULT '',
+    "issued_at" TIMESTAMP(0) WITH
+        TIME zone,
+    "paid_at" TIMESTAMP(0) WITH
+        TIME zone,
+    "user_id" BIGINT,
+    "created_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL,
+    "updated_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "supplier_invoices_invoice_no_unique" ON
+    "supplier_invoices"("invoice_no");
+CREATE UNIQUE INDEX IF NOT EXISTS "supplier_invoices_period_unique" ON
+    "supplier_invoices"("supplier_id", "period_start", "currency");
+ALTER TABLE
+    "supplier_invoices" ADD CONSTRAINT "supplier_invoices_supplier_id_foreign" FOREIGN KEY("supplier_id") REFERENCES "suppliers"("id");
+ALTER TABLE
+    "supplier_invoices" ADD CONSTRAINT "supplier_invoices_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "supplier_invoices"."status" IS '작성중,발행,입금완료';
+COMMENT
+ON COLUMN
+    "supplier_invoices"."total" IS '합계 (통화 최소 단위)';
+
+CREATE TABLE IF NOT EXISTS "supplier_invoice_lines"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "invoice_id" BIGINT NOT NULL,
+    "kind" VARCHAR(20) CHECK
+    ("kind" IN('handling', 'bl', 'storage')) NOT NULL,
+    "container_id" BIGINT,
+    "container_no" VARCHAR(255) NOT NULL DEFAULT '',
+    "description" VARCHAR(255) NOT NULL DEFAULT '',
+    "quantity" INTEGER NOT NULL DEFAULT 1,
+    "unit_price" BIGINT NOT NULL DEFAULT 0,
+    "amount" BIGINT NOT NULL DEFAULT 0,
+    "sort_order" INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS "supplier_invoice_lines_invoice_id_index" ON
+    "supplier_invoice_lines"("invoice_id", "sort_order");
+ALTER TABLE
+    "supplier_invoice_lines" ADD CONSTRAINT "supplier_invoice_lines_invoice_id_foreign" FOREIGN KEY("invoice_id") REFERENCES "supplier_invoices"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "supplier_invoice_lines" ADD CONSTRAINT "supplier_invoice_lines_container_id_foreign" FOREIGN KEY("container_id") REFERENCES "containers"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "supplier_invoice_lines"."kind" IS '작업료,BL 수수료,보관료';
+COMMENT
+ON COLUMN
+    "supplier_invoice_lines"."container_no" IS '발행 후 컨테이너가 바뀌어도 남도록 복사해 둔 번호';
@@ -0,0 +1,40 @@
+-- BL positions become warehouse locations: zone > row > rack > level.
+-- Existing flat positions stay as top-level nodes.
+ALTER TABLE
+    "bl_positions" ADD COLUMN IF NOT EXISTS "parent_id" BIGINT;
+ALTER TABLE
+    "bl_positions" ADD COLUMN IF NOT EXISTS "location_type" VARCHAR(20) CHECK
+    ("location_type" IN('zone', 'row', 'rack', 'level')) NOT NULL DEFAULT 'zone';
+ALTER TABLE
+    "bl_positions" ADD COLUMN IF NOT EXISTS "capacity_pallets" INTEGER CHECK("capacity_pallets" >= 0);
+ALTER TABLE
+    "bl_positions" ADD COLUMN IF NOT EXISTS "capacity_cbm" NUMERIC(10, 2) CHECK("capacity_cbm" >= 0);
+ALTER TABLE
+    "bl_positions" ADD COLUMN IF NOT EXISTS "path" TEXT NOT NULL DEFAULT '';
+ALTER TABLE
+    "bl_positions" ADD COLUMN IF NOT EXISTS "depth" SMALLINT NOT NULL DEFAULT 0;
+
+UPDATE "bl_positions" SET "path" = "name" WHERE "path" = '';
+
+ALTER TABLE
+    "bl_positions" ADD CONSTRAINT "bl_positions_parent_id_foreign" FOREIGN KEY("parent_id") REFERENCES "bl_positions"("id");
+CREATE INDEX IF NOT EXISTS "bl_positions_parent_id_index" ON
+    "bl_positions"("parent_id");
+
+-- Names only need to be unique among siblings now.
+DROP INDEX IF EXISTS "bl_positions_name_unique";
+CREATE UNIQUE INDEX IF NOT EXISTS "bl_positions_parent_name_unique" ON
+    "bl_positions"(COALESCE("parent_id", 0), lower("name"));
+
+COMMENT
+ON COLUMN
+    "bl_positions"."location_type" IS '구역,열,랙,단';
+COMMENT
+ON COLUMN
+    "bl_positions"."path" IS '상위 위치를 포함한 전체 이름 (예: A > 01 > R3 > L2)';
+COMMENT
+ON COLUMN
+    "bl_positions"."capacity_pallets" IS '적재 가능 팔레트 수';
+COMMENT
+ON COLUMN
+    "bl_positions"."capacity_cbm" IS '적재 가능 용적 (CBM)';
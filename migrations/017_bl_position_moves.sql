@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS "bl_position_moves"(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "bl_marking_id" BIGINT NOT NULL,
+    "from_position_id" BIGINT,
+    "to_position_id" BIGINT,
+    "from_path" TEXT NOT NULL DEFAULT '',
+    "to_path" TEXT NOT NULL DEFAULT '',
+    "source" VARCHAR(20) CHECK
+    ("source" IN('mobile_scan', 'admin', 'import', 'import_rollback')) NOT NULL,
+    "user_id" BIGINT,
+    "device" VARCHAR(255) NOT NULL DEFAULT '',
+    "moved_at" TIMESTAMP(0) WITH
+        TIME zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "bl_position_moves_bl_marking_id_index" ON
+    "bl_position_moves"("bl_marking_id", "moved_at");
+ALTER TABLE
+    "bl_position_moves" ADD CONSTRAINT "bl_position_moves_bl_marking_id_foreign" FOREIGN KEY("bl_marking_id") REFERENCES "bl_markings"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "bl_position_moves" ADD CONSTRAINT "bl_position_moves_from_position_id_foreign" FOREIGN KEY("from_position_id") REFERENCES "bl_positions"("id") ON DELETE SET NULL;
+ALTER TABLE
+    "bl_position_moves" ADD CONSTRAINT "bl_position_moves_to_position_id_foreign" FOREIGN KEY("to_position_id") REFERENCES "bl_positions"("id") ON DELETE SET NULL;
+ALTER TABLE
+    "bl_position_moves" ADD CONSTRAINT "bl_position_moves_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL;
+COMMENT
+ON COLUMN
+    "bl_position_moves"."from_path" IS '이동 당시 위치 전체 이름 (위치가 바뀌거나 삭제돼도 남김)';
+COMMENT
+ON COLUMN
+    "bl_position_moves"."source" IS '모바일 스캔,관리자 수정,엑셀 업로드,업로드 취소';
+COMMENT
+ON COLUMN
+    "bl_position_moves"."device" IS '요청한 기기의 User-Agent';
@@ -0,0 +1,5 @@
+ALTER TABLE
+    "import_batch_rows" ADD COLUMN IF NOT EXISTS "old_is_active" BOOLEAN;
+COMMENT
+ON COLUMN
+    "import_batch_rows"."old_is_active" IS '반영 전 활성 여부 (비활성 BL 재활성화 시 false)';